@@ -10,3 +10,9 @@ for f in $SIMFILES; do
     gofem $f
     go run doplot-"$f".go
 done
+
+# generalized-α method
+echo
+echo "[1;33m>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>> sg114ga <<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<<[0m"
+gofem sg114ga
+go run doplot-sg114.go sg114ga.sim
@@ -0,0 +1,235 @@
+// Copyright 2015 Dorival Pedroso and Raul Durand. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fem
+
+import (
+	"bytes"
+	"os"
+	"path"
+
+	"github.com/cpmech/gosl/chk"
+	"github.com/cpmech/gosl/io"
+	"github.com/cpmech/gosl/utl"
+)
+
+// Checkpoint holds the complete state of a domain (in one processor); e.g. to restart simulations
+//  Note: (1) nodal values are recorded by vertex id and dof key; thus checkpoints can be loaded
+//            by stages with a different set of active elements
+//        (2) the history of time steps is not recorded; e.g. BDF2 restarts with backward Euler
+type Checkpoint struct {
+
+	// simulation
+	Dirout   string    // directory of simulation that saved this checkpoint
+	Fnkey    string    // filename key of simulation that saved this checkpoint
+	StgIdx   int       // stage index
+	Tidx     int       // next time output index
+	OutTimes []float64 // [Tidx] output times before this checkpoint
+
+	// elements
+	Inact []int    // tags of inactive elements
+	Cids  []int    // ids of cells of elements in this processor
+	Ivs   [][]byte // [len(Cids)] encoded internal variables of elements
+
+	// nodes
+	T      float64   // time
+	Vids   []int     // [ndofs] vertex id of each degree-of-freedom
+	Keys   []string  // [ndofs] key of each degree-of-freedom; e.g. "ux"
+	Y      []float64 // [ndofs] primary variables
+	Dydt   []float64 // [ndofs] first time derivatives; nil if steady
+	D2ydt2 []float64 // [ndofs] second time derivatives; nil if steady
+	L      []float64 // Lagrange multipliers
+}
+
+// SaveCheckpoint saves the complete state of this domain to a file
+//  Input:
+//   fn      -- filename with path; e.g. from ckp_path
+//   sum     -- summary with output times; may be nil
+//   verbose -- show message
+func (o *Domain) SaveCheckpoint(fn string, sum *Summary, verbose bool) (err error) {
+
+	// simulation
+	var ckp Checkpoint
+	ckp.Dirout = o.Sim.DirOut
+	ckp.Fnkey = o.Sim.Key
+	ckp.StgIdx = o.stgidx
+	if sum != nil {
+		ckp.Tidx = sum.tidx
+		ckp.OutTimes = make([]float64, len(sum.OutTimes))
+		copy(ckp.OutTimes, sum.OutTimes)
+	}
+
+	// elements
+	for _, edat := range o.Reg.ElemsData {
+		if edat.Inact {
+			ckp.Inact = append(ckp.Inact, edat.Tag)
+		}
+	}
+	ckp.Cids = o.MyCids
+	ckp.Ivs = make([][]byte, len(o.Elems))
+	for i, e := range o.Elems {
+		var buf bytes.Buffer
+		err = e.Encode(GetEncoder(&buf, o.Sim.EncType))
+		if err != nil {
+			return chk.Err("cannot encode element %d:\n%v", o.MyCids[i], err)
+		}
+		ckp.Ivs[i] = buf.Bytes()
+	}
+
+	// nodes
+	ckp.T = o.Sol.T
+	steady := len(o.Sol.Dydt) == 0
+	for _, nod := range o.Nodes {
+		for _, dof := range nod.Dofs {
+			ckp.Vids = append(ckp.Vids, nod.Vert.Id)
+			ckp.Keys = append(ckp.Keys, dof.Key)
+			ckp.Y = append(ckp.Y, o.Sol.Y[dof.Eq])
+			if !steady {
+				ckp.Dydt = append(ckp.Dydt, o.Sol.Dydt[dof.Eq])
+				ckp.D2ydt2 = append(ckp.D2ydt2, o.Sol.D2ydt2[dof.Eq])
+			}
+		}
+	}
+	ckp.L = o.Sol.L
+
+	// save file
+	var buf bytes.Buffer
+	enc := GetEncoder(&buf, o.Sim.EncType)
+	err = enc.Encode(&ckp)
+	if err != nil {
+		return chk.Err("cannot encode checkpoint:\n%v", err)
+	}
+	return save_file(fn, &buf, verbose)
+}
+
+// ReadCheckpoint reads a checkpoint file; the state is then set by SetStage and SetIniVals
+func (o *Domain) ReadCheckpoint(fn string) (err error) {
+
+	// open file
+	fil, err := os.Open(fn)
+	if err != nil {
+		return
+	}
+	defer func() {
+		e := fil.Close()
+		if err == nil {
+			err = e
+		}
+	}()
+
+	// decode
+	o.ckp = new(Checkpoint)
+	dec := GetDecoder(fil, o.Sim.EncType)
+	err = dec.Decode(o.ckp)
+	if err != nil {
+		o.ckp = nil
+		return chk.Err("cannot decode checkpoint:\n%v", err)
+	}
+	return
+}
+
+// set_inact_flags sets the inactive flags of elements' data as recorded in checkpoint
+func (o *Domain) set_inact_flags() {
+	for _, edat := range o.Reg.ElemsData {
+		edat.Inact = utl.IntIndexSmall(o.ckp.Inact, edat.Tag) >= 0
+	}
+}
+
+// set_checkpoint sets the solution and internal variables as recorded in checkpoint
+//  Note: (1) all internal variables are first initialised; thus, elements activated after the
+//            checkpoint was saved start from their initial state
+//        (2) time is kept only if the checkpoint was saved by the same stage; otherwise the new
+//            stage starts from zero as usual
+func (o *Domain) set_checkpoint() (err error) {
+
+	// initialise all internal variables
+	for _, e := range o.ElemIntvars {
+		err = e.SetIniIvs(o.Sol, nil)
+		if err != nil {
+			return
+		}
+	}
+
+	// nodes
+	ckp := o.ckp
+	steady := len(o.Sol.Dydt) == 0 || len(ckp.Dydt) == 0
+	for k, vid := range ckp.Vids {
+		if vid < 0 || vid >= len(o.Vid2node) {
+			return chk.Err("checkpoint has invalid vertex id = %d", vid)
+		}
+		nod := o.Vid2node[vid]
+		if nod == nil {
+			continue
+		}
+		eq := nod.GetEq(ckp.Keys[k])
+		if eq < 0 {
+			continue
+		}
+		o.Sol.Y[eq] = ckp.Y[k]
+		if !steady {
+			o.Sol.Dydt[eq] = ckp.Dydt[k]
+			o.Sol.D2ydt2[eq] = ckp.D2ydt2[k]
+		}
+	}
+	if len(ckp.L) == len(o.Sol.L) {
+		copy(o.Sol.L, ckp.L)
+	}
+
+	// elements
+	if len(ckp.Ivs) != len(ckp.Cids) {
+		return chk.Err("checkpoint has inconsistent elements data: %d != %d", len(ckp.Ivs), len(ckp.Cids))
+	}
+	for i, cid := range ckp.Cids {
+		if cid < 0 || cid >= len(o.Cid2elem) {
+			return chk.Err("checkpoint has invalid cell id = %d", cid)
+		}
+		e := o.Cid2elem[cid]
+		if e == nil {
+			continue
+		}
+		err = e.Decode(GetDecoder(bytes.NewReader(ckp.Ivs[i]), o.Sim.EncType))
+		if err != nil {
+			return chk.Err("cannot decode element %d:\n%v", cid, err)
+		}
+	}
+
+	// time
+	o.Sol.T = 0
+	if ckp.StgIdx == o.stgidx {
+		o.Sol.T = ckp.T
+	}
+	return
+}
+
+// restore_from sets output times and the next output index as recorded in checkpoint
+//  Note: nothing is done if the checkpoint was saved by another simulation
+func (o *Summary) restore_from(ckp *Checkpoint, dirout, fnkey string) {
+	if ckp.Dirout != dirout || ckp.Fnkey != fnkey {
+		return
+	}
+	o.OutTimes = make([]float64, len(ckp.OutTimes))
+	copy(o.OutTimes, ckp.OutTimes)
+	o.tidx = ckp.Tidx
+}
+
+// auxiliary ///////////////////////////////////////////////////////////////////////////////////////
+
+// ckp_path returns the filename of a checkpoint
+//  Input:
+//   ckey -- checkpoint key with path; e.g. /tmp/gofem/sim_stg0 (see ckp_key_stg and ckp_key_out)
+//   proc -- processor number
+//   didx -- domain (region) index
+func ckp_path(ckey string, proc, didx int) string {
+	return io.Sf("%s_p%d_d%d.ckp", ckey, proc, didx)
+}
+
+// ckp_key_stg returns the key of checkpoints saved at the end of stages
+func ckp_key_stg(dir, fnkey string, stgidx int) string {
+	return path.Join(dir, io.Sf("%s_stg%d", fnkey, stgidx))
+}
+
+// ckp_key_out returns the key of checkpoints saved at output times
+func ckp_key_out(dir, fnkey string, tidx int) string {
+	return path.Join(dir, io.Sf("%s_out%010d", fnkey, tidx))
+}
@@ -0,0 +1,131 @@
+# Copyright 2015 Dorival Pedroso and Raul Durand. All rights reserved.
+# Use of this source code is governed by a BSD-style
+# license that can be found in the LICENSE file.
+
+# Reference solution of Cook's membrane (cook01.sim) used by Test_cook01 in t_large_test.go
+#
+#  total Lagrangian formulation with the first Piola-Kirchhoff stress of the compressible
+#  neo-Hookean model of msolid/neohooke.go:
+#
+#    P = G.F + (l.ln(J) - G).F^-T
+#
+#  plane strain; mesh cook8.msh (8x8 qua4) with 2x2 Gauss points; E = 240.565 and nu = 0.3;
+#  dead loads at the right edge with total vertical force = 100 applied in 10 equal steps
+#
+#  usage: python cook01.py
+
+import json
+import math
+
+# material
+E, nu = 240.565, 0.3
+G = E / (2.0 * (1.0 + nu))
+l = E * nu / ((1.0 + nu) * (1.0 - 2.0 * nu))
+
+# mesh
+msh = json.load(open('cook8.msh'))
+X = [v['c'] for v in msh['verts']]
+tags = [v['tag'] for v in msh['verts']]
+cells = [c['verts'] for c in msh['cells']]
+neq = 2 * len(X)
+
+# essential boundary conditions and nodal loads for t = 1
+fixed = set()
+fext = [0.0] * neq
+for i, tag in enumerate(tags):
+    if tag == -100:
+        fixed.update([2 * i, 2 * i + 1])
+    if tag == -201:
+        fext[2 * i + 1] = 12.5
+    if tag == -202:
+        fext[2 * i + 1] = 6.25
+
+# integration points and shape functions of qua4
+a = 1.0 / math.sqrt(3.0)
+ips = [(-a, -a), (a, -a), (a, a), (-a, a)]
+rs = [(-1, -1), (1, -1), (1, 1), (-1, 1)]
+
+def dNdR(r, s):
+    return [[rn * (1.0 + s * sn) / 4.0, sn * (1.0 + r * rn) / 4.0] for rn, sn in rs]
+
+def element(verts, u):
+    """ returns the internal forces [8] and the tangent matrix [8][8] of element """
+    fe = [0.0] * 8
+    Ke = [[0.0] * 8 for _ in range(8)]
+    for r, s in ips:
+
+        # gradients of shape functions w.r.t. the reference configuration
+        dN = dNdR(r, s)
+        Jm = [[sum(X[v][i] * dN[m][j] for m, v in enumerate(verts)) for j in range(2)] for i in range(2)]
+        detJ = Jm[0][0] * Jm[1][1] - Jm[0][1] * Jm[1][0]
+        Ji = [[Jm[1][1] / detJ, -Jm[0][1] / detJ], [-Jm[1][0] / detJ, Jm[0][0] / detJ]]
+        G0 = [[dN[m][0] * Ji[0][j] + dN[m][1] * Ji[1][j] for j in range(2)] for m in range(4)]
+
+        # deformation gradient
+        F = [[(1.0 if i == j else 0.0) + sum(u[2 * v + i] * G0[m][j] for m, v in enumerate(verts)) for j in range(2)] for i in range(2)]
+        J = F[0][0] * F[1][1] - F[0][1] * F[1][0]
+        Fi = [[F[1][1] / J, -F[0][1] / J], [-F[1][0] / J, F[0][0] / J]]
+        c = l * math.log(J) - G
+
+        # first Piola-Kirchhoff stress and its derivative w.r.t. F
+        P = [[G * F[i][I] + c * Fi[I][i] for I in range(2)] for i in range(2)]
+        def A(i, I, k, L):
+            return (G if i == k and I == L else 0.0) + l * Fi[I][i] * Fi[L][k] - c * Fi[I][k] * Fi[L][i]
+
+        # internal forces and tangent
+        for m in range(4):
+            for i in range(2):
+                fe[2 * m + i] += sum(P[i][I] * G0[m][I] for I in range(2)) * detJ
+                for n in range(4):
+                    for k in range(2):
+                        Ke[2 * m + i][2 * n + k] += sum(A(i, I, k, L) * G0[m][I] * G0[n][L] for I in range(2) for L in range(2)) * detJ
+    return fe, Ke
+
+def solve(K, b):
+    """ solves K.x = b by Gaussian elimination with partial pivoting """
+    n = len(b)
+    M = [K[i][:] + [b[i]] for i in range(n)]
+    for j in range(n):
+        p = max(range(j, n), key=lambda i: abs(M[i][j]))
+        M[j], M[p] = M[p], M[j]
+        for i in range(j + 1, n):
+            f = M[i][j] / M[j][j]
+            if f != 0.0:
+                for k in range(j, n + 1):
+                    M[i][k] -= f * M[j][k]
+    x = [0.0] * n
+    for i in reversed(range(n)):
+        x[i] = (M[i][n] - sum(M[i][k] * x[k] for k in range(i + 1, n))) / M[i][i]
+    return x
+
+# load steps with Newton-Raphson iterations
+u = [0.0] * neq
+nsteps = 10
+for step in range(1, nsteps + 1):
+    t = float(step) / float(nsteps)
+    for it in range(20):
+        R = [-t * f for f in fext]
+        K = [[0.0] * neq for _ in range(neq)]
+        for verts in cells:
+            fe, Ke = element(verts, u)
+            eqs = [2 * v + i for v in verts for i in range(2)]
+            for m, I in enumerate(eqs):
+                R[I] += fe[m]
+                for n, J in enumerate(eqs):
+                    K[I][J] += Ke[m][n]
+        for I in fixed:
+            R[I] = 0.0
+            K[I] = [0.0] * neq
+            for row in K:
+                row[I] = 0.0
+            K[I][I] = 1.0
+        maxR = max(abs(r) for r in R)
+        if maxR < 1e-10:
+            break
+        du = solve(K, [-r for r in R])
+        u = [ui + dui for ui, dui in zip(u, du)]
+    print('t = %g: %d iterations' % (t, it))
+
+# displacements of top-right corner (vertex 80)
+print('ux = %.15g' % u[160])
+print('uy = %.15g' % u[161])
@@ -35,6 +35,9 @@ type Solution struct {
 	Chi []float64 // t2 star vars; e.g. χ* = α4.u + α5.v + α6.a
 	L   []float64 // Lagrange multipliers
 
+	// reactions
+	React []float64 // reactions at equations with essential bcs / constraints; see Domain.CalcReactions
+
 	// problem definition and constants
 	Steady  bool      // [from Sim] steady simulation
 	Axisym  bool      // [from Sim] axisymmetric
@@ -92,11 +95,28 @@ type Domain struct {
 	Nyb   int // total number of equations: ny + nλ
 
 	// stage: solution and linear solver
-	Sol      *Solution   // solution state
-	Kb       *la.Triplet // Jacobian == dRdy
-	Fb       []float64   // residual == -fb
-	Wb       []float64   // workspace
-	InitLSol bool        // flag telling that linear solver needs to be initialised prior to any further call
+	Sol      *Solution // solution state
+	Kb       *Triplet  // Jacobian == dRdy
+	Fb       []float64 // residual == -fb
+	Wb       []float64 // workspace
+	InitLSol bool      // flag telling that linear solver needs to be initialised prior to any further call
+
+	// stage: quasi-Newton updates
+	qn *QuasiNewton // updates of inverse of Kb; nil if QNmethod is not given
+
+	// stage: generalized-α method
+	fbn []float64 // [nyb] (negative of) residual terms of the previous state; nil if GenAlpha is false
+
+	// stage: reactions (see reactions.go)
+	fr   []float64 // [nyb] (negative of) residuals without constraint terms; from the last assembly of fb
+	frok bool      // fr corresponds to the current (converged) state
+
+	// stage: shared-memory parallelism
+	wrk *elemWorkers // concurrent computations of elements; nil if Data.Nworkers < 2
+
+	// stage: checkpoints
+	stgidx int         // index of current stage
+	ckp    *Checkpoint // checkpoint to be loaded by this stage; nil if Stage.Load is not given
 
 	// for divergence control
 	bkpSol *Solution // backup solution
@@ -118,11 +138,14 @@ func NewDomains(sim *inp.Simulation, dyncfs *DynCoefs, hydsta *HydroStatic, proc
 		doms[i].Reg = reg
 		doms[i].Msh = reg.Msh
 		if distr {
-			if nproc != len(reg.Msh.Part2cells) {
-				chk.Panic("number of processors must be equal to the number of partitions defined in mesh file. %d != %d", nproc, len(reg.Msh.Part2cells))
+			if !reg.Msh.PartitionsOk(nproc) {
+				err := reg.Msh.Partition(nproc)
+				if err != nil {
+					chk.Panic("cannot partition mesh into %d parts:\n%v", nproc, err)
+				}
 			}
 		}
-		doms[i].LinSol = la.GetSolver(sim.LinSol.Name)
+		doms[i].LinSol = get_linsol(&sim.LinSol)
 		doms[i].DynCfs = dyncfs
 		doms[i].HydSta = hydsta
 	}
@@ -135,6 +158,12 @@ func (o *Domain) SetStage(stgidx int) (err error) {
 	// pointer to stage structure
 	stg := o.Sim.Stages[stgidx]
 
+	// restore inactive flags from checkpoint
+	o.stgidx = stgidx
+	if o.ckp != nil {
+		o.set_inact_flags()
+	}
+
 	// backup state
 	if stgidx > 0 {
 		o.create_stage_copy()
@@ -169,7 +198,8 @@ func (o *Domain) SetStage(stgidx int) (err error) {
 	// allocate nodes and cells (active only) -------------------------------------------------------
 
 	// for each cell
-	var eq int // current equation number => total number of equations @ end of loop
+	var eq int    // current equation number => total number of equations @ end of loop
+	var enz []int // number of non-zeros in Kb of each element
 	o.NnzKb = 0
 	for _, cell := range o.Msh.Cells {
 
@@ -235,6 +265,11 @@ func (o *Domain) SetStage(stgidx int) (err error) {
 		mycell := cell.Part == o.Proc // cell belongs to this processor
 		if mycell || !o.Distr {
 
+			// concurrent computations: each cell must have its own shape structure
+			if o.Sim.Data.Nworkers > 1 && cell.Shp != nil && cell.Shp.Nurbs == nil {
+				cell.Shp = cell.Shp.GetCopy()
+			}
+
 			// new element
 			ele, err := NewElem(cell, o.Reg, o.Sim)
 			if err != nil {
@@ -255,6 +290,11 @@ func (o *Domain) SetStage(stgidx int) (err error) {
 			if err != nil {
 				return chk.Err("cannot set element equations:\n%v", err)
 			}
+			nu := 0
+			for _, l := range eqs {
+				nu += len(l)
+			}
+			enz = append(enz, nu*nu)
 
 			// subsets of elements
 			o.add_element_to_subsets(ele)
@@ -273,7 +313,7 @@ func (o *Domain) SetStage(stgidx int) (err error) {
 	// element conditions, essential and natural boundary conditions --------------------------------
 
 	// (re)set constraints and prescribed forces structures
-	o.EssenBcs.Init(o.HydSta)
+	o.EssenBcs.Init(o.HydSta, o.Sim.Solver.Spcs, o.Sim.Solver.Penalty)
 	o.PtNatBcs.Reset()
 
 	// element conditions
@@ -330,7 +370,10 @@ func (o *Domain) SetStage(stgidx int) (err error) {
 						return chk.Err("cannot find function named %q\n", nc.Funcs[j])
 					}
 					if o.YandC[key] {
-						o.EssenBcs.Set(key, []*Node{n}, fcn, nc.Extra)
+						err = o.EssenBcs.Set(key, []*Node{n}, fcn, nc.Extra)
+						if err != nil {
+							return chk.Err("setting of essential boundary conditions failed:\n%v", err)
+						}
 					} else {
 						o.PtNatBcs.Set(o.F2Y[key], n, fcn, nc.Extra)
 					}
@@ -339,6 +382,42 @@ func (o *Domain) SetStage(stgidx int) (err error) {
 		}
 	}
 
+	// multi-point constraints
+	for i, mpc := range stg.Mpcs {
+		nodes := make([]*Node, len(mpc.Tags))
+		for j, tag := range mpc.Tags {
+			verts, ok := o.Msh.VertTag2verts[tag]
+			if !ok || len(verts) != 1 {
+				return chk.Err("multi-point constraint %d: tag = %d must correspond to exactly one vertex", i, tag)
+			}
+			nodes[j] = o.Vid2node[verts[0].Id]
+		}
+		fcn := o.Sim.Functions.Get(mpc.Func)
+		if fcn == nil {
+			return chk.Err("cannot find function named %q\n", mpc.Func)
+		}
+		err = o.EssenBcs.SetMpc(nodes, mpc.Keys, mpc.Coefs, fcn)
+		if err != nil {
+			return chk.Err("setting of multi-point constraint %d failed:\n%v", i, err)
+		}
+	}
+
+	// periodic boundary conditions
+	if stg.Periodic != nil {
+		err = o.set_periodic(stg.Periodic)
+		if err != nil {
+			return chk.Err("setting of periodic boundary conditions failed:\n%v", err)
+		}
+	}
+
+	// ties between regions
+	if len(o.Sim.Ties) > 0 {
+		err = o.set_ties(o.Sim.Ties)
+		if err != nil {
+			return chk.Err("setting of ties between regions failed:\n%v", err)
+		}
+	}
+
 	// resize slices --------------------------------------------------------------------------------
 
 	// t1 and t2 equations
@@ -370,12 +449,52 @@ func (o *Domain) SetStage(stgidx int) (err error) {
 	o.Sol.DynCfs = o.DynCfs
 
 	// linear system and linear solver
-	o.Kb = new(la.Triplet)
+	//  Note: the entries of Kb are kept in I, J and X only if they are read by the iterative
+	//        linear solver or by the elimination/penalty of single-point constraints
+	_, iterative := o.LinSol.(*LinSolIter)
+	readable := iterative || len(o.EssenBcs.Spcs) > 0
+	o.Kb = new(Triplet)
 	o.Fb = make([]float64, o.Nyb)
 	o.Wb = make([]float64, o.Nyb)
-	o.Kb.Init(o.Nyb, o.Nyb, o.NnzKb+2*o.NnzA)
+	o.Kb.Init(o.Nyb, o.Nyb, o.NnzKb+2*o.NnzA+len(o.EssenBcs.Spcs), !iterative, readable)
 	o.InitLSol = true // tell solver that lis has to be initialised before use
 
+	// iterative linear solver: matrix and one block of equations per node
+	if ls, ok := o.LinSol.(*LinSolIter); ok {
+		ls.SetTriplet(o.Kb)
+		blocks := make([][]int, len(o.Nodes))
+		for i, nod := range o.Nodes {
+			for _, dof := range nod.Dofs {
+				blocks[i] = append(blocks[i], dof.Eq)
+			}
+		}
+		ls.SetBlocks(blocks)
+	}
+
+	// quasi-Newton updates
+	o.qn = nil
+	if o.Sim.Solver.QNmethod != "" {
+		o.qn = NewQuasiNewton(o.Sim.Solver.QNmethod, o.Sim.Solver.QNnmax, o.Nyb, func(x, b []float64) error {
+			return o.LinSol.SolveR(x, b, false)
+		})
+	}
+
+	// generalized-α method
+	o.fbn = nil
+	if !o.Sim.Data.Steady && o.Sim.Solver.GenAlpha {
+		o.fbn = make([]float64, o.Nyb)
+	}
+
+	// reactions
+	o.fr = make([]float64, o.Nyb)
+	o.frok = false
+
+	// shared-memory parallelism
+	o.wrk = nil
+	if o.Sim.Data.Nworkers > 1 {
+		o.wrk = new_elem_workers(o.Sim.Data.Nworkers, o.Elems, enz, o.Nyb)
+	}
+
 	// allocate arrays
 	o.Sol.Y = make([]float64, o.Ny)
 	o.Sol.ΔY = make([]float64, o.Ny)
@@ -403,6 +522,11 @@ func (o *Domain) SetIniVals(stgidx int, zeroSol bool) (err error) {
 		o.Sol.Reset(o.Sim.Data.Steady)
 	}
 
+	// load checkpoint
+	if o.ckp != nil {
+		return o.set_checkpoint()
+	}
+
 	// initialise internal variables
 	if stg.HydroSt {
 		err = o.SetHydroSt(stg)
@@ -557,6 +681,7 @@ func (o *Domain) backup() {
 
 // restore restores solution
 func (o *Domain) restore() {
+	o.frok = false
 	o.Sol.T = o.bkpSol.T
 	copy(o.Sol.Y, o.bkpSol.Y)
 	copy(o.Sol.ΔY, o.bkpSol.ΔY)
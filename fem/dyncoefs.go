@@ -11,25 +11,42 @@ import (
 	"github.com/cpmech/gofem/inp"
 )
 
-// DynCoefs calculates θ-method, Newmark's or HHT coefficients.
+// DynCoefs calculates θ-method, BDF2, Newmark's, HHT or generalized-α coefficients.
 //  Notes:
+//   BDF2 -- use variable-step second-order backward differentiation formula for t1 variables ?
+//   if BDF2==True, with ω = h/hp, where hp is the previous (accepted) time step:
+//     dydt(n+1) = β1.y(n+1) - ψ*  with  ψ* = β1.y(n) + β2.dydt(n) + β3.Δy(n)
+//     β1 = (1+2ω)/((1+ω).h),  β2 = 0  and  β3 = ω²/((1+ω).h)
+//   where Δy(n) = y(n) - y(n-1) is the increment of the previous step. The first step
+//   (hp == 0) is performed with the backward Euler method
 //   θ1  -- Newmark parameter (gamma)  [0 <= θ1 <= 1]
 //   θ2  -- Newmark parameter (2*beta) [0 <= θ2 <= 1]
 //   HHT -- use Hilber-Hughes-Taylor method ?
 //   α   -- Hilber-Hughes-Taylor parameter [-1/3 <= α <= 0]
 //   if HHT==True, θ1 and θ2 are automatically calculated for unconditional stability
+//   GenAlpha -- use generalized-α method (Chung and Hulbert 1993) ?
+//   ρ∞       -- generalized-α: spectral radius at infinite frequency [0 <= ρ∞ <= 1]
+//   if GenAlpha==True, αm, αf, θ1 and θ2 are calculated from ρ∞ (second-order accuracy and
+//   optimal high-frequency dissipation). The equilibrium M.a(n+1-αm) + C.v(n+1-αf) +
+//   fint(n+1-αf) = fext(n+1-αf) is divided by (1-αf); thus α1, α2 and α3 are multiplied by
+//   cm = (1-αm)/(1-αf) and the terms corresponding to the previous state are added by the solver
 type DynCoefs struct {
 
 	// input
 	θ, θ1, θ2, α float64
 	HHT          bool
+	GenAlpha     bool
+	ρinf         float64
+	BDF2         bool
 
 	// derived
-	β1, β2     float64
+	β1, β2, β3 float64
 	α1, α2, α3 float64
 	α4, α5, α6 float64
 	α7, α8     float64
+	αm, αf, cm float64
 	hmin       float64
+	hp         float64 // previous (accepted) time step; BDF2 only
 }
 
 // Init initialises this structure
@@ -41,12 +58,35 @@ func (o *DynCoefs) Init(dat *inp.SolverData) {
 	// HHT
 	o.HHT = dat.HHT
 
+	// BDF2
+	o.BDF2 = dat.BDF2
+	o.hp = 0
+
 	// θ-method
 	o.θ = dat.Theta
 	if o.θ < 1e-5 || o.θ > 1.0 {
 		chk.Panic("θ-method requires 1e-5 <= θ <= 1.0 (θ = %v is incorrect)", o.θ)
 	}
 
+	// check
+	if dat.HHT && dat.GenAlpha {
+		chk.Panic("HHT and generalized-α methods cannot be used simultaneously")
+	}
+
+	// generalized-α method
+	o.GenAlpha = dat.GenAlpha
+	if dat.GenAlpha {
+		o.ρinf = dat.GArhoInf
+		if o.ρinf < 0.0 || o.ρinf > 1.0 {
+			chk.Panic("generalized-α method requires: 0 <= ρ∞ <= 1 (ρ∞ = %v is incorrect)", o.ρinf)
+		}
+		o.αm = (2.0*o.ρinf - 1.0) / (o.ρinf + 1.0)
+		o.αf = o.ρinf / (o.ρinf + 1.0)
+		o.θ1 = 0.5 - o.αm + o.αf
+		o.θ2 = (1.0 - o.αm + o.αf) * (1.0 - o.αm + o.αf) / 2.0
+		return
+	}
+
 	// HHT method
 	if dat.HHT {
 		o.α = dat.HHTalp
@@ -87,12 +127,31 @@ func (o *DynCoefs) CalcBetas(Δt float64) (err error) {
 		return chk.Err("θ-method requires h >= %v (h = %v is incorrect)", o.hmin, h)
 	}
 
+	// BDF2
+	if o.BDF2 {
+		o.β1, o.β2, o.β3 = 1.0/h, 0, 0
+		if o.hp > 0 {
+			ω := h / o.hp
+			o.β1 = (1.0 + 2.0*ω) / ((1.0 + ω) * h)
+			o.β3 = ω * ω / ((1.0 + ω) * h)
+		}
+		return
+	}
+
 	// β coefficients
 	o.β1 = 1.0 / (o.θ * h)
 	o.β2 = (1.0 - o.θ) / o.θ
+	o.β3 = 0
 	return
 }
 
+// SetPrevDt sets the previous (accepted) time step used by the BDF2 method
+//  Note: hp = 0 forces the next step to be performed with the backward Euler method; e.g. at the
+//        beginning of a stage when the increments of the previous step are not available
+func (o *DynCoefs) SetPrevDt(hp float64) {
+	o.hp = hp
+}
+
 // CalcAlphas computes only alphas
 func (o *DynCoefs) CalcAlphas(Δt float64) (err error) {
 
@@ -113,6 +172,13 @@ func (o *DynCoefs) CalcAlphas(Δt float64) (err error) {
 	if o.HHT {
 		o.α7, o.α8 = (1.0+o.α)*o.α4, 1.0+o.α
 	}
+
+	// generalized-α method
+	o.cm = 1.0
+	if o.GenAlpha {
+		o.cm = (1.0 - o.αm) / (1.0 - o.αf)
+		o.α1, o.α2, o.α3 = o.cm*o.α1, o.cm*o.α2, o.cm*o.α3
+	}
 	return
 }
 
@@ -120,7 +186,9 @@ func (o *DynCoefs) CalcAlphas(Δt float64) (err error) {
 func (o *DynCoefs) Print() {
 	io.Pfgrey("θ=%v, θ1=%v, θ2=%v, α=%v\n", o.θ, o.θ1, o.θ2, o.α)
 	io.Pfgrey("HHT=%v\n", o.HHT)
-	io.Pfgrey("β1=%v, β2=%v\n", o.β1, o.β2)
+	io.Pfgrey("GenAlpha=%v, ρ∞=%v, αm=%v, αf=%v, cm=%v\n", o.GenAlpha, o.ρinf, o.αm, o.αf, o.cm)
+	io.Pfgrey("BDF2=%v, hp=%v\n", o.BDF2, o.hp)
+	io.Pfgrey("β1=%v, β2=%v, β3=%v\n", o.β1, o.β2, o.β3)
 	io.Pfgrey("α1=%v, α2=%v, α3=%v, α4=%v, α5=%v, α6=%v\n", o.α1, o.α2, o.α3, o.α4, o.α5, o.α6)
 	io.Pfgrey("α7=%v, α8=%v\n", o.α7, o.α8)
 }
@@ -18,6 +18,14 @@ import (
 )
 
 // Beam represents a structural beam element (Euler-Bernoulli, linear elastic)
+//  Note: (1) in 3D, the local x-axis goes from the first to the second node and the local y-axis
+//            is given by the orientation vector vxy in ElemData.Extra; e.g. "!vxy:0,0,1". By
+//            default, the local y-axis is Z × x; i.e. it lies on the global X-Y plane, as in 2D.
+//            The default local y-axis of vertical beams is Y
+//        (2) bending in the local x-y plane uses Izz and bending in the local x-z plane uses Iyy.
+//            The torsional constant J and G (or nu) are also required in 3D
+//        (3) end releases (hinges) are given in ElemData.Extra with the local rotations released
+//            at the first (A) or second (B) node; e.g. "!relA:rz !relB:ry,rz"
 type Beam struct {
 
 	// basic data
@@ -28,23 +36,33 @@ type Beam struct {
 
 	// parameters and properties
 	E   float64 // Young's modulus
+	G   float64 // shear modulus (3D only)
 	A   float64 // cross-sectional area
+	Iyy float64 // Inertia yy (3D only)
 	Izz float64 // Inertia zz
+	J   float64 // torsional constant (3D only)
 	L   float64 // length of beam
 
+	// local system and end releases
+	Vxy []float64   // [3] orientation vector on the local x-y plane (3D only)
+	Rel []int       // released local dofs (hinges); e.g. [2] or [5] in 2D with rz released at node 0 or 1
+	Γ   [][]float64 // [nu][nu] condensation matrix: ua = Γ ⋅ ua(kept) (only if there are releases)
+
 	// for output
 	Nstations int // number of points along beam to generate bending moment / shear force diagrams
 
 	// variables for dynamics
 	Rho  float64  // density of solids
+	Ray  Rayleigh // Rayleigh damping coefficients
 	Gfcn fun.Func // gravity function
 
 	// vectors and matrices
-	T   [][]float64 // global-to-local transformation matrix [nnode*ndim][nnode*ndim]
+	T   [][]float64 // global-to-local transformation matrix [nu][nu]
 	Kl  [][]float64 // local K matrix
 	K   [][]float64 // global K matrix
 	Ml  [][]float64 // local M matrices
 	M   [][]float64 // global M matrices
+	C   [][]float64 // global C matrix (Rayleigh damping)
 	Rus []float64   // residual: Rus = fi - fx
 
 	// problem variables
@@ -53,14 +71,19 @@ type Beam struct {
 	QnL  fun.Func // distributed normal load functions: left
 	QnR  fun.Func // distributed normal load functions: right
 	Qt   fun.Func // distributed tangential load
+	QzL  fun.Func // distributed load along local z (3D only): left
+	QzR  fun.Func // distributed load along local z (3D only): right
 
 	// scratchpad. computed @ each ip
 	grav []float64 // [ndim] gravity vector
 	fi   []float64 // [nu] internal forces
 	ue   []float64 // local u vector
-	ua   []float64 // [6] u aligned with beam system
+	ua   []float64 // [nu] u aligned with beam system
+	fl   []float64 // [nu] nodal forces aligned with beam system
 	ζe   []float64 // local ζ* vector
+	χe   []float64 // local χ* vector
 	fxl  []float64 // local external force vector
+	fxr  []float64 // local external force vector before condensation of releases
 }
 
 // register element
@@ -93,18 +116,13 @@ func init() {
 	// element allocator
 	eallocators["beam"] = func(sim *inp.Simulation, cell *inp.Cell, edat *inp.ElemData, x [][]float64) Elem {
 
-		// check
-		ndim := len(x)
-		if ndim == 3 {
-			chk.Panic("beam is not implemented for 3D yet")
-		}
-
 		// basic data
 		var o Beam
 		o.Cell = cell
 		o.X = x
+		ndim := len(x)
 		ndof := 3 * (ndim - 1)
-		o.Nu = ndof * ndim
+		o.Nu = ndof * 2
 		o.Ndim = ndim
 
 		// parameters
@@ -112,14 +130,23 @@ func init() {
 		if matdata == nil {
 			return nil
 		}
+		var ν float64
 		for _, p := range matdata.Prms {
 			switch p.N {
 			case "E":
 				o.E = p.V
+			case "G":
+				o.G = p.V
+			case "nu":
+				ν = p.V
 			case "A":
 				o.A = p.V
+			case "Iyy":
+				o.Iyy = p.V
 			case "Izz":
 				o.Izz = p.V
+			case "J":
+				o.J = p.V
 			case "rho":
 				o.Rho = p.V
 			}
@@ -128,6 +155,31 @@ func init() {
 		if o.E < ϵp || o.A < ϵp || o.Izz < ϵp || o.Rho < ϵp {
 			chk.Panic("E, A, Izz and rho parameters must be all positive")
 		}
+		if ndim == 3 {
+			if o.G == 0 {
+				o.G = o.E / (2.0 * (1.0 + ν))
+			}
+			if o.G < ϵp || o.Iyy < ϵp || o.J < ϵp {
+				chk.Panic("G (or nu), Iyy and J parameters must be all positive for 3D beams")
+			}
+		}
+
+		// local system and end releases
+		var relA, relB []string
+		o.Vxy, relA, relB = GetBeamFlags(edat.Extra)
+		if ndim == 3 && o.Vxy != nil && len(o.Vxy) != 3 {
+			chk.Panic("orientation vector vxy of beam must have 3 components. vxy=%v is invalid {tag=%d id=%d}", o.Vxy, cell.Tag, cell.Id)
+		}
+		err := o.set_releases(relA, relB)
+		if err != nil {
+			chk.Panic("cannot set end releases of beam element {tag=%d id=%d}:\n%v", cell.Tag, cell.Id, err)
+		}
+
+		// Rayleigh damping
+		o.Ray, err = GetRayleigh(matdata.Prms, edat.Extra)
+		if err != nil {
+			chk.Panic("cannot get Rayleigh damping coefficients for beam element {tag=%d id=%d material=%q}:\n%v", cell.Tag, cell.Id, edat.Mat, err)
+		}
 
 		// for output
 		o.Nstations = 11
@@ -138,10 +190,14 @@ func init() {
 		o.K = la.MatAlloc(o.Nu, o.Nu)
 		o.Ml = la.MatAlloc(o.Nu, o.Nu)
 		o.M = la.MatAlloc(o.Nu, o.Nu)
+		o.C = la.MatAlloc(o.Nu, o.Nu)
 		o.ue = make([]float64, o.Nu)
-		o.ua = make([]float64, 6) // TODO: check this
+		o.ua = make([]float64, o.Nu)
+		o.fl = make([]float64, o.Nu)
 		o.ζe = make([]float64, o.Nu)
+		o.χe = make([]float64, o.Nu)
 		o.fxl = make([]float64, o.Nu)
+		o.fxr = make([]float64, o.Nu)
 		o.Rus = make([]float64, o.Nu)
 
 		// compute K and M
@@ -191,6 +247,18 @@ func (o *Beam) SetEleConds(key string, f fun.Func, extra string) (err error) {
 		o.Hasq, o.QnR = true, f
 	case "qt":
 		o.Hasq, o.Qt = true, f
+	case "qz", "qzL", "qzR":
+		if o.Ndim != 3 {
+			return chk.Err("distributed load %q along local z is only available in 3D", key)
+		}
+		switch key {
+		case "qz":
+			o.Hasq, o.QzL, o.QzR = true, f, f
+		case "qzL":
+			o.Hasq, o.QzL = true, f
+		case "qzR":
+			o.Hasq, o.QzR = true, f
+		}
 	default:
 		return chk.Err("cannot handle boundary condition named %q", key)
 	}
@@ -201,6 +269,7 @@ func (o *Beam) SetEleConds(key string, f fun.Func, extra string) (err error) {
 func (o *Beam) InterpStarVars(sol *Solution) (err error) {
 	for i, I := range o.Umap {
 		o.ζe[i] = sol.Zet[I]
+		o.χe[i] = sol.Chi[I]
 	}
 	return
 }
@@ -218,26 +287,18 @@ func (o *Beam) AddToRhs(fb []float64, sol *Solution) (err error) {
 		la.MatVecMul(o.fi, 1, o.K, o.ue)
 	} else {
 		α1 := sol.DynCfs.α1
+		α4 := sol.DynCfs.α4
 		for i := 0; i < o.Nu; i++ {
 			o.fi[i] = 0
 			for j := 0; j < o.Nu; j++ {
-				o.fi[i] += o.M[i][j]*(α1*o.ue[j]-o.ζe[j]) + o.K[i][j]*o.ue[j]
+				o.fi[i] += o.M[i][j]*(α1*o.ue[j]-o.ζe[j]) + o.C[i][j]*(α4*o.ue[j]-o.χe[j]) + o.K[i][j]*o.ue[j]
 			}
 		}
 	}
 
 	// distributed loads
 	if o.Hasq {
-		dx := o.X[0][1] - o.X[0][0]
-		dy := o.X[1][1] - o.X[1][0]
-		l := math.Sqrt(dx*dx + dy*dy)
-		qnL, qnR, qt := o.calc_loads(sol.T)
-		o.fxl[0] = qt * l / 2.0
-		o.fxl[1] = l * (7.0*qnL + 3.0*qnR) / 20.0
-		o.fxl[2] = l * l * (3.0*qnL + 2.0*qnR) / 60.0
-		o.fxl[3] = qt * l / 2.0
-		o.fxl[4] = l * (3.0*qnL + 7.0*qnR) / 20.0
-		o.fxl[5] = -l * l * (2.0*qnL + 3.0*qnR) / 60.0
+		o.calc_fxl(sol.T)
 		la.MatTrVecMulAdd(o.fi, -1.0, o.T, o.fxl) // Rus -= fx; fx = trans(T) * fxl
 	}
 
@@ -249,7 +310,7 @@ func (o *Beam) AddToRhs(fb []float64, sol *Solution) (err error) {
 }
 
 // adds element K to global Jacobian matrix Kb
-func (o *Beam) AddToKb(Kb *la.Triplet, sol *Solution, firstIt bool) (err error) {
+func (o *Beam) AddToKb(Kb *Triplet, sol *Solution, firstIt bool) (err error) {
 	if sol.Steady {
 		for i, I := range o.Umap {
 			for j, J := range o.Umap {
@@ -259,9 +320,10 @@ func (o *Beam) AddToKb(Kb *la.Triplet, sol *Solution, firstIt bool) (err error)
 		return
 	}
 	α1 := sol.DynCfs.α1
+	α4 := sol.DynCfs.α4
 	for i, I := range o.Umap {
 		for j, J := range o.Umap {
-			Kb.Put(I, J, o.M[i][j]*α1+o.K[i][j])
+			Kb.Put(I, J, o.M[i][j]*α1+o.C[i][j]*α4+o.K[i][j])
 		}
 	}
 	return
@@ -272,6 +334,51 @@ func (o *Beam) Update(sol *Solution) (err error) {
 	return
 }
 
+// explicit dynamics ////////////////////////////////////////////////////////////////////////////////
+
+// AddToLumpedM adds diagonal (lumped) mass matrix to global vector mb
+//  Note: the diagonal of the consistent mass matrix is scaled such that the total mass is preserved
+//        (HRZ lumping); i.e. mt = ρ.A.L/2 for displacements and mr = ρ.A.L³/78 for rotations.
+//        In 3D, the torsional mass is ρ.(Iyy+Izz).L/2 and the local diagonal is rotated to global
+//        axes; see lumped_masses
+func (o *Beam) AddToLumpedM(mb []float64, sol *Solution) (err error) {
+	ml := o.lumped_masses()
+	for i, I := range o.Umap {
+		mb[I] += ml[i]
+	}
+	return
+}
+
+// CritDt returns an estimate of the critical time step of this element
+//  Note: Δtcr = 2 / ωmax where ωmax is bounded by the largest row sum of M⁻¹.|K| (Gershgorin).
+//        Bending usually governs; thus the bar wave speed sqrt(E/ρ) alone is not sufficient.
+//        The global K and the global lumped masses are used; i.e. the same as in explicit runs
+func (o *Beam) CritDt(sol *Solution) (Δtcr float64, err error) {
+	ml := o.lumped_masses()
+	var ω2max float64
+	for i := 0; i < o.Nu; i++ {
+		var rowsum float64
+		for j := 0; j < o.Nu; j++ {
+			rowsum += math.Abs(o.K[i][j])
+		}
+		ω2max = utl.Max(ω2max, rowsum/ml[i])
+	}
+	Δtcr = 2.0 / math.Sqrt(ω2max)
+	return
+}
+
+// mass matrix /////////////////////////////////////////////////////////////////////////////////////
+
+// AddToM adds consistent mass matrix to global matrix M
+func (o *Beam) AddToM(M *la.Triplet, sol *Solution) (err error) {
+	for i, I := range o.Umap {
+		for j, J := range o.Umap {
+			M.Put(I, J, o.M[i][j])
+		}
+	}
+	return
+}
+
 // Encode encodes internal variables
 func (o *Beam) Encode(enc Encoder) (err error) {
 	return
@@ -283,6 +390,7 @@ func (o *Beam) Decode(dec Decoder) (err error) {
 }
 
 // OutIpsData returns data from all integration points for output
+//  Note: the keys are V and M in 2D and N, Vy, Vz, T, My and Mz in 3D
 func (o *Beam) OutIpsData() (data []*OutIpData) {
 	unused := 0
 	ds := 1.0 / float64(o.Nstations-1)
@@ -294,6 +402,16 @@ func (o *Beam) OutIpsData() (data []*OutIpData) {
 		}
 		calc := func(sol *Solution) (vals map[string]float64) {
 			vals = make(map[string]float64)
+			if o.Ndim == 3 {
+				N, Vy, Vz, T, My, Mz := o.CalcResultants(sol, s, unused)
+				vals["N"] = N[0]
+				vals["Vy"] = Vy[0]
+				vals["Vz"] = Vz[0]
+				vals["T"] = T[0]
+				vals["My"] = My[0]
+				vals["Mz"] = Mz[0]
+				return
+			}
 			V, M := o.CalcVandM(sol, s, unused)
 			vals["V"] = V[0]
 			vals["M"] = M[0]
@@ -304,11 +422,111 @@ func (o *Beam) OutIpsData() (data []*OutIpData) {
 	return
 }
 
+// geometric stiffness /////////////////////////////////////////////////////////////////////////////
+
+// AddToKg adds geometric stiffness matrix (due to the current axial force) to global matrix Kg
+//  Note: the axial force is N = E.A.(ua[3] - ua[0])/L (positive if tensile), where ua are the
+//        displacements aligned with the beam; and the local geometric stiffness matrix is consistent
+//        with the cubic interpolation of transverse displacements. In 3D, ua[6] replaces ua[3] and
+//        both bending planes are considered. End releases are condensed with Γ
+func (o *Beam) AddToKg(Kg *la.Triplet, sol *Solution) (err error) {
+
+	// axial force
+	for i, I := range o.Umap {
+		o.ue[i] = sol.Y[I]
+	}
+	la.MatVecMul(o.ua, 1, o.T, o.ue) // ua = T * ue
+	l := o.L
+	ndof := o.Nu / 2
+	N := o.E * o.A * (o.ua[ndof] - o.ua[0]) / l
+
+	// local and global geometric stiffness matrices
+	c := N / (30.0 * l)
+	Kgl := la.MatAlloc(o.Nu, o.Nu)
+	if o.Ndim == 3 {
+		kg := [][]float64{
+			{36 * c, 3 * l * c, -36 * c, 3 * l * c},
+			{3 * l * c, 4 * l * l * c, -3 * l * c, -l * l * c},
+			{-36 * c, -3 * l * c, 36 * c, -3 * l * c},
+			{3 * l * c, -l * l * c, -3 * l * c, 4 * l * l * c},
+		}
+		beam_add_bending_block(Kgl, []int{1, 5, 7, 11}, kg, 1)
+		beam_add_bending_block(Kgl, []int{2, 4, 8, 10}, kg, -1)
+		o.add_kg_to_global(Kg, Kgl)
+		return
+	}
+	Kgl[1][1] = 36 * c
+	Kgl[1][2] = 3 * l * c
+	Kgl[1][4] = -36 * c
+	Kgl[1][5] = 3 * l * c
+	Kgl[2][1] = 3 * l * c
+	Kgl[2][2] = 4 * l * l * c
+	Kgl[2][4] = -3 * l * c
+	Kgl[2][5] = -l * l * c
+	Kgl[4][1] = -36 * c
+	Kgl[4][2] = -3 * l * c
+	Kgl[4][4] = 36 * c
+	Kgl[4][5] = -3 * l * c
+	Kgl[5][1] = 3 * l * c
+	Kgl[5][2] = -l * l * c
+	Kgl[5][4] = -3 * l * c
+	Kgl[5][5] = 4 * l * l * c
+	o.add_kg_to_global(Kg, Kgl)
+	return
+}
+
+// add_kg_to_global condenses releases, transforms and adds the local geometric stiffness to Kg
+func (o *Beam) add_kg_to_global(Kg *la.Triplet, Kgl [][]float64) {
+
+	// end releases
+	if o.Γ != nil {
+		tmp := la.MatAlloc(o.Nu, o.Nu)
+		la.MatTrMul3(tmp, 1, o.Γ, Kgl, o.Γ) // tmp := 1 * trans(Γ) * Kgl * Γ
+		Kgl = tmp
+	}
+
+	// global matrix
+	Kge := la.MatAlloc(o.Nu, o.Nu)
+	la.MatTrMul3(Kge, 1, o.T, Kgl, o.T) // Kge := 1 * trans(T) * Kgl * T
+
+	// add to sparse matrix Kg
+	for i, I := range o.Umap {
+		for j, J := range o.Umap {
+			Kg.Put(I, J, Kge[i][j])
+		}
+	}
+}
+
 // auxiliary ////////////////////////////////////////////////////////////////////////////////////////
 
 // Recompute re-compute matrices after dimensions or parameters are externally changed
 func (o *Beam) Recompute(withM bool) {
 
+	// T and local matrices
+	if o.Ndim == 3 {
+		o.local_matrices_3d(withM)
+	} else {
+		o.local_matrices_2d(withM)
+	}
+
+	// end releases
+	if o.Γ != nil {
+		o.condense_releases(withM)
+	}
+
+	// global matrices
+	la.MatTrMul3(o.K, 1, o.T, o.Kl, o.T) // K := 1 * trans(T) * Kl * T
+	if withM {
+		la.MatTrMul3(o.M, 1, o.T, o.Ml, o.T) // M := 1 * trans(T) * Ml * T
+	}
+
+	// C
+	o.Ray.Matrix(o.C, o.M, o.K)
+}
+
+// local_matrices_2d computes T, Kl and Ml in 2D
+func (o *Beam) local_matrices_2d(withM bool) {
+
 	// T
 	dx := o.X[0][1] - o.X[0][0]
 	dy := o.X[1][1] - o.X[1][0]
@@ -353,7 +571,6 @@ func (o *Beam) Recompute(withM bool) {
 	o.Kl[5][2] = 2 * ll * n
 	o.Kl[5][4] = -6 * l * n
 	o.Kl[5][5] = 4 * ll * n
-	la.MatTrMul3(o.K, 1, o.T, o.Kl, o.T) // K := 1 * trans(T) * Kl * T
 
 	// M
 	if withM {
@@ -378,7 +595,6 @@ func (o *Beam) Recompute(withM bool) {
 		o.Ml[5][2] = -3.0 * ll * m
 		o.Ml[5][4] = -22.0 * l * m
 		o.Ml[5][5] = 4.0 * ll * m
-		la.MatTrMul3(o.M, 1, o.T, o.Ml, o.T) // M := 1 * trans(T) * Ml * T
 	}
 }
 
@@ -389,10 +605,17 @@ func (o *Beam) Recompute(withM bool) {
 //  Output:
 //   V -- shear force @ stations or s
 //   M -- bending moment @ stations or s
+//  Note: in 3D, V and M correspond to Vy and Mz; see CalcResultants
 func (o *Beam) CalcVandM(sol *Solution, s float64, nstations int) (V, M []float64) {
 
+	// 3D or with end releases: V and M in the local x-y plane
+	if o.Ndim == 3 || o.Γ != nil {
+		_, V, _, _, _, M = o.CalcResultants(sol, s, nstations)
+		return
+	}
+
 	// aligned displacements
-	for i := 0; i < 6; i++ {
+	for i := 0; i < o.Nu; i++ {
 		o.ua[i] = 0
 		for j, J := range o.Umap {
 			o.ua[i] += o.T[i][j] * sol.Y[J]
@@ -442,6 +665,63 @@ func (o *Beam) calc_V_and_M_after_ua(time, s float64) (V, M float64) {
 	return
 }
 
+// lumped_masses returns the HRZ lumped masses [nu] for displacements (mt) and rotations (mr)
+//  Note: the local diagonal mloc is rotated to global axes and lumped again; i.e. ml is the
+//        diagonal of trans(T) * diag(mloc) * T. Displacements keep mt because each row of T is a
+//        unit vector. In 3D, the rotational masses become mr + (mx - mr) * e1[i]², where e1 is the
+//        beam axis; thus inclined members do not carry the torsional mass about a global axis
+func (o *Beam) lumped_masses() (ml []float64) {
+	mt := o.Rho * o.A * o.L / 2.0
+	mr := o.Rho * o.A * o.L * o.L * o.L / 78.0
+	var mloc []float64
+	if o.Ndim == 2 {
+		mloc = []float64{mt, mt, mr, mt, mt, mr}
+	} else {
+		mx := o.Rho * (o.Iyy + o.Izz) * o.L / 2.0
+		mloc = []float64{mt, mt, mt, mx, mr, mr, mt, mt, mt, mx, mr, mr}
+	}
+	ml = make([]float64, o.Nu)
+	for i := 0; i < o.Nu; i++ {
+		for k := 0; k < o.Nu; k++ {
+			ml[i] += o.T[k][i] * o.T[k][i] * mloc[k]
+		}
+	}
+	return
+}
+
+// calc_fxl computes the local external force vector due to distributed loads
+//  Note: the vector is condensed if there are end releases
+func (o *Beam) calc_fxl(time float64) {
+	l := o.L
+	qnL, qnR, qt := o.calc_loads(time)
+	if o.Ndim == 2 {
+		o.fxr[0] = qt * l / 2.0
+		o.fxr[1] = l * (7.0*qnL + 3.0*qnR) / 20.0
+		o.fxr[2] = l * l * (3.0*qnL + 2.0*qnR) / 60.0
+		o.fxr[3] = qt * l / 2.0
+		o.fxr[4] = l * (3.0*qnL + 7.0*qnR) / 20.0
+		o.fxr[5] = -l * l * (2.0*qnL + 3.0*qnR) / 60.0
+	} else {
+		qzL, qzR := o.calc_loads_z(time)
+		la.VecFill(o.fxr, 0)
+		o.fxr[0] = qt * l / 2.0
+		o.fxr[1] = l * (7.0*qnL + 3.0*qnR) / 20.0
+		o.fxr[2] = l * (7.0*qzL + 3.0*qzR) / 20.0
+		o.fxr[4] = -l * l * (3.0*qzL + 2.0*qzR) / 60.0
+		o.fxr[5] = l * l * (3.0*qnL + 2.0*qnR) / 60.0
+		o.fxr[6] = qt * l / 2.0
+		o.fxr[7] = l * (3.0*qnL + 7.0*qnR) / 20.0
+		o.fxr[8] = l * (3.0*qzL + 7.0*qzR) / 20.0
+		o.fxr[10] = l * l * (2.0*qzL + 3.0*qzR) / 60.0
+		o.fxr[11] = -l * l * (2.0*qnL + 3.0*qnR) / 60.0
+	}
+	if o.Γ != nil {
+		la.MatTrVecMul(o.fxl, 1, o.Γ, o.fxr) // fxl = trans(Γ) * fxr
+		return
+	}
+	copy(o.fxl, o.fxr)
+}
+
 func (o *Beam) calc_loads(time float64) (qnL, qnR, qt float64) {
 	if o.QnL != nil {
 		qnL = o.QnL.F(time, nil)
@@ -464,43 +744,64 @@ func (o *Beam) calc_loads(time float64) (qnL, qnR, qt float64) {
 //   numfmt   -- number format for values. use "" to chose default one
 //   tolM     -- tolerance to clip absolute values of M
 //   sf       -- scaling factor
+//  Note: in 3D, the diagram of the local Mz (see CalcVandM) is drawn along the local y-axis and
+//        projected onto the X-Y plane
 func (o *Beam) PlotDiagMoment(M []float64, withtext bool, numfmt string, tolM, sf float64) {
+	var ey []float64
+	if o.Ndim == 3 {
+		ey = o.T[1][:3]
+	}
+	beam_plot_diag_moment(o.X, ey, M, withtext, numfmt, tolM, sf)
+}
+
+// beam_plot_diag_moment plots the bending moment diagram of a straight beam
+//  Input:
+//   X  -- matrix of nodal coordinates [ndim][nnode]. the first two nodes are the ends of the beam
+//   ey -- [3] local y-axis; the diagram is drawn along ey (3D only; nil in 2D)
+//   see PlotDiagMoment for the other arguments
+func beam_plot_diag_moment(X [][]float64, ey, M []float64, withtext bool, numfmt string, tolM, sf float64) {
 
 	// number of stations
+	ndim := len(X)
 	nstations := len(M)
 	ds := 1.0 / float64(nstations-1)
 
 	// nodes
 	var xa, xb []float64
 	var u []float64 // out-of-pane vector
-	if o.Ndim == 2 {
-		xa = []float64{o.X[0][0], o.X[1][0], 0}
-		xb = []float64{o.X[0][1], o.X[1][1], 0}
+	if ndim == 2 {
+		xa = []float64{X[0][0], X[1][0], 0}
+		xb = []float64{X[0][1], X[1][1], 0}
 		u = []float64{0, 0, 1}
 	} else {
-		chk.Panic("TODO: 3D beam diagram")
+		xa = []float64{X[0][0], X[1][0], X[2][0]}
+		xb = []float64{X[0][1], X[1][1], X[2][1]}
 	}
 
 	// unit vector along beam
 	v := make([]float64, 3)
 	sum := 0.0
-	for j := 0; j < o.Ndim; j++ {
+	for j := 0; j < ndim; j++ {
 		v[j] = xb[j] - xa[j]
 		sum += v[j] * v[j]
 	}
 	sum = math.Sqrt(sum)
-	for j := 0; j < o.Ndim; j++ {
+	for j := 0; j < ndim; j++ {
 		v[j] /= sum
 	}
 
 	// unit normal
-	n := make([]float64, 3)     // normal
-	utl.CrossProduct3d(n, u, v) // n := u cross v
+	n := make([]float64, 3) // normal
+	if ndim == 2 {
+		utl.CrossProduct3d(n, u, v) // n := u cross v
+	} else {
+		copy(n, ey) // local y-axis; already perpendicular to v
+	}
 
 	// auxiliary vectors
-	x := make([]float64, o.Ndim) // station
-	m := make([]float64, o.Ndim) // vector pointing to other side
-	c := make([]float64, o.Ndim) // centre
+	x := make([]float64, ndim) // station
+	m := make([]float64, ndim) // vector pointing to other side
+	c := make([]float64, ndim) // centre
 	imin, imax := utl.DblArgMinMax(M)
 
 	// draw text function
@@ -527,12 +828,12 @@ func (o *Beam) PlotDiagMoment(M []float64, withtext bool, numfmt string, tolM, s
 
 		// station
 		s := float64(i) * ds
-		for j := 0; j < o.Ndim; j++ {
-			x[j] = (1.0-s)*o.X[j][0] + s*o.X[j][1]
+		for j := 0; j < ndim; j++ {
+			x[j] = (1.0-s)*X[j][0] + s*X[j][1]
 		}
 
 		// auxiliary vectors
-		for j := 0; j < o.Ndim; j++ {
+		for j := 0; j < ndim; j++ {
 			m[j] = x[j] - sf*M[i]*n[j]
 			c[j] = (x[j] + m[j]) / 2.0
 		}
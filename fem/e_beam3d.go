@@ -0,0 +1,302 @@
+// Copyright 2015 Dorival Pedroso and Raul Durand. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fem
+
+import (
+	"github.com/cpmech/gosl/chk"
+	"github.com/cpmech/gosl/la"
+	"github.com/cpmech/gosl/utl"
+)
+
+// 3D frames and end releases
+//  Note: (1) the local dofs of each node are {u, v, w, θx, θy, θz}. Bending in the x-y plane
+//            couples v and θz = dv/dx; whereas bending in the x-z plane couples w and θy = -dw/dx
+//        (2) the mass matrix includes the torsional inertia with the polar moment Iyy + Izz
+//        (3) end releases are condensed out of the local matrices with ua = Γ ⋅ ua(kept) where
+//            ua(released) = -Krr⁻¹ ⋅ Krk ⋅ ua(kept); thus, K := Γᵀ ⋅ K ⋅ Γ, M := Γᵀ ⋅ M ⋅ Γ and
+//            fx := Γᵀ ⋅ fx. The element then gives no stiffness to the released dofs
+//        (4) the stress resultants are computed by the equilibrium of the part of the element on
+//            the left of the station; N, T, My and Mz act on the face with normal along the local
+//            x-axis; Vy = dMz/dx and Vz = -dMy/dx. Thus, Vy and Mz correspond to V and M in 2D
+
+// set_releases sets the released local dofs
+//  Input:
+//   relA -- keys of local rotations released at the first node
+//   relB -- keys of local rotations released at the second node
+func (o *Beam) set_releases(relA, relB []string) (err error) {
+
+	// local dofs
+	ndof := o.Nu / 2
+	idx := map[string]int{"rz": 2}
+	if o.Ndim == 3 {
+		idx = map[string]int{"rx": 3, "ry": 4, "rz": 5}
+	}
+
+	// released dofs
+	o.Rel = []int{}
+	released := make([]bool, o.Nu)
+	for m, keys := range [][]string{relA, relB} {
+		for _, key := range keys {
+			i, ok := idx[key]
+			if !ok {
+				return chk.Err("cannot release %q of beam in %dD", key, o.Ndim)
+			}
+			r := i + m*ndof
+			if released[r] {
+				return chk.Err("end release %q is repeated", key)
+			}
+			released[r] = true
+			o.Rel = append(o.Rel, r)
+		}
+	}
+	if o.Ndim == 3 && released[3] && released[3+ndof] {
+		return chk.Err("torsion (rx) cannot be released at both ends")
+	}
+
+	// condensation matrix
+	if len(o.Rel) > 0 {
+		o.Γ = la.MatAlloc(o.Nu, o.Nu)
+	}
+	return
+}
+
+// local_matrices_3d computes T, Kl and Ml in 3D
+func (o *Beam) local_matrices_3d(withM bool) {
+
+	// local axes
+	e1 := make([]float64, 3)
+	e2 := make([]float64, 3)
+	e3 := make([]float64, 3)
+	for i := 0; i < 3; i++ {
+		e1[i] = o.X[i][1] - o.X[i][0]
+	}
+	l := la.VecNorm(e1)
+	o.L = l
+	for i := 0; i < 3; i++ {
+		e1[i] /= l
+	}
+	if o.Vxy == nil {
+		utl.CrossProduct3d(e2, []float64{0, 0, 1}, e1) // e2 := Z cross e1
+		if la.VecNorm(e2) < 1e-10 {
+			e2[0], e2[1], e2[2] = 0, 1, 0 // vertical beam
+		}
+	} else {
+		p := la.VecDot(o.Vxy, e1)
+		for i := 0; i < 3; i++ {
+			e2[i] = o.Vxy[i] - p*e1[i]
+		}
+		if la.VecNorm(e2) < 1e-10 {
+			chk.Panic("orientation vector vxy=%v of beam must not be parallel to its axis {id=%d}", o.Vxy, o.Id())
+		}
+	}
+	n2 := la.VecNorm(e2)
+	for i := 0; i < 3; i++ {
+		e2[i] /= n2
+	}
+	utl.CrossProduct3d(e3, e1, e2) // e3 := e1 cross e2
+
+	// T
+	la.MatFill(o.T, 0)
+	for b := 0; b < 4; b++ {
+		for j := 0; j < 3; j++ {
+			o.T[0+b*3][j+b*3] = e1[j]
+			o.T[1+b*3][j+b*3] = e2[j]
+			o.T[2+b*3][j+b*3] = e3[j]
+		}
+	}
+
+	// K: axial and torsion
+	la.MatFill(o.Kl, 0)
+	ll := l * l
+	m := o.E * o.A / l
+	t := o.G * o.J / l
+	o.Kl[0][0], o.Kl[0][6], o.Kl[6][0], o.Kl[6][6] = m, -m, -m, m
+	o.Kl[3][3], o.Kl[3][9], o.Kl[9][3], o.Kl[9][9] = t, -t, -t, t
+
+	// K: bending
+	beam_add_bending_block(o.Kl, []int{1, 5, 7, 11}, beam_kb(o.E*o.Izz, l), 1)
+	beam_add_bending_block(o.Kl, []int{2, 4, 8, 10}, beam_kb(o.E*o.Iyy, l), -1)
+
+	// M
+	if withM {
+		la.MatFill(o.Ml, 0)
+		m = o.Rho * o.A * l / 420.0
+		t = o.Rho * (o.Iyy + o.Izz) * l / 6.0
+		o.Ml[0][0], o.Ml[0][6], o.Ml[6][0], o.Ml[6][6] = 140.0*m, 70.0*m, 70.0*m, 140.0*m
+		o.Ml[3][3], o.Ml[3][9], o.Ml[9][3], o.Ml[9][9] = 2.0*t, t, t, 2.0*t
+		mb := [][]float64{
+			{156.0 * m, 22.0 * l * m, 54.0 * m, -13.0 * l * m},
+			{22.0 * l * m, 4.0 * ll * m, 13.0 * l * m, -3.0 * ll * m},
+			{54.0 * m, 13.0 * l * m, 156.0 * m, -22.0 * l * m},
+			{-13.0 * l * m, -3.0 * ll * m, -22.0 * l * m, 4.0 * ll * m},
+		}
+		beam_add_bending_block(o.Ml, []int{1, 5, 7, 11}, mb, 1)
+		beam_add_bending_block(o.Ml, []int{2, 4, 8, 10}, mb, -1)
+	}
+}
+
+// condense_releases condenses the released dofs out of Kl and Ml
+func (o *Beam) condense_releases(withM bool) {
+
+	// Krr⁻¹
+	nr := len(o.Rel)
+	Krr := la.MatAlloc(nr, nr)
+	Kri := la.MatAlloc(nr, nr)
+	for i, r := range o.Rel {
+		for j, s := range o.Rel {
+			Krr[i][j] = o.Kl[r][s]
+		}
+	}
+	_, err := la.MatInv(Kri, Krr, 1e-10)
+	if err != nil {
+		chk.Panic("cannot condense end releases of beam {id=%d}:\n%v", o.Id(), err)
+	}
+
+	// Γ
+	released := make([]bool, o.Nu)
+	for _, r := range o.Rel {
+		released[r] = true
+	}
+	la.MatFill(o.Γ, 0)
+	for k := 0; k < o.Nu; k++ {
+		if released[k] {
+			continue
+		}
+		o.Γ[k][k] = 1
+		for i, r := range o.Rel {
+			for j, s := range o.Rel {
+				o.Γ[r][k] -= Kri[i][j] * o.Kl[s][k]
+			}
+		}
+	}
+
+	// condensed matrices
+	tmp := la.MatAlloc(o.Nu, o.Nu)
+	la.MatTrMul3(tmp, 1, o.Γ, o.Kl, o.Γ) // tmp := 1 * trans(Γ) * Kl * Γ
+	la.MatCopy(o.Kl, 1, tmp)
+	if withM {
+		la.MatTrMul3(tmp, 1, o.Γ, o.Ml, o.Γ) // tmp := 1 * trans(Γ) * Ml * Γ
+		la.MatCopy(o.Ml, 1, tmp)
+	}
+}
+
+// CalcResultants calculates the stress resultants @ s
+//  Input:
+//   s         -- natural coordinate   0 ≤ s ≤ 1
+//   nstations -- compute many values; otherwise, if nstations<2, compute @ s
+//  Output:
+//   N      -- axial force (positive if tensile)
+//   Vy, Vz -- shear forces along local y and z
+//   T      -- torque
+//   My, Mz -- bending moments about local y and z
+//  Note: in 2D, Vz, T and My are zero
+func (o *Beam) CalcResultants(sol *Solution, s float64, nstations int) (N, Vy, Vz, T, My, Mz []float64) {
+
+	// aligned displacements and nodal forces acting on element: fl = Kl * ua - fxl
+	for i := 0; i < o.Nu; i++ {
+		o.ua[i] = 0
+		for j, J := range o.Umap {
+			o.ua[i] += o.T[i][j] * sol.Y[J]
+		}
+	}
+	la.MatVecMul(o.fl, 1, o.Kl, o.ua)
+	if o.Hasq {
+		o.calc_fxl(sol.T)
+		for i := 0; i < o.Nu; i++ {
+			o.fl[i] -= o.fxl[i]
+		}
+	}
+
+	// results
+	if nstations < 2 {
+		n, vy, vz, t, my, mz := o.calc_resultants_after_fl(sol.T, s)
+		N, Vy, Vz, T, My, Mz = []float64{n}, []float64{vy}, []float64{vz}, []float64{t}, []float64{my}, []float64{mz}
+		return
+	}
+	N = make([]float64, nstations)
+	Vy = make([]float64, nstations)
+	Vz = make([]float64, nstations)
+	T = make([]float64, nstations)
+	My = make([]float64, nstations)
+	Mz = make([]float64, nstations)
+	ds := 1.0 / float64(nstations-1)
+	for i := 0; i < nstations; i++ {
+		N[i], Vy[i], Vz[i], T[i], My[i], Mz[i] = o.calc_resultants_after_fl(sol.T, float64(i)*ds)
+	}
+	return
+}
+
+// calc_resultants_after_fl computes the stress resultants @ s by considering the equilibrium of
+// the part of the element on the left of s
+func (o *Beam) calc_resultants_after_fl(time, s float64) (N, Vy, Vz, T, My, Mz float64) {
+
+	// forces and moments acting on the first node
+	var fx, fy, fz, mx, my, mz float64
+	if o.Ndim == 2 {
+		fx, fy, mz = o.fl[0], o.fl[1], o.fl[2]
+	} else {
+		fx, fy, fz, mx, my, mz = o.fl[0], o.fl[1], o.fl[2], o.fl[3], o.fl[4], o.fl[5]
+	}
+	x := s * o.L
+	N = -fx
+	Vy = fy
+	Vz = fz
+	T = -mx
+	My = -my - x*fz
+	Mz = -mz + x*fy
+
+	// distributed loads: q = qL + (qR - qL) x / L
+	if o.Hasq {
+		qnL, qnR, qt := o.calc_loads(time)
+		qzL, qzR := o.calc_loads_z(time)
+		dqn := (qnR - qnL) / o.L
+		dqz := (qzR - qzL) / o.L
+		N -= qt * x
+		Vy += qnL*x + dqn*x*x/2.0
+		Vz += qzL*x + dqz*x*x/2.0
+		My -= qzL*x*x/2.0 + dqz*x*x*x/6.0
+		Mz += qnL*x*x/2.0 + dqn*x*x*x/6.0
+	}
+	return
+}
+
+// calc_loads_z computes the distributed loads along the local z-axis (3D only)
+func (o *Beam) calc_loads_z(time float64) (qzL, qzR float64) {
+	if o.QzL != nil {
+		qzL = o.QzL.F(time, nil)
+	}
+	if o.QzR != nil {
+		qzR = o.QzR.F(time, nil)
+	}
+	return
+}
+
+// beam_kb returns the bending stiffness block of a beam with flexural rigidity EI
+func beam_kb(EI, l float64) [][]float64 {
+	n := EI / (l * l * l)
+	ll := l * l
+	return [][]float64{
+		{12 * n, 6 * l * n, -12 * n, 6 * l * n},
+		{6 * l * n, 4 * ll * n, -6 * l * n, 2 * ll * n},
+		{-12 * n, -6 * l * n, 12 * n, -6 * l * n},
+		{6 * l * n, 2 * ll * n, -6 * l * n, 4 * ll * n},
+	}
+}
+
+// beam_add_bending_block adds a bending block to a local matrix
+//  Input:
+//   idx -- local dofs {u1, θ1, u2, θ2} of the bending plane
+//   b   -- [4][4] block in the x-y plane; i.e. with θ = du/dx
+//   σ   -- sign of rotations: 1 for the x-y plane and -1 for the x-z plane where θy = -dw/dx
+//  Output:
+//   K -- local matrix with b added
+func beam_add_bending_block(K [][]float64, idx []int, b [][]float64, σ float64) {
+	sgn := []float64{1, σ, 1, σ}
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 4; j++ {
+			K[idx[i]][idx[j]] += sgn[i] * sgn[j] * b[i][j]
+		}
+	}
+}
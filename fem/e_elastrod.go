@@ -32,18 +32,22 @@ type ElastRod struct {
 
 	// variables for dynamics
 	Rho  float64  // density of solids
+	Ray  Rayleigh // Rayleigh damping coefficients
 	Gfcn fun.Func // gravity function
 
 	// vectors and matrices
 	T [][]float64 // [ndim][nu] transformation matrix: system aligned to rod => element system
 	K [][]float64 // [nu][nu] element K matrix
 	M [][]float64 // [nu][nu] element M matrix
+	C [][]float64 // [nu][nu] element C matrix (Rayleigh damping); constant, thus not encoded
 
 	// problem variables
 	Umap []int // assembly map (location array/element equations)
 
 	// scratchpad. computed @ each ip
 	ua []float64 // [2] local axial displacements
+	ζe []float64 // [nu] local ζ* vector
+	χe []float64 // [nu] local χ* vector
 }
 
 // register element
@@ -107,10 +111,20 @@ func init() {
 			}
 		}
 
+		// Rayleigh damping
+		var err error
+		o.Ray, err = GetRayleigh(matdata.Prms, edat.Extra)
+		if err != nil {
+			chk.Panic("cannot get Rayleigh damping coefficients for elastic rod element {tag=%d id=%d material=%q}:\n%v", cell.Tag, cell.Id, edat.Mat, err)
+		}
+
 		// vectors and matrices
 		o.K = la.MatAlloc(o.Nu, o.Nu)
 		o.M = la.MatAlloc(o.Nu, o.Nu)
+		o.C = la.MatAlloc(o.Nu, o.Nu)
 		o.ua = make([]float64, 2)
+		o.ζe = make([]float64, o.Nu)
+		o.χe = make([]float64, o.Nu)
 
 		// geometry
 		x0 := o.X[0][0]
@@ -145,6 +159,9 @@ func init() {
 			{0.0, 1.0 * β, 0.0, 2.0 * β},
 		}
 
+		// C matrix
+		o.Ray.Matrix(o.C, o.M, o.K)
+
 		// return new element
 		return &o
 	}
@@ -169,8 +186,10 @@ func (o *ElastRod) SetEqs(eqs [][]int, mixedform_eqs []int) (err error) {
 
 // InterpStarVars interpolates star variables to integration points
 func (o *ElastRod) InterpStarVars(sol *Solution) (err error) {
-	// TODO: dynamics
-	chk.Panic("ElastRod cannot handle dynamics yet")
+	for i, I := range o.Umap {
+		o.ζe[i] = sol.Zet[I]
+		o.χe[i] = sol.Chi[I]
+	}
 	return
 }
 
@@ -185,19 +204,39 @@ func (o *ElastRod) SetEleConds(key string, f fun.Func, extra string) (err error)
 
 // AddToRhs adds -R to global residual vector fb
 func (o *ElastRod) AddToRhs(fb []float64, sol *Solution) (err error) {
+	if sol.Steady {
+		for i, I := range o.Umap {
+			for j, J := range o.Umap {
+				fb[I] -= o.K[i][j] * sol.Y[J] // -fi
+			}
+		}
+		return
+	}
+	α1 := sol.DynCfs.α1
+	α4 := sol.DynCfs.α4
 	for i, I := range o.Umap {
 		for j, J := range o.Umap {
-			fb[I] -= o.K[i][j] * sol.Y[J] // -fi
+			fb[I] -= o.M[i][j]*(α1*sol.Y[J]-o.ζe[j]) + o.C[i][j]*(α4*sol.Y[J]-o.χe[j]) + o.K[i][j]*sol.Y[J]
 		}
 	}
 	return
 }
 
 // AddToKb adds element K to global Jacobian matrix Kb
-func (o *ElastRod) AddToKb(Kb *la.Triplet, sol *Solution, firstIt bool) (err error) {
+func (o *ElastRod) AddToKb(Kb *Triplet, sol *Solution, firstIt bool) (err error) {
+	if sol.Steady {
+		for i, I := range o.Umap {
+			for j, J := range o.Umap {
+				Kb.Put(I, J, o.K[i][j])
+			}
+		}
+		return
+	}
+	α1 := sol.DynCfs.α1
+	α4 := sol.DynCfs.α4
 	for i, I := range o.Umap {
 		for j, J := range o.Umap {
-			Kb.Put(I, J, o.K[i][j])
+			Kb.Put(I, J, o.M[i][j]*α1+o.C[i][j]*α4+o.K[i][j])
 		}
 	}
 	return
@@ -216,6 +255,32 @@ func (o *ElastRod) Update(sol *Solution) (err error) {
 	return
 }
 
+// geometric stiffness /////////////////////////////////////////////////////////////////////////////
+
+// AddToKg adds geometric stiffness matrix (due to the current axial force) to global matrix Kg
+//  Note: Kg = N/L [[I, -I], [-I, I]] where N = E.A.(ua[1] - ua[0])/L is the axial force
+func (o *ElastRod) AddToKg(Kg *la.Triplet, sol *Solution) (err error) {
+	for i := 0; i < 2; i++ {
+		o.ua[i] = 0
+		for j, J := range o.Umap {
+			o.ua[i] += o.T[i][j] * sol.Y[J]
+		}
+	}
+	N := o.E * o.A * (o.ua[1] - o.ua[0]) / o.L
+	for m := 0; m < 2; m++ {
+		for n := 0; n < 2; n++ {
+			sign := 1.0
+			if m != n {
+				sign = -1.0
+			}
+			for i := 0; i < o.Ndim; i++ {
+				Kg.Put(o.Umap[i+m*o.Ndim], o.Umap[i+n*o.Ndim], sign*N/o.L)
+			}
+		}
+	}
+	return
+}
+
 // writer ///////////////////////////////////////////////////////////////////////////////////////////
 
 // Encode encodes internal variables
@@ -296,4 +361,7 @@ func (o *ElastRod) Recompute(withM bool) {
 		o.M[3][1] = 1.0 * β
 		o.M[3][3] = 2.0 * β
 	}
+
+	// C matrix
+	o.Ray.Matrix(o.C, o.M, o.K)
 }
@@ -148,7 +148,7 @@ func init() {
 		nip := len(o.IpsElem)
 
 		// models
-		o.Mdl, err = GetAndInitPorousModel(sim.MatParams, edat.Mat, sim.Key)
+		o.Mdl, err = GetAndInitPorousModel(sim.MatParams, edat.Mat, sim.Key, sim.Data.Nworkers > 1)
 		if err != nil {
 			chk.Panic("cannot get model for p-element {tag=%d id=%d material=%q}:\n%v", cell.Tag, cell.Id, edat.Mat, err)
 		}
@@ -341,7 +341,7 @@ func (o *ElemP) AddToRhs(fb []float64, sol *Solution) (err error) {
 }
 
 // AddToKb adds element K to global Jacobian matrix Kb
-func (o *ElemP) AddToKb(Kb *la.Triplet, sol *Solution, firstIt bool) (err error) {
+func (o *ElemP) AddToKb(Kb *Triplet, sol *Solution, firstIt bool) (err error) {
 
 	// clear matrices
 	la.MatFill(o.Kpp, 0)
@@ -642,6 +642,28 @@ func (o *ElemP) OutIpsData() (data []*OutIpData) {
 	return
 }
 
+// IpVelocity computes the seepage velocity of liquid at integration point idx
+//  Note: wl = nl・wl / nl with nl = (1 - ns)・sl and nl・wl being the filter velocity
+func (o *ElemP) IpVelocity(v []float64, idx int, sol *Solution) (err error) {
+	err = o.ipvars(idx, sol)
+	if err != nil {
+		return
+	}
+	s := o.States[idx]
+	nl := (1.0 - s.A_ns0) * s.A_sl
+	if nl < 1e-10 {
+		return chk.Err("cannot compute seepage velocity because liquid volume fraction is zero. nl = %g", nl)
+	}
+	klr := o.Mdl.Cnd.Klr(s.A_sl)
+	for i := 0; i < o.Ndim; i++ {
+		v[i] = 0
+		for j := 0; j < o.Ndim; j++ {
+			v[i] += klr * o.Mdl.Klsat[i][j] * (o.g[j] - o.gpl[j]/s.A_ρL) / nl
+		}
+	}
+	return
+}
+
 // auxiliary ////////////////////////////////////////////////////////////////////////////////////////
 
 // ipvars computes current values @ integration points. idx == index of integration point
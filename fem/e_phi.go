@@ -5,48 +5,92 @@
 package fem
 
 import (
+	"math"
+
 	"github.com/cpmech/gofem/inp"
 	"github.com/cpmech/gofem/shp"
 	"github.com/cpmech/gosl/chk"
 	"github.com/cpmech/gosl/fun"
+	"github.com/cpmech/gosl/io"
 	"github.com/cpmech/gosl/la"
-
-	// "fmt"
-	"math"
 )
 
 // ElemPhi implementes a general element to solve the following equation
 //     dφ       ∂φ
-//     -- + v . -- = s(x)
+//     -- + v . -- = s(t,x)
 //     dt       ∂x
-// Notes: v is a constant vector
+//  Notes: (1) v is given by the element conditions "vx", "vy" and "vz" as functions of (t,x) or,
+//             if "!vfield:1" is given in the element data (extra), v is computed by another
+//             element with the same vertices; e.g. the seepage velocity of a p-element.
+//             If none of these is given, v = [1, 0] in 2D or [1, 0, 1] in 3D, as the velocity
+//             that was hard-coded in the original formulation; see (6). The source term s is given
+//             by the element condition "s"
+//         (2) with the element condition "reinit", the reinitialisation equation is solved instead
+//                 dφ
+//                 -- + S(φ0) . (|∇φ| - 1) = 0   with   v = S(φ0) . ∇φ / |∇φ|  and  s = S(φ0)
+//                 dτ
+//             where S(φ0) = φ0 / sqrt(φ0² + |∇φ0|² h²) is the smoothed sign function computed
+//             with the values φ0 at the beginning of each time step and h is the size of the
+//             element. Thus, φ turns into a signed distance function as τ increases while its
+//             zero level-set remains (approximately) unchanged
+//         (3) the streamline-upwind Petrov-Galerkin (SUPG) method is used unless "!supg:0" is
+//             given in the element data (extra)
+//         (4) the velocity computed by another element is not differentiated w.r.t the variables
+//             of that element; i.e. the coupling terms are not added to the Jacobian matrix
+//         (5) the linear implicit solver (lin-imp) assembles the Jacobian matrix just once; thus,
+//             it can be used with constant velocities only and cannot solve the reinitialisation
+//             equation
+//         (6) this formulation replaces the original one, which used a Taylor-Galerkin scheme with
+//             its own time discretisation (φ at t-Δt and t+Δt) and worked with lin-imp only. Now,
+//             the starred variables ψ* = β1.φ + β2.dφdt of the θ-method (or BDF2) are used as in
+//             the other elements with first-order time derivatives; e.g. ElemP
 type ElemPhi struct {
-	reinit bool
 
 	// basic data
 	Cell *inp.Cell   // the cell structure
 	X    [][]float64 // [ndim][nnode] matrix of nodal coordinates
-
-	Nu   int // total number of unknowns == number of vertices
-	Ndim int // space dimension
+	Nu   int         // total number of unknowns == number of vertices
+	Ndim int         // space dimension
+	Hele float64     // size of element = (area or volume)^(1/ndim)
 
 	// integration points
 	IpsElem []shp.Ipoint // integration points of element
 	IpsFace []shp.Ipoint // integration points corresponding to faces
 
+	// velocity and source term
+	Vfcns  []fun.Func   // [ndim] velocity functions v(t,x)
+	Sfcn   fun.Func     // source function s(t,x)
+	Vfield bool         // velocity is computed by another element; see Connect
+	Vsrc   ElemVelocity // element computing the velocity field; if Vfield
+	srcIds []int        // ids of cells with the same vertices; candidates for Vsrc
+	v      [][]float64  // [nip][ndim] velocity at integration points
+
+	// flags
+	Reinit bool // solve reinitialisation equation
+	Supg   bool // use SUPG method
+	linimp bool // linear implicit solver; i.e. the Jacobian matrix is assembled just once
+
 	// local starred variables
-	ψs      []float64 // [nip] ψ* = β1.φ + β2.dφdt
-	PhiSign []float64
-	// scratchpad. computed @ each ip
-	K [][]float64 // [nu][nu] consistent tangent matrix
-	M [][]float64
+	ψs  []float64 // [nip] ψ* = β1.φ + β2.dφdt
+	sgn []float64 // [nip] smoothed sign function S(φ0) for reinitialisation
 
 	// problem variables
 	Umap []int // assembly map (location array/element equations)
 
-	v_0 [][]float64 // TODO: Should be input parameter
+	// scratchpad. computed @ each ip
+	φ  float64     // φ
+	gφ []float64   // [ndim] ∇φ
+	s  float64     // source term
+	x  []float64   // [ndim] coordinates of integration point
+	τ  float64     // SUPG stabilisation parameter
+	vG []float64   // [nu] v . ∇N
+	W  []float64   // [nu] weighting functions W = N + τ v . ∇N
+	K  [][]float64 // [nu][nu] consistent tangent matrix
 }
 
+// phi_vdefault holds the default velocity; i.e. the one hard-coded in the original formulation
+var phi_vdefault = []float64{1, 0, 1}
+
 // initialisation ///////////////////////////////////////////////////////////////////////////////////
 
 // register element
@@ -86,18 +130,56 @@ func init() {
 		var err error
 		o.IpsElem, o.IpsFace, err = o.Cell.Shp.GetIps(edat.Nip, edat.Nipf)
 		if err != nil {
-			chk.Panic("cannot allocate integration points of solid element with nip=%d and nipf=%d:\n%v", edat.Nip, edat.Nipf, err)
+			chk.Panic("cannot allocate integration points of phi-element with nip=%d and nipf=%d:\n%v", edat.Nip, edat.Nipf, err)
+		}
+		nip := len(o.IpsElem)
+
+		// size of element
+		var vol float64
+		for _, ip := range o.IpsElem {
+			err = o.Cell.Shp.CalcAtIp(o.X, ip, true)
+			if err != nil {
+				chk.Panic("cannot compute size of phi-element %d:\n%v", cell.Id, err)
+			}
+			vol += o.Cell.Shp.J * ip[3]
+		}
+		o.Hele = math.Pow(vol, 1.0/float64(o.Ndim))
+
+		// flags
+		o.Supg = true
+		if s, found := io.Keycode(edat.Extra, "supg"); found {
+			o.Supg = io.Atob(s)
+		}
+		if s, found := io.Keycode(edat.Extra, "vfield"); found {
+			o.Vfield = io.Atob(s)
+		}
+		o.linimp = sim.Solver.Type == "lin-imp"
+
+		// cells with the same vertices
+		if o.Vfield {
+			msh := cell_mesh(sim, cell)
+			for _, c := range msh.Vert2cells[cell.Verts[0]] {
+				if c != cell && same_verts(c.Verts, cell.Verts) {
+					o.srcIds = append(o.srcIds, c.Id)
+				}
+			}
 		}
 
+		// velocity
+		o.Vfcns = make([]fun.Func, o.Ndim)
+		o.v = la.MatAlloc(nip, o.Ndim)
+
 		// local starred variables
-		nip := len(o.IpsElem)
 		o.ψs = make([]float64, nip)
-		o.PhiSign = make([]float64, nip)
+		o.sgn = make([]float64, nip)
 
 		// scratchpad. computed @ each ip
+		o.gφ = make([]float64, o.Ndim)
+		o.x = make([]float64, o.Ndim)
+		o.vG = make([]float64, o.Nu)
+		o.W = make([]float64, o.Nu)
 		o.K = la.MatAlloc(o.Nu, o.Nu)
-		o.v_0 = la.MatAlloc(nip, o.Ndim)
-		o.reinit = false
+
 		// return new element
 		return &o
 	}
@@ -117,8 +199,56 @@ func (o *ElemPhi) SetEqs(eqs [][]int, mixedform_eqs []int) (err error) {
 	return
 }
 
+// Connect connects this element to the element computing the velocity field
+//  Note: (1) the other element must have the same vertices and integration points
+//        (2) nnzK is zero because the coupling terms are not added to the Jacobian matrix
+func (o *ElemPhi) Connect(cid2elem []Elem, c *inp.Cell) (nnzK int, err error) {
+	if !o.Vfield {
+		return
+	}
+	for _, cid := range o.srcIds {
+		src, ok := cid2elem[cid].(ElemVelocity)
+		if !ok {
+			continue
+		}
+		coords := src.Ipoints()
+		if len(coords) != len(o.IpsElem) {
+			return 0, chk.Err("phi-element %d and element %d computing velocity field must have the same number of integration points. %d != %d", o.Id(), cid, len(o.IpsElem), len(coords))
+		}
+		for idx, ip := range o.IpsElem {
+			x := o.Cell.Shp.IpRealCoords(o.X, ip)
+			for i := 0; i < o.Ndim; i++ {
+				if math.Abs(x[i]-coords[idx][i]) > 1e-10 {
+					return 0, chk.Err("integration point %d of phi-element %d does not coincide with the one of element %d computing velocity field", idx, o.Id(), cid)
+				}
+			}
+		}
+		o.Vsrc = src
+		return
+	}
+	return 0, chk.Err("cannot find element computing velocity field for phi-element %d", o.Id())
+}
+
 // SetEleConds set element conditions
 func (o *ElemPhi) SetEleConds(key string, f fun.Func, extra string) (err error) {
+	switch key {
+	case "vx", "vy", "vz":
+		i := int(key[1] - 'x')
+		if i >= o.Ndim {
+			return chk.Err("velocity component %q cannot be set in %dD", key, o.Ndim)
+		}
+		if o.Vfield {
+			return chk.Err("velocity of phi-element %d is computed by another element and cannot be given by function", o.Id())
+		}
+		o.Vfcns[i] = f
+	case "s":
+		o.Sfcn = f
+	case "reinit":
+		if o.linimp {
+			return chk.Err("reinitialisation of phi-element %d cannot be solved with the linear implicit solver (lin-imp)", o.Id())
+		}
+		o.Reinit = true
+	}
 	return
 }
 
@@ -129,15 +259,32 @@ func (o *ElemPhi) InterpStarVars(sol *Solution) (err error) {
 	for idx, ip := range o.IpsElem {
 
 		// interpolation functions and gradients
-		err = o.Cell.Shp.CalcAtIp(o.X, ip, false)
+		err = o.Cell.Shp.CalcAtIp(o.X, ip, true)
 		if err != nil {
 			return
 		}
+		S := o.Cell.Shp.S
+		G := o.Cell.Shp.G
 
-		//interpolate starred variables
-		o.ψs[idx] = 0
+		//interpolate starred variables and φ0
+		o.ψs[idx], o.φ = 0, 0
+		la.VecFill(o.gφ, 0)
 		for m := 0; m < o.Nu; m++ {
-			o.ψs[idx] += o.Cell.Shp.S[m] * sol.Psi[o.Umap[m]]
+			r := o.Umap[m]
+			o.ψs[idx] += S[m] * sol.Psi[r]
+			o.φ += S[m] * sol.Y[r]
+			for i := 0; i < o.Ndim; i++ {
+				o.gφ[i] += G[m][i] * sol.Y[r]
+			}
+		}
+
+		// smoothed sign function
+		if o.Reinit {
+			o.sgn[idx] = 0
+			den := math.Sqrt(o.φ*o.φ + la.VecDot(o.gφ, o.gφ)*o.Hele*o.Hele)
+			if den > 1e-14 {
+				o.sgn[idx] = o.φ / den
+			}
 		}
 	}
 	return
@@ -146,69 +293,53 @@ func (o *ElemPhi) InterpStarVars(sol *Solution) (err error) {
 // AddToRhs adds -R to global residual vector fb
 func (o *ElemPhi) AddToRhs(fb []float64, sol *Solution) (err error) {
 
-	// auxiliary
-	ndim := o.Ndim
-	nverts := o.Cell.Shp.Nverts
-	dt := sol.Dt * 2
 	// for each integration point
-	for iip, ip := range o.IpsElem {
+	β1 := sol.DynCfs.β1
+	var coef, res float64
+	for idx, ip := range o.IpsElem {
 
-		// interpolation functions and gradients
-		err = o.Cell.Shp.CalcAtIp(o.X, ip, true)
+		// interpolation functions, gradients and variables @ ip
+		err = o.ipvars(idx, sol)
 		if err != nil {
 			return
 		}
+		coef = o.Cell.Shp.J * ip[3]
 
-		// auxiliary variables
-		coef := o.Cell.Shp.J * ip[3]
-		S := o.Cell.Shp.S
-		G := o.Cell.Shp.G
-		o.Speed(S, G, ndim, iip, sol)
-		// add to right hand side vector
-		for m := 0; m < nverts; m++ {
-			r := o.Umap[m] // row in the global vector
+		// residual of equation @ ip
+		res = β1*o.φ - o.ψs[idx] + la.VecDot(o.v[idx], o.gφ) - o.s
 
-			// additional term for reinitialisation process
-			if o.reinit {
-				fb[r] -= coef * S[m] * o.PhiSign[iip]
-			}
-			for n := 0; n < nverts; n++ {
-				fb[r] += coef * (S[m]*S[n] + dt*dt/6.0*(o.v_0[iip][0]*G[m][0]+o.v_0[iip][1]*G[m][1])*(o.v_0[iip][0]*G[n][0]+o.v_0[iip][1]*G[n][1])) * (sol.Y[o.Umap[n]] - sol.Psi[o.Umap[n]]) / dt
-				fb[r] += coef * (S[m] + o.v_0[iip][0]*dt/2.0*G[m][0] + o.v_0[iip][1]*dt/2.0*G[m][1]) * (o.v_0[iip][0]*G[n][0]*sol.Psi[o.Umap[n]] + o.v_0[iip][1]*G[n][1]*sol.Psi[o.Umap[n]])
-			}
+		// add to right hand side vector
+		for m := 0; m < o.Nu; m++ {
+			fb[o.Umap[m]] -= coef * o.W[m] * res
 		}
 	}
 	return
 }
 
 // AddToKb adds element K to global Jacobian matrix Kb
-func (o *ElemPhi) AddToKb(Kb *la.Triplet, sol *Solution, firstIt bool) (err error) {
-
-	// auxiliary
-	nverts := o.Cell.Shp.Nverts
+func (o *ElemPhi) AddToKb(Kb *Triplet, sol *Solution, firstIt bool) (err error) {
 
 	// zero K matrix
 	la.MatFill(o.K, 0)
-	dt := sol.Dt * 2
 
 	// for each integration point
-	for iip, ip := range o.IpsElem {
+	β1 := sol.DynCfs.β1
+	var coef float64
+	for idx, ip := range o.IpsElem {
 
-		// interpolation functions and gradients
-		err = o.Cell.Shp.CalcAtIp(o.X, ip, true)
+		// interpolation functions, gradients and variables @ ip
+		err = o.ipvars(idx, sol)
 		if err != nil {
 			return
 		}
-
-		// auxiliary variables
-		coef := o.Cell.Shp.J * ip[3]
+		coef = o.Cell.Shp.J * ip[3]
 		S := o.Cell.Shp.S
-		G := o.Cell.Shp.G
 
-		// add to right hand side vector
-		for m := 0; m < nverts; m++ {
-			for n := 0; n < nverts; n++ {
-				o.K[m][n] -= coef * (S[m]*S[n] + dt*dt/6.0*(o.v_0[iip][0]*G[m][0]+o.v_0[iip][1]*G[m][1])*(o.v_0[iip][0]*G[n][0]+o.v_0[iip][1]*G[n][1])) / dt
+		// add to K matrix
+		//  Note: with reinitialisation, v . ∇N = S(φ0) ∇φ . ∇N / |∇φ| = d(S(φ0) |∇φ|)/dφ
+		for m := 0; m < o.Nu; m++ {
+			for n := 0; n < o.Nu; n++ {
+				o.K[m][n] += coef * o.W[m] * (β1*S[n] + o.vG[n])
 			}
 		}
 	}
@@ -222,56 +353,6 @@ func (o *ElemPhi) AddToKb(Kb *la.Triplet, sol *Solution, firstIt bool) (err erro
 	return
 }
 
-// TODO: Remove Speed function and treat speed as input parameter
-func (o *ElemPhi) Speed(S []float64, G [][]float64, ndim, iip int, sol *Solution) {
-	// Speed orthogonal if reinit function
-	if o.reinit {
-		var GradPhi []float64
-		var Phi float64
-
-		for inode := 0; inode < 4; inode++ {
-			// phi value at integration point
-			Phi = 0.0
-			// gradient of phi allocation
-			if ndim == 2 {
-				GradPhi = []float64{0., 0.}
-			} else if ndim == 3 {
-				GradPhi = []float64{0., 0., 0.}
-			}
-
-			// Get phi and gradient of phi at integration point level
-			for m := 0; m < 4; m++ {
-				Phi += S[m] * sol.Psi[o.Umap[m]]
-				for j := 0; j < ndim; j++ {
-					GradPhi[j] += G[m][j] * sol.Psi[o.Umap[m]]
-				}
-			}
-		}
-
-		// calculate length of gradient
-		lenGrad := math.Sqrt(math.Pow(GradPhi[0], 2) + math.Pow(GradPhi[1], 2))
-
-		// avoiding division by zero
-		if math.Abs(lenGrad) <= 1.e-11 {
-			lenGrad = 1.e-10
-		}
-
-		// smooth approximation of sign function
-		o.PhiSign[iip] = Phi / math.Sqrt(Phi*Phi+lenGrad/10.0)
-
-		// calculate orthogonal speed at integration point
-		o.v_0[iip][0] = o.PhiSign[iip] * GradPhi[0] / lenGrad
-		o.v_0[iip][1] = o.PhiSign[iip] * GradPhi[1] / lenGrad
-	} else {
-		// Arbitrary speed
-		o.v_0[iip][0] = 1.0
-		o.v_0[iip][1] = 0.0
-		if ndim == 3 {
-			o.v_0[iip][2] = 1.0
-		}
-	}
-}
-
 // Update perform (tangent) update
 func (o *ElemPhi) Update(sol *Solution) (err error) {
 	return
@@ -291,39 +372,137 @@ func (o *ElemPhi) Decode(dec Decoder) (err error) {
 
 // OutIpsData returns data from all integration points for output
 func (o *ElemPhi) OutIpsData() (data []*OutIpData) {
+	gkeys := []string{"Gphix", "Gphiy", "Gphiz"}
+	vkeys := []string{"vx", "vy", "vz"}
+	for i, ip := range o.IpsElem {
+		idx := i
+		calc := func(sol *Solution) (vals map[string]float64) {
+			err := o.ipvars(idx, sol)
+			if err != nil {
+				return
+			}
+			vals = make(map[string]float64)
+			for j := 0; j < o.Ndim; j++ {
+				vals[gkeys[j]] = o.gφ[j]
+				vals[vkeys[j]] = o.v[idx][j]
+			}
+			return
+		}
+		x := o.Cell.Shp.IpRealCoords(o.X, ip)
+		data = append(data, &OutIpData{o.Id(), x, calc})
+	}
+	return
+}
 
-	// for each integration point
-	Gphi := make([]float64, o.Ndim)
-	for _, ip := range o.IpsElem {
+// auxiliary ////////////////////////////////////////////////////////////////////////////////////////
 
-		// interpolation functions and gradients
-		err := o.Cell.Shp.CalcAtIp(o.X, ip, true)
+// ipvars computes current values @ integration points. idx == index of integration point
+func (o *ElemPhi) ipvars(idx int, sol *Solution) (err error) {
+
+	// velocity computed by another element
+	//  Note: before computing interpolation functions because Shp may be shared with other element
+	if o.Vsrc != nil && !o.Reinit {
+		err = o.Vsrc.IpVelocity(o.v[idx], idx, sol)
 		if err != nil {
 			return
 		}
-		G := o.Cell.Shp.G
+	}
 
-		// calculate function
-		calc := func(sol *Solution) (vals map[string]float64) {
+	// interpolation functions and gradients
+	err = o.Cell.Shp.CalcAtIp(o.X, o.IpsElem[idx], true)
+	if err != nil {
+		return
+	}
+	S := o.Cell.Shp.S
+	G := o.Cell.Shp.G
+
+	// φ, ∇φ and x @ ip
+	o.φ = 0
+	la.VecFill(o.gφ, 0)
+	la.VecFill(o.x, 0)
+	for m := 0; m < o.Nu; m++ {
+		r := o.Umap[m]
+		o.φ += S[m] * sol.Y[r]
+		for i := 0; i < o.Ndim; i++ {
+			o.gφ[i] += G[m][i] * sol.Y[r]
+			o.x[i] += S[m] * o.X[i][m]
+		}
+	}
+
+	// velocity and source term
+	v := o.v[idx]
+	o.s = 0
+	if o.Reinit {
+		norm := la.VecNorm(o.gφ)
+		for i := 0; i < o.Ndim; i++ {
+			v[i] = 0
+			if norm > 1e-10 {
+				v[i] = o.sgn[idx] * o.gφ[i] / norm
+			}
+		}
+		o.s = o.sgn[idx]
+	} else {
+		if o.Vsrc == nil {
+			given := false
 			for i := 0; i < o.Ndim; i++ {
-				Gphi[i] = 0
-				for m := 0; m < o.Nu; m++ {
-					Gphi[i] += G[m][i] * sol.Y[o.Umap[m]]
+				v[i] = 0
+				if o.Vfcns[i] != nil {
+					v[i] = o.Vfcns[i].F(sol.T, o.x)
+					given = true
 				}
 			}
-			vals = map[string]float64{
-				"Gphix": Gphi[0],
-				"Gphiy": Gphi[1],
+			if !given {
+				copy(v, phi_vdefault)
 			}
-			if o.Ndim == 3 {
-				vals["Gphiz"] = Gphi[2]
-			}
-			return
 		}
+		if o.Sfcn != nil {
+			o.s = o.Sfcn.F(sol.T, o.x)
+		}
+	}
 
-		// results
-		x := o.Cell.Shp.IpRealCoords(o.X, ip)
-		data = append(data, &OutIpData{o.Id(), x, calc})
+	// SUPG parameter: τ = [(2/Δt)² + (2|v|/he)²]^(-1/2) with he = 2|v| / Σ|v.∇N|
+	var sum float64
+	for m := 0; m < o.Nu; m++ {
+		o.vG[m] = 0
+		for i := 0; i < o.Ndim; i++ {
+			o.vG[m] += v[i] * G[m][i]
+		}
+		sum += math.Abs(o.vG[m])
+	}
+	o.τ = 0
+	if o.Supg {
+		den := sum * sum
+		if !sol.Steady && sol.Dt > 0 {
+			den += 4.0 / (sol.Dt * sol.Dt)
+		}
+		if den > 0 {
+			o.τ = 1.0 / math.Sqrt(den)
+		}
+	}
+
+	// weighting functions
+	for m := 0; m < o.Nu; m++ {
+		o.W[m] = S[m] + o.τ*o.vG[m]
 	}
 	return
 }
+
+// same_verts checks whether a and b hold the same vertices, possibly in different order
+func same_verts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for _, va := range a {
+		found := false
+		for _, vb := range b {
+			if va == vb {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
@@ -402,7 +402,7 @@ func (o *Rjoint) AddToRhs(fb []float64, sol *Solution) (err error) {
 }
 
 // adds element K to global Jacobian matrix Kb
-func (o *Rjoint) AddToKb(Kb *la.Triplet, sol *Solution, firstIt bool) (err error) {
+func (o *Rjoint) AddToKb(Kb *Triplet, sol *Solution, firstIt bool) (err error) {
 
 	// auxiliary
 	rodH := o.Rod.Cell.Shp
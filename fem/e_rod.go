@@ -5,6 +5,8 @@
 package fem
 
 import (
+	"math"
+
 	"github.com/cpmech/gofem/inp"
 	"github.com/cpmech/gofem/msolid"
 	"github.com/cpmech/gofem/shp"
@@ -12,9 +14,12 @@ import (
 	"github.com/cpmech/gosl/chk"
 	"github.com/cpmech/gosl/fun"
 	"github.com/cpmech/gosl/la"
+	"github.com/cpmech/gosl/utl"
 )
 
 // Rod represents a structural rod element (for only axial loads)
+//  Note: the inertia terms are not included in transient simulations yet; nonetheless, the
+//        Rayleigh damping matrix C = α.M + β.K0 uses the consistent mass matrix
 type Rod struct {
 
 	// basic data
@@ -28,6 +33,7 @@ type Rod struct {
 
 	// variables for dynamics
 	Rho  float64  // density of solids
+	Ray  Rayleigh // Rayleigh damping coefficients
 	Gfcn fun.Func // gravity function
 
 	// integration points
@@ -36,6 +42,7 @@ type Rod struct {
 	// vectors and matrices
 	K [][]float64 // element K matrix
 	M [][]float64 // element M matrix
+	C [][]float64 // element C matrix (Rayleigh damping): C = α.M + β.K0 (allocated when needed)
 
 	// problem variables
 	Umap []int // assembly map (location array/element equations)
@@ -98,7 +105,7 @@ func init() {
 		if matdata == nil {
 			chk.Panic("cannot get materials data for rod element {tag=%d id=%d material=%q}", cell.Tag, cell.Id, edat.Mat)
 		}
-		o.Model = msolid.GetOnedSolid(sim.Key, edat.Mat, matdata.Model, false)
+		o.Model = msolid.GetOnedSolid(sim.Key, edat.Mat, matdata.Model, sim.Data.Nworkers > 1)
 		if o.Model == nil {
 			chk.Panic("cannot get model for rod element {tag=%d id=%d material=%q}", cell.Tag, cell.Id, edat.Mat)
 		}
@@ -117,6 +124,12 @@ func init() {
 			}
 		}
 
+		// Rayleigh damping
+		o.Ray, err = GetRayleigh(matdata.Prms, edat.Extra)
+		if err != nil {
+			chk.Panic("cannot get Rayleigh damping coefficients for rod element {tag=%d id=%d material=%q}:\n%v", cell.Tag, cell.Id, edat.Mat, err)
+		}
+
 		// integration points
 		o.IpsElem, _, err = o.Cell.Shp.GetIps(edat.Nip, 0)
 		if err != nil {
@@ -195,47 +208,37 @@ func (o *Rod) AddToRhs(fb []float64, sol *Solution) (err error) {
 			}
 		}
 	}
+
+	// Rayleigh damping
+	if !sol.Steady && o.Ray.On() {
+		err = o.rayleigh_init(sol)
+		if err != nil {
+			return
+		}
+		rayleigh_add_to_rhs(fb, o.C, o.Umap, sol)
+	}
 	return
 }
 
 // AddToKb adds element K to global Jacobian matrix Kb
-func (o *Rod) AddToKb(Kb *la.Triplet, sol *Solution, firstIt bool) (err error) {
-
-	// zero K matrix
-	la.MatFill(o.K, 0)
-	la.MatFill(o.M, 0) // TODO: implement mass matrix
+func (o *Rod) AddToKb(Kb *Triplet, sol *Solution, firstIt bool) (err error) {
 
-	// for each integration point
-	var E float64
-	nverts := o.Cell.Shp.Nverts
-	for idx, ip := range o.IpsElem {
+	// consistent tangent matrix
+	err = o.calc_K(o.K, sol, firstIt)
+	if err != nil {
+		return
+	}
 
-		// interpolation functions, gradients and variables @ ip
-		err = o.ipvars(idx, sol)
+	// Rayleigh damping
+	if !sol.Steady && o.Ray.On() {
+		err = o.rayleigh_init(sol)
 		if err != nil {
 			return
 		}
-
-		// auxiliary
-		coef := ip[3]
-		Jvec := o.Cell.Shp.Jvec3d
-		G := o.Cell.Shp.Gvec
-		J := o.Cell.Shp.J
-
-		// add contribution to consistent tangent matrix
-		for m := 0; m < nverts; m++ {
-			for n := 0; n < nverts; n++ {
-				for i := 0; i < o.Ndim; i++ {
-					for j := 0; j < o.Ndim; j++ {
-						r := i + m*o.Ndim
-						c := j + n*o.Ndim
-						E, err = o.Model.CalcD(o.States[idx], firstIt)
-						if err != nil {
-							return
-						}
-						o.K[r][c] += coef * o.A * E * G[m] * G[n] * Jvec[i] * Jvec[j] / J
-					}
-				}
+		α4 := sol.DynCfs.α4
+		for i := 0; i < o.Nu; i++ {
+			for j := 0; j < o.Nu; j++ {
+				o.K[i][j] += α4 * o.C[i][j]
 			}
 		}
 	}
@@ -285,6 +288,153 @@ func (o *Rod) Update(sol *Solution) (err error) {
 	return
 }
 
+// explicit dynamics ////////////////////////////////////////////////////////////////////////////////
+
+// AddToLumpedM adds diagonal (lumped) mass matrix to global vector mb
+//  Note: the diagonal of the consistent mass matrix is scaled such that the total mass is preserved
+func (o *Rod) AddToLumpedM(mb []float64, sol *Solution) (err error) {
+
+	// diagonal of consistent mass matrix and total mass
+	nverts := o.Cell.Shp.Nverts
+	mdiag := make([]float64, nverts)
+	var mtot, msum float64
+	for idx, ip := range o.IpsElem {
+		err = o.ipvars(idx, sol)
+		if err != nil {
+			return
+		}
+		coef := ip[3] * o.Cell.Shp.J
+		S := o.Cell.Shp.S
+		for m := 0; m < nverts; m++ {
+			mdiag[m] += coef * o.Rho * o.A * S[m] * S[m]
+		}
+		mtot += coef * o.Rho * o.A
+	}
+	for m := 0; m < nverts; m++ {
+		msum += mdiag[m]
+	}
+	if msum < 1e-15 {
+		return chk.Err("Rod: eid=%d: cannot compute lumped mass matrix with rho=%g and A=%g", o.Id(), o.Rho, o.A)
+	}
+
+	// scale diagonal and add to mb
+	for m := 0; m < nverts; m++ {
+		for i := 0; i < o.Ndim; i++ {
+			mb[o.Umap[i+m*o.Ndim]] += mdiag[m] * mtot / msum
+		}
+	}
+	return
+}
+
+// CritDt returns an estimate of the critical time step of this element
+//  Note: Δtcr = h / c where h is the distance between nodes and c = sqrt(E/ρ) is the bar wave speed
+func (o *Rod) CritDt(sol *Solution) (Δtcr float64, err error) {
+
+	// check
+	if o.Rho < 1e-15 {
+		return 0, chk.Err("Rod: eid=%d: critical time step requires positive rho. rho=%g is invalid", o.Id(), o.Rho)
+	}
+
+	// largest modulus and length of rod
+	var E, Emax, L float64
+	for idx, ip := range o.IpsElem {
+		err = o.ipvars(idx, sol)
+		if err != nil {
+			return
+		}
+		E, err = o.Model.CalcD(o.States[idx], true)
+		if err != nil {
+			return
+		}
+		Emax = utl.Max(Emax, E)
+		L += ip[3] * o.Cell.Shp.J
+	}
+
+	// critical time step
+	h := L / float64(o.Cell.Shp.Nverts-1)
+	Δtcr = h / math.Sqrt(Emax/o.Rho)
+	return
+}
+
+// mass matrix /////////////////////////////////////////////////////////////////////////////////////
+
+// AddToM adds consistent mass matrix to global matrix M
+func (o *Rod) AddToM(M *la.Triplet, sol *Solution) (err error) {
+
+	// element mass matrix
+	err = o.calc_M(sol)
+	if err != nil {
+		return
+	}
+
+	// add to sparse matrix M
+	for i, I := range o.Umap {
+		for j, J := range o.Umap {
+			M.Put(I, J, o.M[i][j])
+		}
+	}
+	return
+}
+
+// geometric stiffness /////////////////////////////////////////////////////////////////////////////
+
+// AddToKg adds geometric stiffness matrix (due to the current axial force N = σ.A) to global
+// matrix Kg
+func (o *Rod) AddToKg(Kg *la.Triplet, sol *Solution) (err error) {
+
+	// for each integration point
+	nverts := o.Cell.Shp.Nverts
+	Kge := la.MatAlloc(o.Nu, o.Nu)
+	for idx, ip := range o.IpsElem {
+		err = o.ipvars(idx, sol)
+		if err != nil {
+			return
+		}
+		coef := ip[3] * o.Cell.Shp.J
+		G := o.Cell.Shp.Gvec
+		σ := o.States[idx].Sig
+		for m := 0; m < nverts; m++ {
+			for n := 0; n < nverts; n++ {
+				for i := 0; i < o.Ndim; i++ {
+					r := i + m*o.Ndim
+					c := i + n*o.Ndim
+					Kge[r][c] += coef * o.A * σ * G[m] * G[n]
+				}
+			}
+		}
+	}
+
+	// add to sparse matrix Kg
+	for i, I := range o.Umap {
+		for j, J := range o.Umap {
+			Kg.Put(I, J, Kge[i][j])
+		}
+	}
+	return
+}
+
+// Rayleigh damping ////////////////////////////////////////////////////////////////////////////////
+
+// rayleigh_init computes the Rayleigh damping matrix C = α.M + β.K0 if not computed yet
+//  Note: K0 is computed with the current states and firstIt = true; i.e. the initial stiffness
+func (o *Rod) rayleigh_init(sol *Solution) (err error) {
+	if o.C != nil {
+		return
+	}
+	err = o.calc_M(sol)
+	if err != nil {
+		return
+	}
+	K := la.MatAlloc(o.Nu, o.Nu)
+	err = o.calc_K(K, sol, true)
+	if err != nil {
+		return
+	}
+	o.C = la.MatAlloc(o.Nu, o.Nu)
+	o.Ray.Matrix(o.C, o.M, K)
+	return
+}
+
 // internal variables ///////////////////////////////////////////////////////////////////////////////
 
 // Ipoints returns the real coordinates of integration points [nip][ndim]
@@ -363,16 +513,36 @@ func (o *Rod) Ureset(sol *Solution) (err error) {
 // writer ///////////////////////////////////////////////////////////////////////////////////////////
 
 // Encode encodes internal variables
+//  Note: the Rayleigh damping matrix is also encoded because it is computed with the states
+//        at the first time step (see rayleigh_init)
 func (o *Rod) Encode(enc Encoder) (err error) {
-	return enc.Encode(o.States)
+	err = enc.Encode(o.States)
+	if err != nil {
+		return
+	}
+	if o.Ray.On() {
+		err = enc.Encode(o.C)
+	}
+	return
 }
 
 // Decode decodes internal variables
+//  Note: the Rayleigh damping matrix is nil if it was not computed before encoding
 func (o *Rod) Decode(dec Decoder) (err error) {
 	err = dec.Decode(&o.States)
 	if err != nil {
 		return
 	}
+	if o.Ray.On() {
+		o.C = nil
+		err = dec.Decode(&o.C)
+		if err != nil {
+			return
+		}
+		if len(o.C) == 0 {
+			o.C = nil
+		}
+	}
 	return o.BackupIvs(false)
 }
 
@@ -393,6 +563,75 @@ func (o *Rod) OutIpsData() (data []*OutIpData) {
 
 // auxiliary ////////////////////////////////////////////////////////////////////////////////////////
 
+// calc_K computes the consistent tangent (stiffness) matrix
+func (o *Rod) calc_K(K [][]float64, sol *Solution, firstIt bool) (err error) {
+
+	// zero K matrix
+	la.MatFill(K, 0)
+
+	// for each integration point
+	var E float64
+	nverts := o.Cell.Shp.Nverts
+	for idx, ip := range o.IpsElem {
+
+		// interpolation functions, gradients and variables @ ip
+		err = o.ipvars(idx, sol)
+		if err != nil {
+			return
+		}
+
+		// auxiliary
+		coef := ip[3]
+		Jvec := o.Cell.Shp.Jvec3d
+		G := o.Cell.Shp.Gvec
+		J := o.Cell.Shp.J
+
+		// add contribution to consistent tangent matrix
+		for m := 0; m < nverts; m++ {
+			for n := 0; n < nverts; n++ {
+				for i := 0; i < o.Ndim; i++ {
+					for j := 0; j < o.Ndim; j++ {
+						r := i + m*o.Ndim
+						c := j + n*o.Ndim
+						E, err = o.Model.CalcD(o.States[idx], firstIt)
+						if err != nil {
+							return
+						}
+						K[r][c] += coef * o.A * E * G[m] * G[n] * Jvec[i] * Jvec[j] / J
+					}
+				}
+			}
+		}
+	}
+	return
+}
+
+// calc_M computes the consistent mass matrix o.M
+func (o *Rod) calc_M(sol *Solution) (err error) {
+
+	// for each integration point
+	nverts := o.Cell.Shp.Nverts
+	la.MatFill(o.M, 0)
+	for idx, ip := range o.IpsElem {
+		err = o.ipvars(idx, sol)
+		if err != nil {
+			return
+		}
+		coef := ip[3] * o.Cell.Shp.J
+		S := o.Cell.Shp.S
+		for m := 0; m < nverts; m++ {
+			for i := 0; i < o.Ndim; i++ {
+				r := i + m*o.Ndim
+				for n := 0; n < nverts; n++ {
+					c := i + n*o.Ndim
+					o.M[r][c] += coef * o.Rho * o.A * S[m] * S[n]
+				}
+			}
+		}
+	}
+	return
+}
+
 // ipvars computes current values @ integration points. idx == index of integration point
 func (o *Rod) ipvars(idx int, sol *Solution) (err error) {
 
@@ -0,0 +1,586 @@
+// Copyright 2015 Dorival Pedroso and Raul Durand. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fem
+
+import (
+	"math"
+
+	"github.com/cpmech/gofem/inp"
+	"github.com/cpmech/gofem/shp"
+
+	"github.com/cpmech/gosl/chk"
+	"github.com/cpmech/gosl/fun"
+	"github.com/cpmech/gosl/la"
+)
+
+// TimoBeam represents a structural beam element with shear deformation (Timoshenko, linear elastic)
+//  Note: (1) lin2 or lin3 cells; the vertices must be aligned. u, v and θ are interpolated with the
+//            same shape functions and the shear terms are computed with reduced integration to
+//            avoid shear locking
+//        (2) the shear area As must be given; e.g. As = 5/6 A for rectangular sections
+//        (3) the mass matrix is consistent and includes the rotary inertia ρ.Izz
+//        (4) shear forces and bending moments follow the sign convention of Beam; i.e. V = dM/dx
+//            and M = E.Izz.dθ/dx. They are computed by static equilibrium of the element with
+//            the nodal forces and the distributed loads
+type TimoBeam struct {
+
+	// basic data
+	Cell *inp.Cell   // the cell structure
+	X    [][]float64 // matrix of nodal coordinates [ndim][nnode]
+	Nu   int         // total number of unknowns == 3 * nnode
+	Ndim int         // space dimension
+
+	// parameters and properties
+	E   float64 // Young's modulus
+	G   float64 // shear modulus
+	A   float64 // cross-sectional area
+	As  float64 // shear area
+	Izz float64 // Inertia zz
+	L   float64 // length of beam
+
+	// integration points
+	IpsB []shp.Ipoint // integration points for axial and bending terms, mass and loads
+	IpsS []shp.Ipoint // integration points for shear terms (reduced)
+
+	// for output
+	Nstations int // number of points along beam to generate bending moment / shear force diagrams
+
+	// variables for dynamics
+	Rho  float64  // density of solids
+	Ray  Rayleigh // Rayleigh damping coefficients
+	Gfcn fun.Func // gravity function
+
+	// vectors and matrices
+	T  [][]float64 // global-to-local transformation matrix [nu][nu]
+	Kl [][]float64 // local K matrix
+	K  [][]float64 // global K matrix
+	Ml [][]float64 // local M matrices
+	M  [][]float64 // global M matrices
+	C  [][]float64 // global C matrix (Rayleigh damping)
+	Xl [][]float64 // [2][nnode] local coordinates of nodes; the second row is zero (aligned vertices)
+
+	// problem variables
+	Umap []int    // assembly map (location array/element equations)
+	Hasq bool     // has distributed loads
+	QnL  fun.Func // distributed normal load functions: left
+	QnR  fun.Func // distributed normal load functions: right
+	Qt   fun.Func // distributed tangential load
+
+	// scratchpad
+	fi  []float64 // [nu] internal forces
+	ue  []float64 // local u vector
+	ua  []float64 // [nu] u aligned with beam system
+	fl  []float64 // [nu] nodal forces aligned with beam system
+	ζe  []float64 // local ζ* vector
+	χe  []float64 // local χ* vector
+	fxl []float64 // local external force vector
+}
+
+// register element
+func init() {
+
+	// information allocator
+	infogetters["timobeam"] = func(sim *inp.Simulation, cell *inp.Cell, edat *inp.ElemData) *Info {
+
+		// number of nodes in element
+		nverts := cell.Shp.Nverts
+		if nverts < 0 {
+			return nil // fail
+		}
+
+		// new info
+		var info Info
+
+		// solution variables
+		ykeys := []string{"ux", "uy", "rz"}
+		info.Dofs = make([][]string, nverts)
+		for m := 0; m < nverts; m++ {
+			info.Dofs[m] = ykeys
+		}
+
+		// maps
+		info.Y2F = map[string]string{"ux": "fx", "uy": "fy", "rz": "mz"}
+
+		// t1 and t2 variables
+		info.T2vars = ykeys
+		return &info
+	}
+
+	// element allocator
+	eallocators["timobeam"] = func(sim *inp.Simulation, cell *inp.Cell, edat *inp.ElemData, x [][]float64) Elem {
+
+		// check
+		ndim := len(x)
+		if ndim == 3 {
+			chk.Panic("timobeam is not implemented for 3D yet")
+		}
+		nverts := cell.Shp.Nverts
+		if cell.Shp.Type != "lin2" && cell.Shp.Type != "lin3" {
+			chk.Panic("timobeam requires lin2 or lin3 cells. %q is invalid {tag=%d id=%d}", cell.Shp.Type, cell.Tag, cell.Id)
+		}
+
+		// basic data
+		var o TimoBeam
+		o.Cell = cell
+		o.X = x
+		o.Nu = 3 * nverts
+		o.Ndim = ndim
+
+		// parameters
+		matdata := sim.MatParams.Get(edat.Mat)
+		if matdata == nil {
+			return nil
+		}
+		var ν float64
+		for _, p := range matdata.Prms {
+			switch p.N {
+			case "E":
+				o.E = p.V
+			case "G":
+				o.G = p.V
+			case "nu":
+				ν = p.V
+			case "A":
+				o.A = p.V
+			case "As":
+				o.As = p.V
+			case "Izz":
+				o.Izz = p.V
+			case "rho":
+				o.Rho = p.V
+			}
+		}
+		if o.G == 0 {
+			o.G = o.E / (2.0 * (1.0 + ν))
+		}
+		ϵp := 1e-9
+		if o.E < ϵp || o.G < ϵp || o.A < ϵp || o.As < ϵp || o.Izz < ϵp || o.Rho < ϵp {
+			chk.Panic("E, G (or nu), A, As, Izz and rho parameters must be all positive")
+		}
+
+		// integration points
+		var err error
+		o.IpsB, _, err = cell.Shp.GetIps(nverts, 0)
+		if err != nil {
+			chk.Panic("cannot get integration points of timobeam element {tag=%d id=%d}:\n%v", cell.Tag, cell.Id, err)
+		}
+		o.IpsS, _, err = cell.Shp.GetIps(nverts-1, 0)
+		if err != nil {
+			chk.Panic("cannot get integration points of timobeam element {tag=%d id=%d}:\n%v", cell.Tag, cell.Id, err)
+		}
+
+		// Rayleigh damping
+		o.Ray, err = GetRayleigh(matdata.Prms, edat.Extra)
+		if err != nil {
+			chk.Panic("cannot get Rayleigh damping coefficients for timobeam element {tag=%d id=%d material=%q}:\n%v", cell.Tag, cell.Id, edat.Mat, err)
+		}
+
+		// for output
+		o.Nstations = 11
+
+		// vectors and matrices
+		o.T = la.MatAlloc(o.Nu, o.Nu)
+		o.Kl = la.MatAlloc(o.Nu, o.Nu)
+		o.K = la.MatAlloc(o.Nu, o.Nu)
+		o.Ml = la.MatAlloc(o.Nu, o.Nu)
+		o.M = la.MatAlloc(o.Nu, o.Nu)
+		o.C = la.MatAlloc(o.Nu, o.Nu)
+		o.Xl = la.MatAlloc(2, nverts)
+		o.fi = make([]float64, o.Nu)
+		o.ue = make([]float64, o.Nu)
+		o.ua = make([]float64, o.Nu)
+		o.fl = make([]float64, o.Nu)
+		o.ζe = make([]float64, o.Nu)
+		o.χe = make([]float64, o.Nu)
+		o.fxl = make([]float64, o.Nu)
+
+		// compute K and M
+		err = o.Recompute(true)
+		if err != nil {
+			chk.Panic("cannot compute matrices of timobeam element {tag=%d id=%d}:\n%v", cell.Tag, cell.Id, err)
+		}
+
+		// return new element
+		return &o
+	}
+}
+
+// Id returns the cell Id
+func (o *TimoBeam) Id() int { return o.Cell.Id }
+
+// SetEqs set equations [nnode][3]. Format of eqs == format of info.Dofs
+func (o *TimoBeam) SetEqs(eqs [][]int, mixedform_eqs []int) (err error) {
+	o.Umap = make([]int, o.Nu)
+	for m := 0; m < o.Cell.Shp.Nverts; m++ {
+		for i := 0; i < 3; i++ {
+			o.Umap[i+m*3] = eqs[m][i]
+		}
+	}
+	return
+}
+
+// SetEleConds set element conditions
+func (o *TimoBeam) SetEleConds(key string, f fun.Func, extra string) (err error) {
+
+	// gravity
+	if key == "g" {
+		o.Gfcn = f
+		return
+	}
+
+	// distributed loads
+	switch key {
+	case "qn":
+		o.Hasq, o.QnL, o.QnR = true, f, f
+	case "qnL":
+		o.Hasq, o.QnL = true, f
+	case "qnR":
+		o.Hasq, o.QnR = true, f
+	case "qt":
+		o.Hasq, o.Qt = true, f
+	default:
+		return chk.Err("cannot handle boundary condition named %q", key)
+	}
+	return
+}
+
+// InterpStarVars interpolates star variables to integration points
+func (o *TimoBeam) InterpStarVars(sol *Solution) (err error) {
+	for i, I := range o.Umap {
+		o.ζe[i] = sol.Zet[I]
+		o.χe[i] = sol.Chi[I]
+	}
+	return
+}
+
+// AddToRhs adds -R to global residual vector fb
+func (o *TimoBeam) AddToRhs(fb []float64, sol *Solution) (err error) {
+
+	// node displacements
+	for i, I := range o.Umap {
+		o.ue[i] = sol.Y[I]
+	}
+
+	// steady/dynamics
+	if sol.Steady {
+		la.MatVecMul(o.fi, 1, o.K, o.ue)
+	} else {
+		α1 := sol.DynCfs.α1
+		α4 := sol.DynCfs.α4
+		for i := 0; i < o.Nu; i++ {
+			o.fi[i] = 0
+			for j := 0; j < o.Nu; j++ {
+				o.fi[i] += o.M[i][j]*(α1*o.ue[j]-o.ζe[j]) + o.C[i][j]*(α4*o.ue[j]-o.χe[j]) + o.K[i][j]*o.ue[j]
+			}
+		}
+	}
+
+	// distributed loads
+	if o.Hasq {
+		err = o.calc_fxl(sol.T)
+		if err != nil {
+			return
+		}
+		la.MatTrVecMulAdd(o.fi, -1.0, o.T, o.fxl) // Rus -= fx; fx = trans(T) * fxl
+	}
+
+	// add to fb
+	for i, I := range o.Umap {
+		fb[I] -= o.fi[i]
+	}
+	return
+}
+
+// AddToKb adds element K to global Jacobian matrix Kb
+func (o *TimoBeam) AddToKb(Kb *Triplet, sol *Solution, firstIt bool) (err error) {
+	if sol.Steady {
+		for i, I := range o.Umap {
+			for j, J := range o.Umap {
+				Kb.Put(I, J, o.K[i][j])
+			}
+		}
+		return
+	}
+	α1 := sol.DynCfs.α1
+	α4 := sol.DynCfs.α4
+	for i, I := range o.Umap {
+		for j, J := range o.Umap {
+			Kb.Put(I, J, o.M[i][j]*α1+o.C[i][j]*α4+o.K[i][j])
+		}
+	}
+	return
+}
+
+// Update perform (tangent) update
+func (o *TimoBeam) Update(sol *Solution) (err error) {
+	return
+}
+
+// mass matrix /////////////////////////////////////////////////////////////////////////////////////
+
+// AddToM adds consistent mass matrix to global matrix M
+func (o *TimoBeam) AddToM(M *la.Triplet, sol *Solution) (err error) {
+	for i, I := range o.Umap {
+		for j, J := range o.Umap {
+			M.Put(I, J, o.M[i][j])
+		}
+	}
+	return
+}
+
+// Encode encodes internal variables
+func (o *TimoBeam) Encode(enc Encoder) (err error) {
+	return
+}
+
+// Decode decodes internal variables
+func (o *TimoBeam) Decode(dec Decoder) (err error) {
+	return
+}
+
+// OutIpsData returns data from all integration points for output
+func (o *TimoBeam) OutIpsData() (data []*OutIpData) {
+	unused := 0
+	ds := 1.0 / float64(o.Nstations-1)
+	for i := 0; i < o.Nstations; i++ {
+		s := float64(i) * ds
+		x := make([]float64, o.Ndim)
+		for j := 0; j < o.Ndim; j++ {
+			x[j] = (1.0-s)*o.X[j][0] + s*o.X[j][1]
+		}
+		calc := func(sol *Solution) (vals map[string]float64) {
+			vals = make(map[string]float64)
+			N, V, M := o.CalcNVM(sol, s, unused)
+			vals["N"] = N[0]
+			vals["V"] = V[0]
+			vals["M"] = M[0]
+			return
+		}
+		data = append(data, &OutIpData{o.Id(), x, calc})
+	}
+	return
+}
+
+// auxiliary ////////////////////////////////////////////////////////////////////////////////////////
+
+// Recompute re-compute matrices after dimensions or parameters are externally changed
+func (o *TimoBeam) Recompute(withM bool) (err error) {
+
+	// T
+	nverts := o.Cell.Shp.Nverts
+	dx := o.X[0][1] - o.X[0][0]
+	dy := o.X[1][1] - o.X[1][0]
+	l := math.Sqrt(dx*dx + dy*dy)
+	o.L = l
+	c := dx / l
+	s := dy / l
+	for m := 0; m < nverts; m++ {
+		o.T[0+m*3][0+m*3] = c
+		o.T[0+m*3][1+m*3] = s
+		o.T[1+m*3][0+m*3] = -s
+		o.T[1+m*3][1+m*3] = c
+		o.T[2+m*3][2+m*3] = 1
+	}
+
+	// local coordinates
+	for m := 0; m < nverts; m++ {
+		ax := o.X[0][m] - o.X[0][0]
+		ay := o.X[1][m] - o.X[1][0]
+		o.Xl[0][m] = c*ax + s*ay
+		if math.Abs(-s*ax+c*ay) > 1e-10*l {
+			return chk.Err("vertices of timobeam must be aligned")
+		}
+	}
+
+	// K: axial and bending terms
+	la.MatFill(o.Kl, 0)
+	for _, ip := range o.IpsB {
+		err = o.Cell.Shp.CalcAtIp(o.Xl, ip, true)
+		if err != nil {
+			return
+		}
+		coef := o.Cell.Shp.J * ip[3]
+		G := o.Cell.Shp.Gvec
+		for m := 0; m < nverts; m++ {
+			for n := 0; n < nverts; n++ {
+				o.Kl[0+m*3][0+n*3] += coef * o.E * o.A * G[m] * G[n]
+				o.Kl[2+m*3][2+n*3] += coef * o.E * o.Izz * G[m] * G[n]
+			}
+		}
+	}
+
+	// K: shear terms with γ = dv/dx - θ
+	for _, ip := range o.IpsS {
+		err = o.Cell.Shp.CalcAtIp(o.Xl, ip, true)
+		if err != nil {
+			return
+		}
+		coef := o.Cell.Shp.J * ip[3] * o.G * o.As
+		S := o.Cell.Shp.S
+		G := o.Cell.Shp.Gvec
+		for m := 0; m < nverts; m++ {
+			for n := 0; n < nverts; n++ {
+				o.Kl[1+m*3][1+n*3] += coef * G[m] * G[n]
+				o.Kl[1+m*3][2+n*3] -= coef * G[m] * S[n]
+				o.Kl[2+m*3][1+n*3] -= coef * S[m] * G[n]
+				o.Kl[2+m*3][2+n*3] += coef * S[m] * S[n]
+			}
+		}
+	}
+	la.MatTrMul3(o.K, 1, o.T, o.Kl, o.T) // K := 1 * trans(T) * Kl * T
+
+	// M
+	if withM {
+		la.MatFill(o.Ml, 0)
+		for _, ip := range o.IpsB {
+			err = o.Cell.Shp.CalcAtIp(o.Xl, ip, true)
+			if err != nil {
+				return
+			}
+			coef := o.Cell.Shp.J * ip[3] * o.Rho
+			S := o.Cell.Shp.S
+			for m := 0; m < nverts; m++ {
+				for n := 0; n < nverts; n++ {
+					o.Ml[0+m*3][0+n*3] += coef * o.A * S[m] * S[n]
+					o.Ml[1+m*3][1+n*3] += coef * o.A * S[m] * S[n]
+					o.Ml[2+m*3][2+n*3] += coef * o.Izz * S[m] * S[n]
+				}
+			}
+		}
+		la.MatTrMul3(o.M, 1, o.T, o.Ml, o.T) // M := 1 * trans(T) * Ml * T
+	}
+
+	// C
+	o.Ray.Matrix(o.C, o.M, o.K)
+	return
+}
+
+// CalcVandM calculate shear force and bending moment @ s
+//  Input:
+//   s         -- natural coordinate   0 ≤ s ≤ 1
+//   nstations -- compute many values; otherwise, if nstations<2, compute @ s
+//  Output:
+//   V -- shear force @ stations or s
+//   M -- bending moment @ stations or s
+func (o *TimoBeam) CalcVandM(sol *Solution, s float64, nstations int) (V, M []float64) {
+	_, V, M = o.CalcNVM(sol, s, nstations)
+	return
+}
+
+// CalcNVM calculate axial force, shear force and bending moment @ s
+//  Input:
+//   s         -- natural coordinate   0 ≤ s ≤ 1
+//   nstations -- compute many values; otherwise, if nstations<2, compute @ s
+//  Output:
+//   N -- axial force @ stations or s (positive if tensile)
+//   V -- shear force @ stations or s
+//   M -- bending moment @ stations or s
+func (o *TimoBeam) CalcNVM(sol *Solution, s float64, nstations int) (N, V, M []float64) {
+
+	// aligned displacements and nodal forces acting on element: fl = Kl * ua - fxl
+	for i := 0; i < o.Nu; i++ {
+		o.ua[i] = 0
+		for j, J := range o.Umap {
+			o.ua[i] += o.T[i][j] * sol.Y[J]
+		}
+	}
+	la.MatVecMul(o.fl, 1, o.Kl, o.ua)
+	if o.Hasq {
+		o.calc_fxl(sol.T)
+		for i := 0; i < o.Nu; i++ {
+			o.fl[i] -= o.fxl[i]
+		}
+	}
+
+	// results
+	if nstations < 2 {
+		n, v, m := o.calc_NVM_after_fl(sol.T, s)
+		N, V, M = []float64{n}, []float64{v}, []float64{m}
+		return
+	}
+	N = make([]float64, nstations)
+	V = make([]float64, nstations)
+	M = make([]float64, nstations)
+	ds := 1.0 / float64(nstations-1)
+	for i := 0; i < nstations; i++ {
+		N[i], V[i], M[i] = o.calc_NVM_after_fl(sol.T, float64(i)*ds)
+	}
+	return
+}
+
+// PlotDiagMoment plots bending moment diagram
+//  Input:
+//   M        -- moment along stations
+//   withtext -- show bending moment values
+//   numfmt   -- number format for values. use "" to chose default one
+//   tolM     -- tolerance to clip absolute values of M
+//   sf       -- scaling factor
+func (o *TimoBeam) PlotDiagMoment(M []float64, withtext bool, numfmt string, tolM, sf float64) {
+	beam_plot_diag_moment(o.X, nil, M, withtext, numfmt, tolM, sf)
+}
+
+// calc_NVM_after_fl computes N, V and M @ s by considering the equilibrium of the part of
+// the element on the left of s
+func (o *TimoBeam) calc_NVM_after_fl(time, s float64) (N, V, M float64) {
+
+	// forces from nodes on the left; the right end (node 1) is always excluded
+	x := s * o.L
+	for m := 0; m < o.Cell.Shp.Nverts; m++ {
+		xm := o.Xl[0][m]
+		if m == 1 || (m > 1 && xm >= x) {
+			continue
+		}
+		N -= o.fl[0+m*3]
+		V += o.fl[1+m*3]
+		M += (x-xm)*o.fl[1+m*3] - o.fl[2+m*3]
+	}
+
+	// distributed loads: q = qnL + (qnR - qnL) x / L
+	if o.Hasq {
+		qnL, qnR, qt := o.calc_loads(time)
+		dq := (qnR - qnL) / o.L
+		N -= qt * x
+		V += qnL*x + dq*x*x/2.0
+		M += qnL*x*x/2.0 + dq*x*x*x/6.0
+	}
+	return
+}
+
+// calc_fxl computes the local external force vector due to distributed loads
+func (o *TimoBeam) calc_fxl(time float64) (err error) {
+	qnL, qnR, qt := o.calc_loads(time)
+	la.VecFill(o.fxl, 0)
+	for _, ip := range o.IpsB {
+		err = o.Cell.Shp.CalcAtIp(o.Xl, ip, true)
+		if err != nil {
+			return
+		}
+		coef := o.Cell.Shp.J * ip[3]
+		S := o.Cell.Shp.S
+		var x float64
+		for m := 0; m < o.Cell.Shp.Nverts; m++ {
+			x += S[m] * o.Xl[0][m]
+		}
+		qn := qnL + (qnR-qnL)*x/o.L
+		for m := 0; m < o.Cell.Shp.Nverts; m++ {
+			o.fxl[0+m*3] += coef * S[m] * qt
+			o.fxl[1+m*3] += coef * S[m] * qn
+		}
+	}
+	return
+}
+
+func (o *TimoBeam) calc_loads(time float64) (qnL, qnR, qt float64) {
+	if o.QnL != nil {
+		qnL = o.QnL.F(time, nil)
+	}
+	if o.QnR != nil {
+		qnR = o.QnR.F(time, nil)
+	}
+	if o.Qt != nil {
+		qt = o.Qt.F(time, nil)
+	}
+	return
+}
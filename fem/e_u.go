@@ -5,6 +5,8 @@
 package fem
 
 import (
+	"math"
+
 	"github.com/cpmech/gofem/inp"
 	"github.com/cpmech/gofem/msolid"
 	"github.com/cpmech/gofem/shp"
@@ -13,6 +15,7 @@ import (
 	"github.com/cpmech/gosl/fun"
 	"github.com/cpmech/gosl/la"
 	"github.com/cpmech/gosl/tsr"
+	"github.com/cpmech/gosl/utl"
 )
 
 // ElemU represents a solid element with displacements u as primary variables
@@ -25,9 +28,11 @@ type ElemU struct {
 	Ndim int         // space dimension
 
 	// variables for dynamics
-	Rho  float64  // density of solids
-	Cdam float64  // coefficient for damping
-	Gfcn fun.Func // gravity function
+	Rho  float64     // density of solids
+	Cdam float64     // coefficient for damping
+	Ray  Rayleigh    // Rayleigh damping coefficients
+	Cray [][]float64 // [nu][nu] Rayleigh damping matrix C = α.M + β.K0 (allocated when needed)
+	Gfcn fun.Func    // gravity function
 
 	// optional data
 	UseB      bool    // use B matrix
@@ -88,18 +93,33 @@ type ElemU struct {
 	Kuq           [][]float64 // [nu][nq] Kuq := dRu/dq consistent tangent matrix
 	Kqu           [][]float64 // [nq][nu] Kqu := dRq/du consistent tangent matrix
 	Kqq           [][]float64 // [nq][nq] Kqq := dRq/dq consistent tangent matrix
+	qua4          *shp.Shape  // contact: own qua4 shape to map points onto the master face
 
-	// XFEM (material interface or not)
+	// XFEM: material interface or crack (see e_u_xfem.go)
 	Xmat bool        // material interface
 	Xcrk bool        // crack
 	Xfem bool        // Xmat || Xcrk
 	Na   int         // number of additional degrees of freedom (XFEM)
-	Amap []int       // additional DOFs map
-	Kua  [][]float64 // TODO: [nu][na] Kua := dRu/da consistent tangent matrix
-	Kau  [][]float64 // TODO: [na][nu] Kau := dRa/du consistent tangent matrix
-	Kaa  [][]float64 // TODO: [na][na] Kaa := dRa/da consistent tangent matrix
-	//ProxyMesh *inp.Mesh      // TODO: auxiliary mesh
-	//EnrichShp *shp.EnrichShp // TODO: enriched shape functions
+	Anum []int       // [nverts] number of additional degrees of freedom of each vertex
+	Amap []int       // [na] additional DOFs map
+	Kua  [][]float64 // [nu][na] Kua := dRu/da consistent tangent matrix
+	Kau  [][]float64 // [na][nu] Kau := dRa/du consistent tangent matrix
+	Kaa  [][]float64 // [na][na] Kaa := dRa/da consistent tangent matrix
+	Xenr [][]int     // [nverts] crack: indices of enrichment functions of each vertex
+	Xfv  [][]float64 // [nverts][5] crack: enrichment functions @ vertices (for shifting)
+	Ba   [][]float64 // [nsig][na] crack: B matrix of enriched displacements
+	crk  *xcrack     // crack: geometry
+	xip  []float64   // [ndim] crack: real coordinates of integration point
+	xf   []float64   // [5] crack: enrichment functions @ ip
+	xdf  [][]float64 // [5][ndim] crack: derivatives of enrichment functions @ ip
+
+	// large deformations (see e_u_large.go)
+	F    [][]float64     // [3][3] deformation gradient @ ip
+	FΔ   [][]float64     // [3][3] incremental deformation gradient @ ip
+	Fold [][]float64     // [3][3] deformation gradient @ ip at the beginning of the time step
+	Fi   [][]float64     // [3][3] inverse of deformation gradient
+	gs   [][]float64     // [nverts][ndim] spatial gradients of shape functions
+	A    [][][][]float64 // [3][3][3][3] spatial tangent modulus
 }
 
 // initialisation ///////////////////////////////////////////////////////////////////////////////////
@@ -133,7 +153,10 @@ func init() {
 		contact_set_info(&info, cell, edat)
 
 		// xfem: extra information
-		xfem_set_info(&info, cell, edat)
+		err := xfem_set_info(&info, sim, cell, edat)
+		if err != nil {
+			chk.Panic("cannot set XFEM information of solid element {tag=%d id=%d}:\n%v", cell.Tag, cell.Id, err)
+		}
 
 		// results
 		return &info
@@ -162,7 +185,7 @@ func init() {
 
 		// model
 		var prms fun.Prms
-		o.Model, prms, err = GetAndInitSolidModel(sim.MatParams, edat.Mat, sim.Key, sim.Ndim, sim.Data.Pstress)
+		o.Model, prms, err = GetAndInitSolidModel(sim.MatParams, edat.Mat, sim.Key, sim.Ndim, sim.Data.Pstress, sim.Data.Nworkers > 1)
 		if err != nil {
 			chk.Panic("cannot get model for solid element {tag=%d id=%d material=%q}", cell.Tag, cell.Id, edat.Mat)
 		}
@@ -187,6 +210,12 @@ func init() {
 			}
 		}
 
+		// Rayleigh damping
+		o.Ray, err = GetRayleigh(prms, edat.Extra)
+		if err != nil {
+			chk.Panic("cannot get Rayleigh damping coefficients for solid element {tag=%d id=%d material=%q}:\n%v", cell.Tag, cell.Id, edat.Mat, err)
+		}
+
 		// local starred variables
 		o.ζs = la.MatAlloc(nip, o.Ndim)
 		o.χs = la.MatAlloc(nip, o.Ndim)
@@ -225,7 +254,13 @@ func init() {
 		o.contact_init(edat)
 
 		// xfem: init
-		o.xfem_init(edat)
+		err = o.xfem_init(sim, edat)
+		if err != nil {
+			chk.Panic("cannot initialise XFEM of solid element {tag=%d id=%d}:\n%v", cell.Tag, cell.Id, err)
+		}
+
+		// large deformations: init
+		o.large_init()
 
 		// return new element
 		return &o
@@ -260,10 +295,11 @@ func (o *ElemU) SetEqs(eqs [][]int, mixedform_eqs []int) (err error) {
 
 	// xfem DOFs
 	if o.Xfem {
+		r := 0
 		for m := 0; m < o.Cell.Shp.Nverts; m++ {
-			for i := 0; i < o.Na; i++ {
-				r := i + m*o.Na
+			for i := 0; i < o.Anum[m]; i++ {
 				o.Amap[r] = eqs[m][ndn+i]
+				r++
 			}
 		}
 	}
@@ -332,7 +368,13 @@ func (o *ElemU) AddToRhs(fb []float64, sol *Solution) (err error) {
 		G := o.Cell.Shp.G
 
 		// add internal forces to fb
-		if o.UseB {
+		switch {
+		case o.MdlLarge != nil:
+			err = o.large_add_to_rhs(fb, idx, coef)
+			if err != nil {
+				return
+			}
+		case o.UseB:
 			radius := 1.0
 			if sol.Axisym {
 				radius = o.Cell.Shp.AxisymGetRadius(o.X)
@@ -340,7 +382,7 @@ func (o *ElemU) AddToRhs(fb []float64, sol *Solution) (err error) {
 			}
 			IpBmatrix(o.B, o.Ndim, nverts, G, radius, S, sol.Axisym)
 			la.MatTrVecMulAdd(o.fi, coef, o.B, o.States[idx].Sig) // fi += coef * tr(B) * σ
-		} else {
+		default:
 			for m := 0; m < nverts; m++ {
 				for i := 0; i < o.Ndim; i++ {
 					r := o.Umap[i+m*o.Ndim]
@@ -371,6 +413,15 @@ func (o *ElemU) AddToRhs(fb []float64, sol *Solution) (err error) {
 		}
 	}
 
+	// Rayleigh damping
+	if !sol.Steady && o.Ray.On() {
+		err = o.rayleigh_init(sol, true)
+		if err != nil {
+			return
+		}
+		rayleigh_add_to_rhs(fb, o.Cray, o.Umap, sol)
+	}
+
 	// external forces
 	err = o.add_surfloads_to_rhs(fb, sol)
 	if err != nil {
@@ -386,7 +437,7 @@ func (o *ElemU) AddToRhs(fb []float64, sol *Solution) (err error) {
 }
 
 // AddToKb adds element K to global Jacobian matrix Kb
-func (o *ElemU) AddToKb(Kb *la.Triplet, sol *Solution, firstIt bool) (err error) {
+func (o *ElemU) AddToKb(Kb *Triplet, sol *Solution, firstIt bool) (err error) {
 
 	// zero K matrix
 	la.MatFill(o.K, 0)
@@ -411,23 +462,32 @@ func (o *ElemU) AddToKb(Kb *la.Triplet, sol *Solution, firstIt bool) (err error)
 		S := o.Cell.Shp.S
 		G := o.Cell.Shp.G
 
-		// consistent tangent model matrix
-		err = o.MdlSmall.CalcD(o.D, o.States[idx], firstIt)
-		if err != nil {
-			return
-		}
-
-		// add contribution to consistent tangent matrix
-		if o.UseB {
-			radius := 1.0
-			if sol.Axisym {
-				radius = o.Cell.Shp.AxisymGetRadius(o.X)
-				coef *= radius
+		// large deformations: material and geometric stiffness
+		if o.MdlLarge != nil {
+			err = o.large_add_to_kb(idx, coef, firstIt)
+			if err != nil {
+				return
 			}
-			IpBmatrix(o.B, o.Ndim, nverts, G, radius, S, sol.Axisym)
-			la.MatTrMulAdd3(o.K, coef, o.B, o.D, o.B) // K += coef * tr(B) * D * B
 		} else {
-			IpAddToKt(o.K, nverts, o.Ndim, coef, G, o.D)
+
+			// consistent tangent model matrix
+			err = o.MdlSmall.CalcD(o.D, o.States[idx], firstIt)
+			if err != nil {
+				return
+			}
+
+			// add contribution to consistent tangent matrix
+			if o.UseB {
+				radius := 1.0
+				if sol.Axisym {
+					radius = o.Cell.Shp.AxisymGetRadius(o.X)
+					coef *= radius
+				}
+				IpBmatrix(o.B, o.Ndim, nverts, G, radius, S, sol.Axisym)
+				la.MatTrMulAdd3(o.K, coef, o.B, o.D, o.B) // K += coef * tr(B) * D * B
+			} else {
+				IpAddToKt(o.K, nverts, o.Ndim, coef, G, o.D)
+			}
 		}
 
 		// dynamic term
@@ -446,6 +506,19 @@ func (o *ElemU) AddToKb(Kb *la.Triplet, sol *Solution, firstIt bool) (err error)
 		}
 	}
 
+	// Rayleigh damping
+	if !sol.Steady && o.Ray.On() {
+		err = o.rayleigh_init(sol, true)
+		if err != nil {
+			return
+		}
+		for i := 0; i < o.Nu; i++ {
+			for j := 0; j < o.Nu; j++ {
+				o.K[i][j] += sol.DynCfs.α4 * o.Cray[i][j]
+			}
+		}
+	}
+
 	// add Ks to sparse matrix Kb
 	switch {
 
@@ -453,7 +526,7 @@ func (o *ElemU) AddToKb(Kb *la.Triplet, sol *Solution, firstIt bool) (err error)
 		err = o.contact_add_to_jac(Kb, sol)
 
 	case o.Xfem:
-		err = o.xfem_add_to_jac(Kb, sol)
+		err = o.xfem_add_to_jac(Kb, sol, firstIt)
 
 	default:
 		for i, I := range o.Umap {
@@ -480,6 +553,15 @@ func (o *ElemU) Update(sol *Solution) (err error) {
 		S := o.Cell.Shp.S
 		G := o.Cell.Shp.G
 
+		// large deformations: update stresses with deformation gradient
+		if o.MdlLarge != nil {
+			err = o.large_update(idx, sol)
+			if err != nil {
+				return chk.Err("Update failed (eid=%d, ip=%d)\n%v", o.Id(), idx, err)
+			}
+			continue
+		}
+
 		// compute strains
 		if o.UseB {
 			radius := 1.0
@@ -492,6 +574,9 @@ func (o *ElemU) Update(sol *Solution) (err error) {
 			IpStrainsAndInc(o.ε, o.Δε, nverts, o.Ndim, sol.Y, sol.ΔY, o.Umap, G)
 		}
 
+		// xfem: strains due to enriched displacements
+		o.xfem_add_strains(sol)
+
 		// call model update => update stresses
 		err = o.MdlSmall.Update(o.States[idx], o.ε, o.Δε, o.Id(), idx, sol.T)
 		if err != nil {
@@ -501,6 +586,173 @@ func (o *ElemU) Update(sol *Solution) (err error) {
 	return
 }
 
+// explicit dynamics ////////////////////////////////////////////////////////////////////////////////
+
+// AddToLumpedM adds diagonal (lumped) mass matrix to global vector mb
+//  Note: the diagonal of the consistent mass matrix is scaled such that the total mass is preserved
+//        (HRZ lumping). This avoids the negative masses given by the row-sum technique with
+//        quadratic elements.
+func (o *ElemU) AddToLumpedM(mb []float64, sol *Solution) (err error) {
+
+	// diagonal of consistent mass matrix and total mass
+	nverts := o.Cell.Shp.Nverts
+	mdiag := make([]float64, nverts)
+	var mtot, msum float64
+	for _, ip := range o.IpsElem {
+		err = o.Cell.Shp.CalcAtIp(o.X, ip, true)
+		if err != nil {
+			return
+		}
+		coef := o.Cell.Shp.J * ip[3] * o.Thickness
+		if sol.Axisym {
+			coef *= o.Cell.Shp.AxisymGetRadius(o.X)
+		}
+		S := o.Cell.Shp.S
+		for m := 0; m < nverts; m++ {
+			mdiag[m] += coef * o.Rho * S[m] * S[m]
+		}
+		mtot += coef * o.Rho
+	}
+	for m := 0; m < nverts; m++ {
+		msum += mdiag[m]
+	}
+	if msum < 1e-15 {
+		return chk.Err("ElemU: eid=%d: cannot compute lumped mass matrix with rho=%g", o.Id(), o.Rho)
+	}
+
+	// scale diagonal and add to mb
+	for m := 0; m < nverts; m++ {
+		for i := 0; i < o.Ndim; i++ {
+			mb[o.Umap[i+m*o.Ndim]] += mdiag[m] * mtot / msum
+		}
+	}
+	return
+}
+
+// CritDt returns an estimate of the critical time step of this element
+//  Note: Δtcr = h / c where h is the smallest distance between vertices and c = sqrt(M/ρ) is the
+//        dilatational wave speed computed with the largest constrained modulus M = D[i][i] (i < ndim)
+func (o *ElemU) CritDt(sol *Solution) (Δtcr float64, err error) {
+
+	// check
+	if o.MdlSmall == nil {
+		return 0, chk.Err("ElemU: eid=%d: critical time step can only be estimated with small strain models", o.Id())
+	}
+	if o.Rho < 1e-15 {
+		return 0, chk.Err("ElemU: eid=%d: critical time step requires positive rho. rho=%g is invalid", o.Id(), o.Rho)
+	}
+
+	// largest constrained modulus
+	var Mmax float64
+	for idx, _ := range o.IpsElem {
+		err = o.MdlSmall.CalcD(o.D, o.States[idx], true)
+		if err != nil {
+			return
+		}
+		for i := 0; i < o.Ndim; i++ {
+			Mmax = utl.Max(Mmax, o.D[i][i])
+		}
+	}
+
+	// smallest distance between vertices
+	nverts := o.Cell.Shp.Nverts
+	h := math.MaxFloat64
+	for m := 0; m < nverts; m++ {
+		for n := m + 1; n < nverts; n++ {
+			var d2 float64
+			for i := 0; i < o.Ndim; i++ {
+				d2 += math.Pow(o.X[i][n]-o.X[i][m], 2.0)
+			}
+			h = utl.Min(h, math.Sqrt(d2))
+		}
+	}
+
+	// critical time step
+	Δtcr = h / math.Sqrt(Mmax/o.Rho)
+	return
+}
+
+// mass matrix /////////////////////////////////////////////////////////////////////////////////////
+
+// AddToM adds consistent mass matrix to global matrix M
+func (o *ElemU) AddToM(M *la.Triplet, sol *Solution) (err error) {
+
+	// check
+	if o.HasContact || o.Xfem {
+		return chk.Err("ElemU: eid=%d: mass matrix is not available with contact or xfem", o.Id())
+	}
+
+	// element mass matrix
+	Me := la.MatAlloc(o.Nu, o.Nu)
+	err = o.calc_M(Me, sol)
+	if err != nil {
+		return
+	}
+
+	// add to sparse matrix M
+	for i, I := range o.Umap {
+		for j, J := range o.Umap {
+			M.Put(I, J, Me[i][j])
+		}
+	}
+	return
+}
+
+// Rayleigh damping ////////////////////////////////////////////////////////////////////////////////
+
+// rayleigh_init computes the Rayleigh damping matrix C = α.M + β.K0 if not computed yet
+//  Note: K0 is computed with the current states and firstIt = true; i.e. the initial stiffness.
+//        withM == false skips the mass-proportional term (used by ElemUP)
+func (o *ElemU) rayleigh_init(sol *Solution, withM bool) (err error) {
+
+	// already computed
+	if o.Cray != nil {
+		return
+	}
+
+	// mass matrix
+	M := la.MatAlloc(o.Nu, o.Nu)
+	if withM {
+		err = o.calc_M(M, sol)
+		if err != nil {
+			return
+		}
+	}
+
+	// initial stiffness matrix
+	K := la.MatAlloc(o.Nu, o.Nu)
+	nverts := o.Cell.Shp.Nverts
+	for idx, ip := range o.IpsElem {
+		err = o.Cell.Shp.CalcAtIp(o.X, ip, true)
+		if err != nil {
+			return
+		}
+		coef := o.Cell.Shp.J * ip[3] * o.Thickness
+		S := o.Cell.Shp.S
+		G := o.Cell.Shp.G
+		err = o.MdlSmall.CalcD(o.D, o.States[idx], true)
+		if err != nil {
+			return
+		}
+		if o.UseB {
+			radius := 1.0
+			if sol.Axisym {
+				radius = o.Cell.Shp.AxisymGetRadius(o.X)
+				coef *= radius
+			}
+			IpBmatrix(o.B, o.Ndim, nverts, G, radius, S, sol.Axisym)
+			la.MatTrMulAdd3(K, coef, o.B, o.D, o.B) // K += coef * tr(B) * D * B
+		} else {
+			IpAddToKt(K, nverts, o.Ndim, coef, G, o.D)
+		}
+	}
+
+	// damping matrix
+	o.Cray = la.MatAlloc(o.Nu, o.Nu)
+	o.Ray.Matrix(o.Cray, M, K)
+	return
+}
+
 // internal variables ///////////////////////////////////////////////////////////////////////////////
 
 // Ipoints returns the real coordinates of integration points [nip][ndim]
@@ -572,8 +824,8 @@ func (o *ElemU) RestoreIvs(aux bool) (err error) {
 func (o *ElemU) Ureset(sol *Solution) (err error) {
 	for idx, _ := range o.IpsElem {
 		if len(o.States[idx].F) > 0 {
-			la.MatFill(o.States[idx].F, 0)
-			la.MatFill(o.StatesBkp[idx].F, 0)
+			la.MatCopy(o.States[idx].F, 1, tsr.It)
+			la.MatCopy(o.StatesBkp[idx].F, 1, tsr.It)
 		}
 	}
 	return
@@ -582,16 +834,36 @@ func (o *ElemU) Ureset(sol *Solution) (err error) {
 // writer ///////////////////////////////////////////////////////////////////////////////////////////
 
 // Encode encodes internal variables
+//  Note: the Rayleigh damping matrix is also encoded because it is computed with the states
+//        at the first time step (see rayleigh_init)
 func (o *ElemU) Encode(enc Encoder) (err error) {
-	return enc.Encode(o.States)
+	err = enc.Encode(o.States)
+	if err != nil {
+		return
+	}
+	if o.Ray.On() {
+		err = enc.Encode(o.Cray)
+	}
+	return
 }
 
 // Decode decodes internal variables
+//  Note: the Rayleigh damping matrix is nil if it was not computed before encoding
 func (o *ElemU) Decode(dec Decoder) (err error) {
 	err = dec.Decode(&o.States)
 	if err != nil {
 		return
 	}
+	if o.Ray.On() {
+		o.Cray = nil
+		err = dec.Decode(&o.Cray)
+		if err != nil {
+			return
+		}
+		if len(o.Cray) == 0 {
+			o.Cray = nil
+		}
+	}
 	return o.BackupIvs(false)
 }
 
@@ -615,6 +887,34 @@ func (o *ElemU) OutIpsData() (data []*OutIpData) {
 
 // auxiliary ////////////////////////////////////////////////////////////////////////////////////////
 
+// calc_M computes the consistent mass matrix of this element
+func (o *ElemU) calc_M(Me [][]float64, sol *Solution) (err error) {
+
+	// for each integration point
+	nverts := o.Cell.Shp.Nverts
+	for _, ip := range o.IpsElem {
+		err = o.Cell.Shp.CalcAtIp(o.X, ip, false)
+		if err != nil {
+			return
+		}
+		coef := o.Cell.Shp.J * ip[3] * o.Thickness
+		if sol.Axisym {
+			coef *= o.Cell.Shp.AxisymGetRadius(o.X)
+		}
+		S := o.Cell.Shp.S
+		for m := 0; m < nverts; m++ {
+			for i := 0; i < o.Ndim; i++ {
+				r := i + m*o.Ndim
+				for n := 0; n < nverts; n++ {
+					c := i + n*o.Ndim
+					Me[r][c] += coef * o.Rho * S[m] * S[n]
+				}
+			}
+		}
+	}
+	return
+}
+
 // ipvars computes current values @ integration points. idx == index of integration point
 func (o *ElemU) ipvars(idx int, sol *Solution) (err error) {
 
@@ -62,6 +62,9 @@ func (o *ElemU) contact_init(edat *inp.ElemData) {
 	o.Kuq = la.MatAlloc(o.Nu, o.Nq)
 	o.Kqu = la.MatAlloc(o.Nq, o.Nu)
 	o.Kqq = la.MatAlloc(o.Nq, o.Nq)
+
+	// shape structure; not shared with other elements
+	o.qua4 = shp.Get("qua4", 0).GetCopy()
 }
 
 // contact_add_to_rhs adds contribution to rhs due to contact modelling
@@ -116,7 +119,7 @@ func (o *ElemU) contact_add_to_rhs(fb []float64, sol *Solution) (err error) {
 }
 
 // contact_add_to_jac adds coupled equations due to contact modelling to Jacobian
-func (o *ElemU) contact_add_to_jac(Kb *la.Triplet, sol *Solution) (err error) {
+func (o *ElemU) contact_add_to_jac(Kb *Triplet, sol *Solution) (err error) {
 
 	// clear matrices
 	for i := 0; i < o.Nq; i++ {
@@ -228,8 +231,7 @@ func (o *ElemU) contact_g(x []float64) float64 {
 
 	r := make([]float64, 3)
 	Y := o.contact_get_Y()
-	qua4 := shp.Get("qua4", 0) //o.Sim.GoroutineId)
-	qua4.InvMap(r, x, Y)
+	o.qua4.InvMap(r, x, Y)
 	δ := o.Cell.Shp.CellBryDist(r)
 	return δ
 }
@@ -241,15 +243,14 @@ func (o *ElemU) contact_dgdx(dgdx, x []float64) {
 
 	r := make([]float64, 3)
 	Y := o.contact_get_Y()
-	qua4 := shp.Get("qua4", 0) //o.Sim.GoroutineId)
-	qua4.InvMap(r, x, Y)
+	o.qua4.InvMap(r, x, Y)
 	dfdR := make([]float64, 2)
 	o.Cell.Shp.CellBryDistDeriv(dfdR, r)
-	qua4.CalcAtR(Y, r, true)
+	o.qua4.CalcAtR(Y, r, true)
 	dgdx[0], dgdx[1] = 0.0, 0.0
 	for i := 0; i < 2; i++ {
 		for k := 0; k < 2; k++ {
-			dgdx[i] += dfdR[k] * qua4.DRdx[k][i]
+			dgdx[i] += dfdR[k] * o.qua4.DRdx[k][i]
 		}
 	}
 }
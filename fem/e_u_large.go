@@ -0,0 +1,160 @@
+// Copyright 2015 Dorival Pedroso and Raul Durand. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fem
+
+import (
+	"github.com/cpmech/gosl/chk"
+	"github.com/cpmech/gosl/la"
+	"github.com/cpmech/gosl/tsr"
+)
+
+// large deformations
+//  Note: (1) the formulation is total Lagrangian with integrals over the reference configuration;
+//            however, stresses and tangent moduli are spatial quantities (Cauchy stress σ and A)
+//            and the gradients of shape functions are pushed forward: g = G ⋅ F⁻¹. Thus, the
+//            expressions are the same as in the updated Lagrangian formulation
+//        (2) internal forces:  fi_mi = ∫ J σ_ij g_mj dV0 where J = det(F) and J σ is the
+//            Kirchhoff stress
+//        (3) tangent matrix:   K_mink = ∫ J g_mj A_ijkl g_nl dV0 where A includes the geometric
+//            stiffness; see msolid.Large.CalcA
+//        (4) in 2D, plane-strain is assumed with F_zz = 1
+//        (5) F is computed with the total displacements and FΔ = F ⋅ Fold⁻¹ is the incremental
+//            deformation gradient since the beginning of the time step
+
+// large_init initialises variables for large deformation analyses
+func (o *ElemU) large_init() {
+
+	// skip if small strains
+	if o.MdlLarge == nil {
+		return
+	}
+
+	// check
+	if o.UseB {
+		chk.Panic("large deformation models do not work with axisymmetric, plane-stress or B-matrix analyses {eid=%d}", o.Id())
+	}
+	if o.HasContact || o.Xfem {
+		chk.Panic("large deformation models do not work with contact or xfem {eid=%d}", o.Id())
+	}
+	if o.Ray.On() {
+		chk.Panic("large deformation models do not work with Rayleigh damping {eid=%d}", o.Id())
+	}
+
+	// allocate arrays
+	o.F = tsr.Alloc2()
+	o.FΔ = tsr.Alloc2()
+	o.Fold = tsr.Alloc2()
+	o.Fi = tsr.Alloc2()
+	o.gs = la.MatAlloc(o.Cell.Shp.Nverts, o.Ndim)
+	o.A = tsr.Alloc4()
+}
+
+// large_add_to_rhs adds internal forces to fb: fi_mi = ∫ J σ_ij g_mj dV0
+//  Note: CalcAtIp must be called first
+func (o *ElemU) large_add_to_rhs(fb []float64, idx int, coef float64) (err error) {
+	s := o.States[idx]
+	J, err := o.large_sgrads(s.F)
+	if err != nil {
+		return
+	}
+	for m := 0; m < o.Cell.Shp.Nverts; m++ {
+		for i := 0; i < o.Ndim; i++ {
+			r := o.Umap[i+m*o.Ndim]
+			for j := 0; j < o.Ndim; j++ {
+				fb[r] -= coef * J * tsr.M2T(s.Sig, i, j) * o.gs[m][j] // -fi
+			}
+		}
+	}
+	return
+}
+
+// large_add_to_kb adds material and geometric stiffness to K
+//  Note: CalcAtIp must be called first
+func (o *ElemU) large_add_to_kb(idx int, coef float64, firstIt bool) (err error) {
+	s := o.States[idx]
+	J, err := o.large_sgrads(s.F)
+	if err != nil {
+		return
+	}
+	err = o.MdlLarge.CalcA(o.A, s, firstIt)
+	if err != nil {
+		return
+	}
+	nverts := o.Cell.Shp.Nverts
+	for m := 0; m < nverts; m++ {
+		for i := 0; i < o.Ndim; i++ {
+			r := i + m*o.Ndim
+			for n := 0; n < nverts; n++ {
+				for k := 0; k < o.Ndim; k++ {
+					c := k + n*o.Ndim
+					for j := 0; j < o.Ndim; j++ {
+						for l := 0; l < o.Ndim; l++ {
+							o.K[r][c] += coef * J * o.gs[m][j] * o.A[i][j][k][l] * o.gs[n][l]
+						}
+					}
+				}
+			}
+		}
+	}
+	return
+}
+
+// large_update updates stresses using the deformation gradients
+//  Note: CalcAtIp must be called first
+func (o *ElemU) large_update(idx int, sol *Solution) (err error) {
+	o.large_defgrad(o.F, sol.Y, nil)
+	o.large_defgrad(o.Fold, sol.Y, sol.ΔY)
+	_, err = tsr.Inv(o.Fi, o.Fold)
+	if err != nil {
+		return
+	}
+	la.MatMul(o.FΔ, 1, o.F, o.Fi) // FΔ = F ⋅ Fold⁻¹
+	return o.MdlLarge.Update(o.States[idx], o.F, o.FΔ)
+}
+
+// large_defgrad computes the deformation gradient F = I + Σ u_m ⊗ G_m
+//  Input:
+//   Y  -- total displacements
+//   ΔY -- increments to be discounted from Y; i.e. F = F(Y - ΔY). may be nil
+func (o *ElemU) large_defgrad(F [][]float64, Y, ΔY []float64) {
+	la.MatFill(F, 0)
+	for i := 0; i < 3; i++ {
+		F[i][i] = 1
+	}
+	G := o.Cell.Shp.G
+	for m := 0; m < o.Cell.Shp.Nverts; m++ {
+		for i := 0; i < o.Ndim; i++ {
+			r := o.Umap[i+m*o.Ndim]
+			u := Y[r]
+			if ΔY != nil {
+				u -= ΔY[r]
+			}
+			for j := 0; j < o.Ndim; j++ {
+				F[i][j] += u * G[m][j]
+			}
+		}
+	}
+}
+
+// large_sgrads computes the spatial gradients of shape functions g = G ⋅ F⁻¹ and returns J = det(F)
+func (o *ElemU) large_sgrads(F [][]float64) (J float64, err error) {
+	J, err = tsr.Inv(o.Fi, F)
+	if err != nil {
+		return
+	}
+	if J <= 0 {
+		return 0, chk.Err("ElemU: eid=%d: determinant of deformation gradient must be positive. J=%g is invalid", o.Id(), J)
+	}
+	G := o.Cell.Shp.G
+	for m := 0; m < o.Cell.Shp.Nverts; m++ {
+		for j := 0; j < o.Ndim; j++ {
+			o.gs[m][j] = 0
+			for k := 0; k < o.Ndim; k++ {
+				o.gs[m][j] += G[m][k] * o.Fi[k][j]
+			}
+		}
+	}
+	return
+}
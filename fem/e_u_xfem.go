@@ -5,26 +5,54 @@
 package fem
 
 import (
+	"math"
+	"strings"
+
 	"github.com/cpmech/gofem/inp"
+	"github.com/cpmech/gofem/msolid"
+	"github.com/cpmech/gofem/shp"
 	"github.com/cpmech/gosl/chk"
 	"github.com/cpmech/gosl/io"
 	"github.com/cpmech/gosl/la"
+	"github.com/cpmech/gosl/mpi"
 )
 
-// xfem_set_info sets extra information for XFEM elements
-func xfem_set_info(info *Info, cell *inp.Cell, edat *inp.ElemData) (ykeys []string) {
+// xcrk_keys holds the keys of the additional DOFs of each enrichment function of cracks
+//  Note: the order corresponds to the enrichment functions; see xcrack.funcs
+var xcrk_keys = [][]string{
+	{"ahx", "ahy"}, // Heaviside
+	{"a1x", "a1y"}, // branch function 1
+	{"a2x", "a2y"}, // branch function 2
+	{"a3x", "a3y"}, // branch function 3
+	{"a4x", "a4y"}, // branch function 4
+}
 
-	// flags
-	xmat, xcrk, xfem := false, false, false
-	if s_xmat, found := io.Keycode(edat.Extra, "xmat"); found {
+// xfem_flags parses the XFEM flags in the extra data of elements
+//  Note: xmat and xcrk cannot be used together because the additional DOFs of vertices hold the
+//        enrichments of either material interfaces or cracks
+func xfem_flags(extra string) (xmat, xcrk, xfem bool, err error) {
+	if s_xmat, found := io.Keycode(extra, "xmat"); found {
 		xmat = io.Atob(s_xmat)
 		xfem = true
 	}
-	if s_xcrk, found := io.Keycode(edat.Extra, "xcrk"); found {
+	if s_xcrk, found := io.Keycode(extra, "xcrk"); found {
 		xcrk = io.Atob(s_xcrk)
 		xfem = true
 	}
-	_ = xcrk // TODO
+	if xmat && xcrk {
+		err = chk.Err("xmat and xcrk cannot be used together in the same element")
+	}
+	return
+}
+
+// xfem_set_info sets extra information for XFEM elements
+func xfem_set_info(info *Info, sim *inp.Simulation, cell *inp.Cell, edat *inp.ElemData) (err error) {
+
+	// flags
+	xmat, xcrk, xfem, err := xfem_flags(edat.Extra)
+	if err != nil {
+		return
+	}
 
 	// skip if not XFEM
 	if !xfem {
@@ -40,21 +68,37 @@ func xfem_set_info(info *Info, cell *inp.Cell, edat *inp.ElemData) (ykeys []stri
 		info.Y2F["am"] = "nil"
 		info.T2vars = append(info.T2vars, "am")
 	}
+	if xcrk {
+		if sim.Ndim != 2 {
+			return chk.Err("xfem with crack works in 2D only for now")
+		}
+		crk, err := new_xcrack(edat.Extra)
+		if err != nil {
+			return chk.Err("cannot get crack data:\n%v", err)
+		}
+		enr := crk.enrichments(cell_mesh(sim, cell), cell)
+		for m := 0; m < nverts; m++ {
+			for _, k := range enr[m] {
+				info.Dofs[m] = append(info.Dofs[m], xcrk_keys[k]...)
+			}
+		}
+		for _, keys := range xcrk_keys {
+			for _, key := range keys {
+				info.Y2F[key] = "nil"
+			}
+			info.T2vars = append(info.T2vars, keys...)
+		}
+	}
 	return
 }
 
 // xfem_init initialises variables need by xfem model
-func (o *ElemU) xfem_init(edat *inp.ElemData) {
+func (o *ElemU) xfem_init(sim *inp.Simulation, edat *inp.ElemData) (err error) {
 
 	// flags
-	o.Xmat, o.Xcrk, o.Xfem = false, false, false
-	if s_xmat, found := io.Keycode(edat.Extra, "xmat"); found {
-		o.Xmat = io.Atob(s_xmat)
-		o.Xfem = true
-	}
-	if s_xcrk, found := io.Keycode(edat.Extra, "xcrk"); found {
-		o.Xcrk = io.Atob(s_xcrk)
-		o.Xfem = true
+	o.Xmat, o.Xcrk, o.Xfem, err = xfem_flags(edat.Extra)
+	if err != nil {
+		return
 	}
 
 	// skip if not XFEM
@@ -62,31 +106,771 @@ func (o *ElemU) xfem_init(edat *inp.ElemData) {
 		return
 	}
 
-	// auxiliary variables
+	// number of additional DOFs of each vertex
+	nverts := o.Cell.Shp.Nverts
+	o.Anum = make([]int, nverts)
 	switch {
 	case o.Xmat:
-		o.Na = 1
-	case o.Xcrk:
-		o.Na = 4 // TODO: this is 2D only
-		if o.Ndim != 2 {
-			chk.Panic("xfem with crack works in 2D only for now")
+		for m := 0; m < nverts; m++ {
+			o.Anum[m] = 1
 		}
+	case o.Xcrk:
+		o.xcrk_init(sim, edat)
 	}
 
-	// allocate extrem XFEM coupling matrices
-	nverts := o.Cell.Shp.Nverts
-	o.Amap = make([]int, o.Na*nverts)
+	// allocate extra XFEM coupling matrices
+	o.Na = 0
+	for m := 0; m < nverts; m++ {
+		o.Na += o.Anum[m]
+	}
+	o.Amap = make([]int, o.Na)
 	o.Kua = la.MatAlloc(o.Nu, o.Na)
 	o.Kau = la.MatAlloc(o.Na, o.Nu)
 	o.Kaa = la.MatAlloc(o.Na, o.Na)
+	if o.Xcrk {
+		o.Ba = la.MatAlloc(2*o.Ndim, o.Na)
+	}
+	return
+}
+
+// xcrk_init initialises crack data, enrichments and sub-cell integration points
+func (o *ElemU) xcrk_init(sim *inp.Simulation, edat *inp.ElemData) {
+
+	// check
+	if o.Ndim != 2 {
+		chk.Panic("xfem with crack works in 2D only for now")
+	}
+	if sim.Data.Axisym || !sim.Data.Steady || o.HasContact || o.MdlSmall == nil {
+		chk.Panic("xfem with crack requires steady, non-axisymmetric simulations, small strain models and no contact. eid=%d", o.Id())
+	}
+
+	// crack and enrichments
+	var err error
+	o.crk, err = new_xcrack(edat.Extra)
+	if err != nil {
+		chk.Panic("cannot get crack data of element {tag=%d id=%d}:\n%v", o.Cell.Tag, o.Id(), err)
+	}
+	nverts := o.Cell.Shp.Nverts
+	o.Xenr = o.crk.enrichments(cell_mesh(sim, o.Cell), o.Cell)
+	o.Xfv = la.MatAlloc(nverts, len(xcrk_keys))
+	o.xip = make([]float64, o.Ndim)
+	o.xf = make([]float64, len(xcrk_keys))
+	o.xdf = la.MatAlloc(len(xcrk_keys), o.Ndim)
+	for m := 0; m < nverts; m++ {
+		o.Anum[m] = o.Ndim * len(o.Xenr[m])
+		o.crk.funcs(o.Xfv[m], o.xdf, []float64{o.X[0][m], o.X[1][m]})
+	}
+
+	// sub-cell integration points
+	cut, tip, rt := o.crk.classify(o.X, o.Cell.Shp)
+	if cut || tip {
+		o.IpsElem, err = o.crk.subcell_ips(o.X, o.Cell.Shp, tip, rt)
+		if err != nil {
+			chk.Panic("cannot compute sub-cell integration points of element {tag=%d id=%d}:\n%v", o.Cell.Tag, o.Id(), err)
+		}
+		nip := len(o.IpsElem)
+		o.ζs = la.MatAlloc(nip, o.Ndim)
+		o.χs = la.MatAlloc(nip, o.Ndim)
+		o.divχs = make([]float64, nip)
+	}
+
+	// B matrix of standard displacements
+	if o.B == nil {
+		o.B = la.MatAlloc(2*o.Ndim, o.Nu)
+	}
+}
+
+// xfem_add_strains adds strains due to enriched displacements to ε and Δε
+//  Note: CalcAtIp must be called first
+func (o *ElemU) xfem_add_strains(sol *Solution) {
+	if !o.Xcrk {
+		return
+	}
+	o.xfem_bmat()
+	for i := 0; i < len(o.ε); i++ {
+		for c, I := range o.Amap {
+			o.ε[i] += o.Ba[i][c] * sol.Y[I]
+			o.Δε[i] += o.Ba[i][c] * sol.ΔY[I]
+		}
+	}
 }
 
 // xfem_add_to_rhs adds contribution to rhs due to xfem model
 func (o *ElemU) xfem_add_to_rhs(fb []float64, sol *Solution) (err error) {
+
+	// skip if not crack
+	if !o.Xcrk {
+		return
+	}
+
+	// internal forces: fa = ∫ tr(Ba) ⋅ σ dV
+	for idx, ip := range o.IpsElem {
+		err = o.Cell.Shp.CalcAtIp(o.X, ip, true)
+		if err != nil {
+			return
+		}
+		coef := o.Cell.Shp.J * ip[3] * o.Thickness
+		o.xfem_bmat()
+		for c, I := range o.Amap {
+			for i, σ := range o.States[idx].Sig {
+				fb[I] -= coef * o.Ba[i][c] * σ // -fi
+			}
+		}
+	}
+
+	// index of first additional DOF of each vertex
+	nverts := o.Cell.Shp.Nverts
+	aoff := make([]int, nverts)
+	for m := 1; m < nverts; m++ {
+		aoff[m] = aoff[m-1] + o.Anum[m-1]
+	}
+
+	// external forces due to surface loads
+	//  Note: the standard face integration points are used; thus, integrals over faces crossed
+	//        by the crack are approximated
+	for _, nbc := range o.NatBcs {
+		switch nbc.Key {
+		case "qn", "qn0", "aqn":
+			res := nbc.Fcn.F(sol.T, nil)
+			iface := nbc.IdxFace
+			for _, ipf := range o.IpsFace {
+				err = o.Cell.Shp.CalcAtFaceIp(o.X, ipf, iface)
+				if err != nil {
+					return
+				}
+				Sf := o.Cell.Shp.Sf
+				nvec := o.Cell.Shp.Fnvec
+				coef := ipf[3] * res * o.Thickness
+				for i := 0; i < o.Ndim; i++ {
+					o.xip[i] = 0
+					for j, m := range o.Cell.Shp.FaceLocalVerts[iface] {
+						o.xip[i] += Sf[j] * o.X[i][m]
+					}
+				}
+				o.crk.funcs(o.xf, o.xdf, o.xip)
+				for j, m := range o.Cell.Shp.FaceLocalVerts[iface] {
+					for l, k := range o.Xenr[m] {
+						ψ := o.xf[k] - o.Xfv[m][k]
+						for i := 0; i < o.Ndim; i++ {
+							fb[o.Amap[aoff[m]+i+l*o.Ndim]] += coef * Sf[j] * ψ * nvec[i] // +fe
+						}
+					}
+				}
+			}
+		}
+	}
+	return
+}
+
+// xfem_add_to_jac adds coupled equations due to xfem to Jacobian
+func (o *ElemU) xfem_add_to_jac(Kb *Triplet, sol *Solution, firstIt bool) (err error) {
+
+	// coupling matrices due to crack
+	if o.Xcrk {
+		la.MatFill(o.Kua, 0)
+		la.MatFill(o.Kau, 0)
+		la.MatFill(o.Kaa, 0)
+		nverts := o.Cell.Shp.Nverts
+		for idx, ip := range o.IpsElem {
+			err = o.Cell.Shp.CalcAtIp(o.X, ip, true)
+			if err != nil {
+				return
+			}
+			coef := o.Cell.Shp.J * ip[3] * o.Thickness
+			err = o.MdlSmall.CalcD(o.D, o.States[idx], firstIt)
+			if err != nil {
+				return
+			}
+			IpBmatrix(o.B, o.Ndim, nverts, o.Cell.Shp.G, 1.0, o.Cell.Shp.S, false)
+			o.xfem_bmat()
+			la.MatTrMulAdd3(o.Kua, coef, o.B, o.D, o.Ba)  // Kua += coef * tr(B) * D * Ba
+			la.MatTrMulAdd3(o.Kau, coef, o.Ba, o.D, o.B)  // Kau += coef * tr(Ba) * D * B
+			la.MatTrMulAdd3(o.Kaa, coef, o.Ba, o.D, o.Ba) // Kaa += coef * tr(Ba) * D * Ba
+		}
+	}
+
+	// add K, Kua, Kau and Kaa to sparse matrix Kb
+	for i, I := range o.Umap {
+		for j, J := range o.Umap {
+			Kb.Put(I, J, o.K[i][j])
+		}
+		for j, J := range o.Amap {
+			Kb.Put(I, J, o.Kua[i][j])
+		}
+	}
+	for i, I := range o.Amap {
+		for j, J := range o.Umap {
+			Kb.Put(I, J, o.Kau[i][j])
+		}
+		for j, J := range o.Amap {
+			Kb.Put(I, J, o.Kaa[i][j])
+		}
+	}
 	return
 }
 
-// contact_add_to_jac adds coupled equations due to xfem to Jacobian
-func (o *ElemU) xfem_add_to_jac(Kb *la.Triplet, sol *Solution) (err error) {
+// xfem_bmat computes the B matrix of enriched displacements (Ba) in Mandel's basis
+//  Note: (1) the enrichment is shifted; i.e. ua = Σ_m Σ_k S_m ⋅ (F_k(x) - F_k(x_m)) ⋅ a_mk
+//        (2) CalcAtIp must be called first
+func (o *ElemU) xfem_bmat() {
+	S := o.Cell.Shp.S
+	G := o.Cell.Shp.G
+	o.xfem_ipx()
+	o.crk.funcs(o.xf, o.xdf, o.xip)
+	c := 0
+	for m := 0; m < o.Cell.Shp.Nverts; m++ {
+		for _, k := range o.Xenr[m] {
+			ψ := o.xf[k] - o.Xfv[m][k]
+			gx := G[m][0]*ψ + S[m]*o.xdf[k][0]
+			gy := G[m][1]*ψ + S[m]*o.xdf[k][1]
+			o.Ba[0][c], o.Ba[1][c], o.Ba[2][c], o.Ba[3][c] = gx, 0, 0, gy/SQ2
+			o.Ba[0][c+1], o.Ba[1][c+1], o.Ba[2][c+1], o.Ba[3][c+1] = 0, gy, 0, gx/SQ2
+			c += 2
+		}
+	}
+}
+
+// xfem_ipx computes the real coordinates of the current integration point (xip)
+//  Note: CalcAtIp must be called first
+func (o *ElemU) xfem_ipx() {
+	for i := 0; i < o.Ndim; i++ {
+		o.xip[i] = 0
+		for m := 0; m < o.Cell.Shp.Nverts; m++ {
+			o.xip[i] += o.Cell.Shp.S[m] * o.X[i][m]
+		}
+	}
+}
+
+// xfem_gradu computes the gradient of displacements @ ip, including enriched displacements
+//  Output:
+//   gu -- [ndim][ndim] gu[i][j] = ∂u_i/∂x_j
+//  Note: CalcAtIp must be called first
+func (o *ElemU) xfem_gradu(gu [][]float64, sol *Solution) {
+	S := o.Cell.Shp.S
+	G := o.Cell.Shp.G
+	nverts := o.Cell.Shp.Nverts
+	la.MatFill(gu, 0)
+	for m := 0; m < nverts; m++ {
+		for i := 0; i < o.Ndim; i++ {
+			u := sol.Y[o.Umap[i+m*o.Ndim]]
+			for j := 0; j < o.Ndim; j++ {
+				gu[i][j] += u * G[m][j]
+			}
+		}
+	}
+	if !o.Xcrk {
+		return
+	}
+	o.xfem_ipx()
+	o.crk.funcs(o.xf, o.xdf, o.xip)
+	c := 0
+	for m := 0; m < nverts; m++ {
+		for _, k := range o.Xenr[m] {
+			ψ := o.xf[k] - o.Xfv[m][k]
+			for i := 0; i < o.Ndim; i++ {
+				a := sol.Y[o.Amap[c+i]]
+				for j := 0; j < o.Ndim; j++ {
+					gu[i][j] += a * (G[m][j]*ψ + S[m]*o.xdf[k][j])
+				}
+			}
+			c += o.Ndim
+		}
+	}
+}
+
+// stress intensity factors /////////////////////////////////////////////////////////////////////////
+
+// XfemSIF computes the stress intensity factors of the crack tip by means of the interaction integral
+//  Input:
+//   rd -- radius of integration domain around the tip; the weight function q is 1 at vertices
+//         inside the circle of radius rd and 0 outside; thus, only elements crossed by the circle
+//         contribute to the integral
+//  Output:
+//   KI, KII -- stress intensity factors of modes I and II
+//  Note: (1) the crack is taken from the first solid element with "xcrk"
+//        (2) elements crossed by the circle must be solid elements with linear elastic material
+//            ("lin-elast"); the material is assumed homogeneous around the tip
+//        (3) the auxiliary fields are Williams' near-tip fields with unit stress intensity factors
+func (o *Domain) XfemSIF(rd float64) (KI, KII float64, err error) {
+
+	// crack
+	var crk *xcrack
+	for _, ele := range o.Elems {
+		if e, ok := ele.(*ElemU); ok && e.Xcrk {
+			crk = e.crk
+			break
+		}
+	}
+	if crk == nil {
+		return 0, 0, chk.Err("cannot find solid element with xfem crack")
+	}
+	if rd <= 0 {
+		return 0, 0, chk.Err("radius of integration domain must be positive. rd = %g is invalid", rd)
+	}
+
+	// interaction integrals
+	K := make([]float64, 2)
+	for _, ele := range o.Elems {
+		if e, ok := ele.(*ElemU); ok {
+			err = e.xfem_interaction(K, crk, rd, o.Sol)
+			if err != nil {
+				return
+			}
+		}
+	}
+
+	// join contributions from all processors
+	if o.Distr {
+		wrk := make([]float64, 2)
+		mpi.AllReduceSum(K, wrk)
+	}
+	return K[0], K[1], nil
+}
+
+// xfem_interaction adds the contribution of this element to the stress intensity factors
+//  Input:
+//   crk -- crack
+//   rd  -- radius of integration domain
+//  Output:
+//   K -- [2] stress intensity factors of modes I and II (added): K = I ⋅ E* / 2, where
+//        I = ∫ (σ_ij ⋅ u^aux_i,1 + σ^aux_ij ⋅ u_i,1 - σ_ik ⋅ ε^aux_ik ⋅ δ_1j) ⋅ q_,j dA
+//        in the local system of the tip
+func (o *ElemU) xfem_interaction(K []float64, crk *xcrack, rd float64, sol *Solution) (err error) {
+
+	// weight function @ vertices; skip element if q is uniform
+	nverts := o.Cell.Shp.Nverts
+	q := make([]float64, nverts)
+	var qsum float64
+	for m := 0; m < nverts; m++ {
+		if math.Hypot(o.X[0][m]-crk.T[0], o.X[1][m]-crk.T[1]) < rd {
+			q[m] = 1
+		}
+		qsum += q[m]
+	}
+	if qsum < 0.5 || qsum > float64(nverts)-0.5 {
+		return
+	}
+
+	// elastic parameters
+	mdl, ok := o.Model.(*msolid.LinElast)
+	if !ok {
+		return chk.Err("ElemU: eid=%d: interaction integral requires linear elastic model (lin-elast)", o.Id())
+	}
+	ν, μ := mdl.Nu, mdl.G
+	κ, Estar := 3.0-4.0*ν, mdl.E/(1.0-ν*ν)
+	if mdl.Pse {
+		κ, Estar = (3.0-ν)/(1.0+ν), mdl.E
+	}
+
+	// auxiliary
+	if o.xip == nil {
+		o.xip = make([]float64, o.Ndim)
+	}
+	Q := [][]float64{crk.t, crk.n} // rotation to local system
+	gu := la.MatAlloc(2, 2)        // gradient of displacements (global)
+	σ := la.MatAlloc(2, 2)         // stresses (global)
+	gul := la.MatAlloc(2, 2)       // gradient of displacements (local)
+	σl := la.MatAlloc(2, 2)        // stresses (local)
+	gua := la.MatAlloc(2, 2)       // auxiliary gradient of displacements (local)
+	σa := la.MatAlloc(2, 2)        // auxiliary stresses (local)
+	gq := make([]float64, 2)       // gradient of weight function (local)
+
+	// for each integration point
+	for idx, ip := range o.IpsElem {
+		err = o.Cell.Shp.CalcAtIp(o.X, ip, true)
+		if err != nil {
+			return
+		}
+		coef := o.Cell.Shp.J * ip[3]
+		G := o.Cell.Shp.G
+
+		// gradients and stresses in local system
+		o.xfem_gradu(gu, sol)
+		o.xfem_ipx()
+		sig := o.States[idx].Sig
+		σ[0][0], σ[0][1], σ[1][0], σ[1][1] = sig[0], sig[3]/SQ2, sig[3]/SQ2, sig[1]
+		for i := 0; i < 2; i++ {
+			gq[i] = 0
+			for m := 0; m < nverts; m++ {
+				gq[i] += (Q[i][0]*G[m][0] + Q[i][1]*G[m][1]) * q[m]
+			}
+			for j := 0; j < 2; j++ {
+				gul[i][j], σl[i][j] = 0, 0
+				for k := 0; k < 2; k++ {
+					for l := 0; l < 2; l++ {
+						gul[i][j] += Q[i][k] * gu[k][l] * Q[j][l]
+						σl[i][j] += Q[i][k] * σ[k][l] * Q[j][l]
+					}
+				}
+			}
+		}
+
+		// interaction integrals
+		r, θ := crk.polar(o.xip)
+		for mode := 0; mode < 2; mode++ {
+			xcrk_auxfields(σa, gua, r, θ, κ, μ, mode)
+			var W, res float64
+			for i := 0; i < 2; i++ {
+				for j := 0; j < 2; j++ {
+					W += σl[i][j] * (gua[i][j] + gua[j][i]) / 2.0
+				}
+			}
+			for j := 0; j < 2; j++ {
+				var s float64
+				for i := 0; i < 2; i++ {
+					s += σl[i][j]*gua[i][0] + σa[i][j]*gul[i][0]
+				}
+				if j == 0 {
+					s -= W
+				}
+				res += s * gq[j]
+			}
+			K[mode] += coef * res * Estar / 2.0
+		}
+	}
+	return
+}
+
+// xcrk_auxfields computes Williams' near-tip fields with unit stress intensity factor
+//  Input:
+//   r, θ -- polar coordinates in the local system of the tip
+//   κ    -- Kolosov's constant: 3 - 4ν (plane-strain) or (3 - ν)/(1 + ν) (plane-stress)
+//   μ    -- shear modulus
+//   mode -- 0: mode I; 1: mode II
+//  Output:
+//   σ  -- [2][2] stresses in the local system
+//   gu -- [2][2] gradient of displacements in the local system; gu[i][j] = ∂u_i/∂x_j
+func xcrk_auxfields(σ, gu [][]float64, r, θ, κ, μ float64, mode int) {
+
+	// auxiliary
+	sh, ch := math.Sin(θ/2.0), math.Cos(θ/2.0)
+	s3, c3 := math.Sin(1.5*θ), math.Cos(1.5*θ)
+	a := 1.0 / math.Sqrt(2.0*math.Pi*r)
+	c := 1.0 / (2.0 * μ * math.Sqrt(2.0*math.Pi))
+
+	// stresses and displacements u_i = √r ⋅ f_i(θ); df_i = df_i/dθ
+	var f, df [2]float64
+	if mode == 0 {
+		σ[0][0] = a * ch * (1.0 - sh*s3)
+		σ[1][1] = a * ch * (1.0 + sh*s3)
+		σ[0][1] = a * sh * ch * c3
+		f[0] = c * ch * (κ - 1.0 + 2.0*sh*sh)
+		f[1] = c * sh * (κ + 1.0 - 2.0*ch*ch)
+		df[0] = c * sh * (2.0*ch*ch - (κ-1.0+2.0*sh*sh)/2.0)
+		df[1] = c * ch * (2.0*sh*sh + (κ+1.0-2.0*ch*ch)/2.0)
+	} else {
+		σ[0][0] = -a * sh * (2.0 + ch*c3)
+		σ[1][1] = a * sh * ch * c3
+		σ[0][1] = a * ch * (1.0 - sh*s3)
+		f[0] = c * sh * (κ + 1.0 + 2.0*ch*ch)
+		f[1] = -c * ch * (κ - 1.0 - 2.0*sh*sh)
+		df[0] = c * ch * ((κ+1.0+2.0*ch*ch)/2.0 - 2.0*sh*sh)
+		df[1] = c * sh * ((κ-1.0-2.0*sh*sh)/2.0 + 2.0*ch*ch)
+	}
+	σ[1][0] = σ[0][1]
+
+	// gradient of displacements
+	sr := math.Sqrt(r)
+	sθ, cθ := math.Sin(θ), math.Cos(θ)
+	for i := 0; i < 2; i++ {
+		dr := f[i] / (2.0 * sr) // ∂u_i/∂r
+		dθ := sr * df[i]        // ∂u_i/∂θ
+		gu[i][0] = dr*cθ - dθ*sθ/r
+		gu[i][1] = dr*sθ + dθ*cθ/r
+	}
+}
+
+// crack geometry ///////////////////////////////////////////////////////////////////////////////////
+
+// xcrack implements the geometry of a straight crack with one tip in 2D
+//  Note: (1) the crack goes from point A to the tip T; A must be outside the domain or on its boundary
+//        (2) the level sets are φ = (x - T)⋅n and ψ = (x - T)⋅t, where t is the unit vector from A
+//            to T and n is normal to t; the crack is the set of points with φ = 0 and ψ < 0
+//        (3) (ψ, φ) are the coordinates in the local system of the tip
+type xcrack struct {
+	A, T []float64 // [2] start point and tip
+	t, n []float64 // [2] unit tangent (from A to T) and normal vectors
+	L    float64   // length of crack
+	Rtip float64   // radius of tip enrichment; vertices of cells containing the tip are always enriched
+}
+
+// new_xcrack parses crack data from the extra string of elements
+//  Example: "!xcrk:1 !crka:-0.1,0.5 !crkt:0.45,0.5 !rtip:0.1"
+func new_xcrack(extra string) (o *xcrack, err error) {
+	o = new(xcrack)
+	for _, key := range []string{"crka", "crkt"} {
+		val, found := io.Keycode(extra, key)
+		if !found {
+			return nil, chk.Err("crack requires the coordinates of the start point (crka) and tip (crkt). %q is missing", key)
+		}
+		vals := strings.Split(val, ",")
+		if len(vals) != 2 {
+			return nil, chk.Err("%q must have two comma-separated coordinates. %q is invalid", key, val)
+		}
+		x := []float64{io.Atof(vals[0]), io.Atof(vals[1])}
+		if key == "crka" {
+			o.A = x
+		} else {
+			o.T = x
+		}
+	}
+	if val, found := io.Keycode(extra, "rtip"); found {
+		o.Rtip = io.Atof(val)
+	}
+	o.L = math.Hypot(o.T[0]-o.A[0], o.T[1]-o.A[1])
+	if o.L < 1e-10 {
+		return nil, chk.Err("length of crack must be positive. L = %g is invalid", o.L)
+	}
+	o.t = []float64{(o.T[0] - o.A[0]) / o.L, (o.T[1] - o.A[1]) / o.L}
+	o.n = []float64{-o.t[1], o.t[0]}
+	return
+}
+
+// levelsets computes the normal (φ) and tangential (ψ) level sets @ x
+func (o *xcrack) levelsets(x []float64) (φ, ψ float64) {
+	for i := 0; i < 2; i++ {
+		φ += (x[i] - o.T[i]) * o.n[i]
+		ψ += (x[i] - o.T[i]) * o.t[i]
+	}
+	return
+}
+
+// polar computes the polar coordinates of x in the local system of the tip; -π ≤ θ ≤ π
+func (o *xcrack) polar(x []float64) (r, θ float64) {
+	φ, ψ := o.levelsets(x)
+	return math.Hypot(ψ, φ), math.Atan2(φ, ψ)
+}
+
+// funcs computes the enrichment functions and their derivatives @ x
+//  Output:
+//   F  -- [5] Heaviside function H = sign(φ) and the branch functions:
+//          √r sin(θ/2), √r cos(θ/2), √r sin(θ/2) sin(θ), √r cos(θ/2) sin(θ)
+//   dF -- [5][2] derivatives of F w.r.t global coordinates; dH/dx = 0
+func (o *xcrack) funcs(F []float64, dF [][]float64, x []float64) {
+
+	// Heaviside function
+	φ, ψ := o.levelsets(x)
+	F[0], dF[0][0], dF[0][1] = 1, 0, 0
+	if φ < 0 {
+		F[0] = -1
+	}
+
+	// branch functions
+	r := math.Hypot(ψ, φ)
+	if r < 1e-15 {
+		for k := 1; k < 5; k++ {
+			F[k], dF[k][0], dF[k][1] = 0, 0, 0
+		}
+		return
+	}
+	θ := math.Atan2(φ, ψ)
+	sr := math.Sqrt(r)
+	sh, ch := math.Sin(θ/2.0), math.Cos(θ/2.0)
+	sθ, cθ := math.Sin(θ), math.Cos(θ)
+	F[1] = sr * sh
+	F[2] = sr * ch
+	F[3] = sr * sh * sθ
+	F[4] = sr * ch * sθ
+
+	// derivatives w.r.t r and θ
+	dr := [4]float64{sh / (2.0 * sr), ch / (2.0 * sr), sh * sθ / (2.0 * sr), ch * sθ / (2.0 * sr)}
+	dθ := [4]float64{sr * ch / 2.0, -sr * sh / 2.0, sr * (ch*sθ/2.0 + sh*cθ), sr * (-sh*sθ/2.0 + ch*cθ)}
+
+	// derivatives w.r.t local and then global coordinates
+	for k := 0; k < 4; k++ {
+		d1 := dr[k]*cθ - dθ[k]*sθ/r
+		d2 := dr[k]*sθ + dθ[k]*cθ/r
+		for i := 0; i < 2; i++ {
+			dF[k+1][i] = d1*o.t[i] + d2*o.n[i]
+		}
+	}
+}
+
+// classify finds whether a cell is cut by the crack or contains the tip
+//  Input:
+//   x  -- [ndim][nverts] coordinates of vertices
+//   sh -- shape structure
+//  Output:
+//   cut -- the crack crosses the cell completely
+//   tip -- the tip is inside the cell
+//   rt  -- [3] natural coordinates of the tip if tip == true
+func (o *xcrack) classify(x [][]float64, sh *shp.Shape) (cut, tip bool, rt []float64) {
+
+	// tip: check bounding box first
+	nc := sh.BasicNverts
+	xmin, xmax := []float64{x[0][0], x[1][0]}, []float64{x[0][0], x[1][0]}
+	for k := 1; k < nc; k++ {
+		for i := 0; i < 2; i++ {
+			xmin[i] = math.Min(xmin[i], x[i][k])
+			xmax[i] = math.Max(xmax[i], x[i][k])
+		}
+	}
+	tol := 1e-10
+	if o.T[0] > xmin[0]-tol && o.T[0] < xmax[0]+tol && o.T[1] > xmin[1]-tol && o.T[1] < xmax[1]+tol {
+		rt = make([]float64, 3)
+		if sh.InvMap(rt, o.T, x) == nil {
+			if inside_refcell(sh.BasicType, rt[:2], tol) {
+				return false, true, rt
+			}
+		}
+	}
+
+	// normal level set @ corners
+	φ := make([]float64, nc)
+	var neg, pos bool
+	for k := 0; k < nc; k++ {
+		φ[k], _ = o.levelsets([]float64{x[0][k], x[1][k]})
+		if φ[k] < 0 {
+			neg = true
+		} else {
+			pos = true
+		}
+	}
+	if !neg || !pos {
+		return
+	}
+
+	// cut: all intersections with edges must be behind the tip
+	for k := 0; k < nc; k++ {
+		l := (k + 1) % nc
+		if φ[k]*φ[l] < 0 {
+			α := φ[k] / (φ[k] - φ[l])
+			_, ψ := o.levelsets([]float64{x[0][k] + α*(x[0][l]-x[0][k]), x[1][k] + α*(x[1][l]-x[1][k])})
+			if ψ >= 0 {
+				return
+			}
+		}
+	}
+	return true, false, nil
+}
+
+// enrichments finds the enrichment functions of each vertex of a cell
+//  Note: (1) vertices of cells containing the tip and vertices closer than Rtip to the tip are
+//            enriched with the branch functions; otherwise, vertices of cells cut by the crack are
+//            enriched with the Heaviside function
+//        (2) all cells sharing each vertex are checked; thus, all elements around a vertex agree
+//            on its enrichment and DOFs
+//  Output:
+//   enr -- [nverts] indices of enrichment functions of each vertex; see funcs and xcrk_keys
+func (o *xcrack) enrichments(msh *inp.Mesh, cell *inp.Cell) (enr [][]int) {
+	enr = make([][]int, len(cell.Verts))
+	status := make(map[int]int) // cell id => 0: uncut, 1: cut, 2: contains tip
+	for m, vid := range cell.Verts {
+		c := msh.Verts[vid].C
+		tipv := math.Hypot(c[0]-o.T[0], c[1]-o.T[1]) < o.Rtip
+		cutv := false
+		for _, nbr := range msh.Vert2cells[vid] {
+			if nbr.IsJoint || nbr.Shp == nil || nbr.Shp.Gndim != 2 {
+				continue
+			}
+			st, ok := status[nbr.Id]
+			if !ok {
+				x := la.MatAlloc(2, nbr.Shp.Nverts)
+				for j, v := range nbr.Verts {
+					x[0][j], x[1][j] = msh.Verts[v].C[0], msh.Verts[v].C[1]
+				}
+				cut, tip, _ := o.classify(x, nbr.Shp)
+				switch {
+				case tip:
+					st = 2
+				case cut:
+					st = 1
+				}
+				status[nbr.Id] = st
+			}
+			tipv = tipv || st == 2
+			cutv = cutv || st == 1
+		}
+		switch {
+		case tipv:
+			enr[m] = []int{1, 2, 3, 4}
+		case cutv:
+			enr[m] = []int{0}
+		}
+	}
+	return
+}
+
+// subcell_ips computes integration points of cells cut by the crack or containing the tip
+//  Note: (1) the cell is split into triangles in the space of natural coordinates; thus, the crack
+//            is assumed straight in this space, which is exact for cells with affine mapping
+//        (2) cut cells: each part is split into triangles with a 12-point rule each
+//        (3) tip cell: triangles fan out from the tip and are integrated with the collapsed
+//            (Duffy) 5 × 5 rule to handle the 1/√r singularity of strains
+//        (4) the weights already include the area of the triangles in natural coordinates
+func (o *xcrack) subcell_ips(x [][]float64, sh *shp.Shape, tip bool, rt []float64) (ips []shp.Ipoint, err error) {
+
+	// polygons above (pos) and below (neg) the crack and polygon with intersections (all)
+	var pos, neg, all [][]float64
+	nc := sh.BasicNverts
+	φ := make([]float64, nc)
+	for k := 0; k < nc; k++ {
+		φ[k], _ = o.levelsets([]float64{x[0][k], x[1][k]})
+	}
+	for k := 0; k < nc; k++ {
+		l := (k + 1) % nc
+		rk := []float64{sh.NatCoords[0][k], sh.NatCoords[1][k]}
+		if φ[k] < 0 {
+			neg = append(neg, rk)
+		} else {
+			pos = append(pos, rk)
+		}
+		all = append(all, rk)
+		if φ[k]*φ[l] < 0 {
+			α := φ[k] / (φ[k] - φ[l])
+			ri := []float64{rk[0] + α*(sh.NatCoords[0][l]-rk[0]), rk[1] + α*(sh.NatCoords[1][l]-rk[1])}
+			pos = append(pos, ri)
+			neg = append(neg, ri)
+			all = append(all, ri)
+		}
+	}
+
+	// tip cell
+	if tip {
+		var gl []shp.Ipoint
+		gl, err = shp.GetIpsBasic("lin", 5)
+		if err != nil {
+			return
+		}
+		n := len(all)
+		for k := 0; k < n; k++ {
+			p, q := all[k], all[(k+1)%n]
+			a := (p[0]-rt[0])*(q[1]-rt[1]) - (p[1]-rt[1])*(q[0]-rt[0]) // 2 × area
+			if a < 1e-14 {
+				continue
+			}
+			for _, ipu := range gl {
+				u := (1.0 + ipu[0]) / 2.0
+				for _, ipv := range gl {
+					v := (1.0 + ipv[0]) / 2.0
+					r := rt[0] + u*(p[0]-rt[0]) + u*v*(q[0]-p[0])
+					s := rt[1] + u*(p[1]-rt[1]) + u*v*(q[1]-p[1])
+					ips = append(ips, shp.Ipoint{r, s, 0, ipu[3] * ipv[3] * u * a / 4.0})
+				}
+			}
+		}
+		return
+	}
+
+	// cut cell
+	var tri []shp.Ipoint
+	tri, err = shp.GetIpsBasic("tri", 12)
+	if err != nil {
+		return
+	}
+	for _, poly := range [][][]float64{pos, neg} {
+		for k := 1; k < len(poly)-1; k++ {
+			p0, p1, p2 := poly[0], poly[k], poly[k+1]
+			a := (p1[0]-p0[0])*(p2[1]-p0[1]) - (p1[1]-p0[1])*(p2[0]-p0[0]) // 2 × area
+			if a < 1e-14 {
+				continue
+			}
+			for _, ip := range tri {
+				r := p0[0] + ip[0]*(p1[0]-p0[0]) + ip[1]*(p2[0]-p0[0])
+				s := p0[1] + ip[0]*(p1[1]-p0[1]) + ip[1]*(p2[1]-p0[1])
+				ips = append(ips, shp.Ipoint{r, s, 0, ip[3] * a})
+			}
+		}
+	}
 	return
 }
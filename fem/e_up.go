@@ -38,6 +38,7 @@ type ElemUP struct {
 	divus float64     // divus
 	bs    []float64   // bs = as - g = α1・u - ζs - g; (Eqs 35b and A.1 [1]) with 'as' being the acceleration of solids and g, gravity
 	hl    []float64   // hl = -ρL・bs - ∇pl; Eq (A.1) of [1]
+	vs    []float64   // vs = α4・u - χs; velocity of solids (for Rayleigh damping)
 	Kup   [][]float64 // [nu][np] Kup := dRus/dpl consistent tangent matrix
 	Kpu   [][]float64 // [np][nu] Kpu := dRpl/dus consistent tangent matrix
 
@@ -98,6 +99,9 @@ func init() {
 		// new LBB cell
 		if !sim.Data.NoLBB {
 			o.LbbCell = o.Cell.GetSimilar(true)
+			if sim.Data.Nworkers > 1 { // similar cells share the shape structure
+				o.LbbCell.Shp = o.LbbCell.Shp.GetCopy()
+			}
 		}
 
 		// allocate u element
@@ -106,6 +110,9 @@ func init() {
 			chk.Panic("cannot allocate underlying u-element")
 		}
 		o.U = u_elem.(*ElemU)
+		if o.U.MdlLarge != nil {
+			chk.Panic("up-element does not work with large deformation models yet")
+		}
 
 		// make sure p-element uses the same number of integration points than u-element
 		edat.Nip = len(o.U.IpsElem)
@@ -121,6 +128,7 @@ func init() {
 		// scratchpad. computed @ each ip
 		o.bs = make([]float64, o.Ndim)
 		o.hl = make([]float64, o.Ndim)
+		o.vs = make([]float64, o.Ndim)
 		o.Kup = la.MatAlloc(o.U.Nu, o.P.Np)
 		o.Kpu = la.MatAlloc(o.P.Np, o.U.Nu)
 
@@ -250,6 +258,10 @@ func (o *ElemUP) AddToRhs(fb []float64, sol *Solution) (err error) {
 	u_nverts := o.U.Cell.Shp.Nverts
 	p_nverts := o.P.Cell.Shp.Nverts
 	var coef, plt, klr, ρl, ρ, p, Cpl, Cvs, divvs float64
+	var αR float64 // Rayleigh damping: mass-proportional coefficient
+	if !sol.Steady {
+		αR = o.U.Ray.A
+	}
 	var r int
 	for idx, ip := range o.U.IpsElem {
 
@@ -315,7 +327,7 @@ func (o *ElemUP) AddToRhs(fb []float64, sol *Solution) (err error) {
 			for m := 0; m < u_nverts; m++ {
 				for i := 0; i < o.Ndim; i++ {
 					r = o.U.Umap[i+m*o.Ndim]
-					fb[r] -= coef * S[m] * ρ * o.bs[i]
+					fb[r] -= coef * S[m] * ρ * (o.bs[i] + αR*o.vs[i])
 					fb[r] += coef * p * G[m][i]
 				}
 			}
@@ -323,7 +335,7 @@ func (o *ElemUP) AddToRhs(fb []float64, sol *Solution) (err error) {
 			for m := 0; m < u_nverts; m++ {
 				for i := 0; i < o.Ndim; i++ {
 					r = o.U.Umap[i+m*o.Ndim]
-					fb[r] -= coef * S[m] * ρ * o.bs[i]
+					fb[r] -= coef * S[m] * ρ * (o.bs[i] + αR*o.vs[i])
 					for j := 0; j < o.Ndim; j++ {
 						fb[r] -= coef * tsr.M2T(σe, i, j) * G[m][j]
 					}
@@ -340,6 +352,15 @@ func (o *ElemUP) AddToRhs(fb []float64, sol *Solution) (err error) {
 		}
 	}
 
+	// Rayleigh damping: stiffness-proportional term
+	if !sol.Steady && o.U.Ray.B > 0 {
+		err = o.U.rayleigh_init(sol, false)
+		if err != nil {
+			return
+		}
+		rayleigh_add_to_rhs(fb, o.U.Cray, o.U.Umap, sol)
+	}
+
 	// external forces
 	if len(o.U.NatBcs) > 0 {
 		err = o.U.add_surfloads_to_rhs(fb, sol)
@@ -356,7 +377,7 @@ func (o *ElemUP) AddToRhs(fb []float64, sol *Solution) (err error) {
 }
 
 // adds element K to global Jacobian matrix Kb
-func (o *ElemUP) AddToKb(Kb *la.Triplet, sol *Solution, firstIt bool) (err error) {
+func (o *ElemUP) AddToKb(Kb *Triplet, sol *Solution, firstIt bool) (err error) {
 
 	// clear matrices
 	u_nverts := o.U.Cell.Shp.Nverts
@@ -390,6 +411,10 @@ func (o *ElemUP) AddToKb(Kb *la.Triplet, sol *Solution, firstIt bool) (err error
 	α1 := sol.DynCfs.α1
 	α4 := sol.DynCfs.α4
 	β1 := sol.DynCfs.β1
+	var αR float64 // Rayleigh damping: mass-proportional coefficient
+	if !sol.Steady {
+		αR = o.U.Ray.A
+	}
 	for idx, ip := range o.U.IpsElem {
 
 		// interpolation functions, gradients and variables @ ip
@@ -452,7 +477,7 @@ func (o *ElemUP) AddToKb(Kb *la.Triplet, sol *Solution, firstIt bool) (err error
 					}
 
 					// add ∂rl/∂pl^n and ∂p/∂pl^n: Eqs (A.9) and (A.11) of [1]
-					o.Kup[c][n] += coef * (S[m]*Sb[n]*dρdpl*o.bs[j] - G[m][j]*Sb[n]*dpdpl)
+					o.Kup[c][n] += coef * (S[m]*Sb[n]*dρdpl*(o.bs[j]+αR*o.vs[j]) - G[m][j]*Sb[n]*dpdpl)
 
 					// for seepage face
 					if o.P.DoExtrap {
@@ -496,7 +521,7 @@ func (o *ElemUP) AddToKb(Kb *la.Triplet, sol *Solution, firstIt bool) (err error
 				for n := 0; n < u_nverts; n++ {
 					for j := 0; j < o.Ndim; j++ {
 						c = j + n*o.Ndim
-						o.U.K[r][c] += coef * S[m] * (S[n]*α1*ρ*tsr.It[i][j] + dρdusM*o.bs[i]*G[n][j])
+						o.U.K[r][c] += coef * S[m] * (S[n]*(α1+αR*α4)*ρ*tsr.It[i][j] + dρdusM*(o.bs[i]+αR*o.vs[i])*G[n][j])
 					}
 				}
 			}
@@ -517,6 +542,19 @@ func (o *ElemUP) AddToKb(Kb *la.Triplet, sol *Solution, firstIt bool) (err error
 		}
 	}
 
+	// Rayleigh damping: stiffness-proportional term
+	if !sol.Steady && o.U.Ray.B > 0 {
+		err = o.U.rayleigh_init(sol, false)
+		if err != nil {
+			return
+		}
+		for i := 0; i < o.U.Nu; i++ {
+			for j := 0; j < o.U.Nu; j++ {
+				o.U.K[i][j] += α4 * o.U.Cray[i][j]
+			}
+		}
+	}
+
 	// contribution from natural boundary conditions
 	if o.P.HasSeep {
 		err = o.P.add_natbcs_to_jac(sol)
@@ -776,9 +814,11 @@ func (o *ElemUP) ipvars(idx int, sol *Solution) (err error) {
 
 	// compute bs and hl. see Eqs (A.1) of [1]
 	α1 := sol.DynCfs.α1
+	α4 := sol.DynCfs.α4
 	for i := 0; i < o.Ndim; i++ {
 		o.bs[i] = α1*o.U.us[i] - o.U.ζs[idx][i] - o.P.g[i]
 		o.hl[i] = -ρL*o.bs[i] - o.P.gpl[i]
+		o.vs[i] = α4*o.U.us[i] - o.U.χs[idx][i]
 	}
 	return
 }
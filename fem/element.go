@@ -33,9 +33,9 @@ type Elem interface {
 	InterpStarVars(sol *Solution) (err error) // interpolate star variables to integration points
 
 	// called for each iteration
-	AddToRhs(fb []float64, sol *Solution) (err error)                // adds -R to global residual vector fb
-	AddToKb(Kb *la.Triplet, sol *Solution, firstIt bool) (err error) // adds element K to global Jacobian matrix Kb
-	Update(sol *Solution) (err error)                                // perform (tangent) update
+	AddToRhs(fb []float64, sol *Solution) (err error)             // adds -R to global residual vector fb
+	AddToKb(Kb *Triplet, sol *Solution, firstIt bool) (err error) // adds element K to global Jacobian matrix Kb
+	Update(sol *Solution) (err error)                             // perform (tangent) update
 
 	// reading and writing of element data
 	Encode(enc Encoder) (err error) // encodes internal variables
@@ -60,6 +60,36 @@ type ElemIntvars interface {
 	Ureset(sol *Solution) (err error)                              // fixes internal variables after u (displacements) have been zeroed
 }
 
+// ElemExplicit defines elements that can be used with the explicit (central-difference) solver
+type ElemExplicit interface {
+	AddToLumpedM(mb []float64, sol *Solution) (err error) // adds diagonal (lumped) mass matrix to global vector mb
+	CritDt(sol *Solution) (Δtcr float64, err error)       // returns an estimate of the critical time step of this element
+}
+
+// ElemMass defines elements that can compute the consistent mass matrix; e.g. for modal analyses
+type ElemMass interface {
+	AddToM(M *la.Triplet, sol *Solution) (err error) // adds consistent mass matrix to global matrix M
+}
+
+// ElemGeoStiff defines elements that can compute the geometric (initial stress) stiffness matrix;
+// e.g. for linear buckling analyses
+type ElemGeoStiff interface {
+	AddToKg(Kg *la.Triplet, sol *Solution) (err error) // adds geometric stiffness matrix to global matrix Kg
+}
+
+// ElemBeam defines beam elements that can compute and plot bending moment diagrams
+type ElemBeam interface {
+	CalcVandM(sol *Solution, s float64, nstations int) (V, M []float64)         // calculates shear force and bending moment @ s or stations
+	PlotDiagMoment(M []float64, withtext bool, numfmt string, tolM, sf float64) // plots bending moment diagram
+}
+
+// ElemVelocity defines elements that can compute a velocity field at their integration points;
+// e.g. the seepage velocity of the liquid computed by p-elements
+type ElemVelocity interface {
+	Ipoints() (coords [][]float64)                              // returns the real coordinates of integration points [nip][ndim]
+	IpVelocity(v []float64, idx int, sol *Solution) (err error) // computes velocity v[ndim] at integration point idx
+}
+
 // Info holds all information required to set a simulation stage
 type Info struct {
 
@@ -128,6 +158,17 @@ func BuildCoordsMatrix(cell *inp.Cell, msh *inp.Mesh) (x [][]float64) {
 	return
 }
 
+// cell_mesh returns the mesh containing cell
+func cell_mesh(sim *inp.Simulation, cell *inp.Cell) *inp.Mesh {
+	for _, reg := range sim.Regions {
+		if cell.Id < len(reg.Msh.Cells) && reg.Msh.Cells[cell.Id] == cell {
+			return reg.Msh
+		}
+	}
+	chk.Panic("cannot find mesh of cell %d", cell.Id)
+	return nil
+}
+
 // infogetters holds all available formulations/info; elemType => infogetter
 var infogetters = make(map[string]func(sim *inp.Simulation, cell *inp.Cell, edat *inp.ElemData) *Info)
 
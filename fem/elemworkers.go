@@ -0,0 +1,213 @@
+// Copyright 2015 Dorival Pedroso and Raul Durand. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fem
+
+import (
+	"sync"
+
+	"github.com/cpmech/gosl/la"
+)
+
+// elemWorkers performs the computations of elements concurrently (shared-memory parallelism)
+//  Note: (1) the elements are split into contiguous groups; one per goroutine (worker)
+//        (2) each worker assembles its own fb and Kb; these are then added to the global ones in
+//            the order of workers. Therefore, the results are deterministic but may differ from
+//            the serial ones by round-off errors
+//        (3) connector elements (e.g. joints) access data of other elements; thus, they are
+//            handled serially after all workers have finished
+//        (4) material models and shape structures must not be shared among elements; see
+//            Data.Nworkers and Domain.SetStage
+type elemWorkers struct {
+	elems  [][]Elem    // [nworkers][nelemsInWorker] elements of each worker
+	serial []Elem      // elements to be handled serially
+	fbs    [][]float64 // [nworkers][nyb] right-hand side vectors of each worker
+	kbs    []*Triplet  // [nworkers] Jacobian matrices of each worker
+	errs   []error     // [nworkers] errors of each worker
+}
+
+// new_elem_workers allocates a new elemWorkers structure
+//  Input:
+//   nworkers -- number of workers
+//   elems    -- all elements
+//   enz      -- [nelems] number of non-zeros in Kb of each element
+//   nyb      -- total number of equations
+func new_elem_workers(nworkers int, elems []Elem, enz []int, nyb int) (o *elemWorkers) {
+
+	// elements
+	o = new(elemWorkers)
+	var concurrent []Elem
+	var nz []int
+	for i, e := range elems {
+		if _, ok := e.(ElemConnector); ok {
+			o.serial = append(o.serial, e)
+			continue
+		}
+		concurrent = append(concurrent, e)
+		nz = append(nz, enz[i])
+	}
+
+	// split elements
+	if nworkers > len(concurrent) {
+		nworkers = len(concurrent)
+	}
+	o.elems = make([][]Elem, nworkers)
+	o.fbs = make([][]float64, nworkers)
+	o.kbs = make([]*Triplet, nworkers)
+	o.errs = make([]error, nworkers)
+	start := 0
+	for w := 0; w < nworkers; w++ {
+		end := start + (len(concurrent)-start)/(nworkers-w)
+		nnz := 0
+		for i := start; i < end; i++ {
+			nnz += nz[i]
+		}
+		o.elems[w] = concurrent[start:end]
+		o.fbs[w] = make([]float64, nyb)
+		o.kbs[w] = new(Triplet)
+		o.kbs[w].Init(nyb, nyb, nnz, false, true) // only read by add_to_kb
+		start = end
+	}
+	return
+}
+
+// run runs fcn concurrently for each worker and returns the error of the first failed worker
+func (o *elemWorkers) run(fcn func(w int) error) (err error) {
+	var wg sync.WaitGroup
+	for w := 0; w < len(o.elems); w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			o.errs[w] = fcn(w)
+		}(w)
+	}
+	wg.Wait()
+	for _, err = range o.errs {
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+// add_to_rhs adds the contributions of all elements to fb
+func (o *elemWorkers) add_to_rhs(fb []float64, sol *Solution) (err error) {
+	err = o.run(func(w int) (err error) {
+		la.VecFill(o.fbs[w], 0)
+		for _, e := range o.elems[w] {
+			err = e.AddToRhs(o.fbs[w], sol)
+			if err != nil {
+				return
+			}
+		}
+		return
+	})
+	if err != nil {
+		return
+	}
+	for w := 0; w < len(o.elems); w++ {
+		la.VecAdd(fb, 1, o.fbs[w]) // fb += fbs[w]
+	}
+	for _, e := range o.serial {
+		err = e.AddToRhs(fb, sol)
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+// add_to_kb adds the contributions of all elements to Kb
+func (o *elemWorkers) add_to_kb(Kb *Triplet, sol *Solution, firstIt bool) (err error) {
+	err = o.run(func(w int) (err error) {
+		o.kbs[w].Start()
+		for _, e := range o.elems[w] {
+			err = e.AddToKb(o.kbs[w], sol, firstIt)
+			if err != nil {
+				return
+			}
+		}
+		return
+	})
+	if err != nil {
+		return
+	}
+	for w := 0; w < len(o.elems); w++ {
+		Kb.PutTriplet(o.kbs[w])
+	}
+	for _, e := range o.serial {
+		err = e.AddToKb(Kb, sol, firstIt)
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+// update updates the secondary variables of all elements
+func (o *elemWorkers) update(sol *Solution) (err error) {
+	err = o.run(func(w int) (err error) {
+		for _, e := range o.elems[w] {
+			err = e.Update(sol)
+			if err != nil {
+				return
+			}
+		}
+		return
+	})
+	if err != nil {
+		return
+	}
+	for _, e := range o.serial {
+		err = e.Update(sol)
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+// element computations ////////////////////////////////////////////////////////////////////////////
+
+// elems_add_to_rhs adds the contributions of all elements to fb
+func elems_add_to_rhs(fb []float64, d *Domain) (err error) {
+	if d.wrk != nil {
+		return d.wrk.add_to_rhs(fb, d.Sol)
+	}
+	for _, e := range d.Elems {
+		err = e.AddToRhs(fb, d.Sol)
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+// elems_add_to_kb adds the contributions of all elements to d.Kb. d.Kb.Start() must be called before
+func elems_add_to_kb(d *Domain, firstIt bool) (err error) {
+	if d.wrk != nil {
+		return d.wrk.add_to_kb(d.Kb, d.Sol, firstIt)
+	}
+	for _, e := range d.Elems {
+		err = e.AddToKb(d.Kb, d.Sol, firstIt)
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+// elems_update updates the secondary variables of all elements
+func elems_update(d *Domain) (err error) {
+	if d.wrk != nil {
+		return d.wrk.update(d.Sol)
+	}
+	for _, e := range d.Elems {
+		err = e.Update(d.Sol)
+		if err != nil {
+			return
+		}
+	}
+	return
+}
@@ -8,6 +8,7 @@ import (
 	"log"
 	"math"
 	"sort"
+	"strings"
 
 	"github.com/cpmech/gosl/chk"
 	"github.com/cpmech/gosl/fun"
@@ -39,23 +40,45 @@ type EssentialBc struct {
 
 // EssentialBcs implements a structure to record the definition of essential bcs / constraints.
 // Each constraint will have a unique Lagrange multiplier index.
+//  Note: single-point constraints can alternatively be enforced by elimination or penalty; see
+//        SolverData.Spcs. Lagrange multipliers are then used for coupled constraints only; e.g.
+//        rigid, incsup and mpc
 type EssentialBcs struct {
 	HydFcn *HydroStatic   // for computing hydrostatic conditions
 	Eq2idx map[int][]int  // maps eq number to indices in BcsTmp
-	Bcs    []*EssentialBc // active essential bcs / constraints
-	A      la.Triplet     // matrix of coefficients 'A'
+	Bcs    []*EssentialBc // active essential bcs / constraints (with Lagrange multipliers)
+	A      Triplet        // matrix of coefficients 'A'
 	Am     *la.CCMatrix   // compressed form of A matrix
 
+	// single-point constraints without Lagrange multipliers
+	Method string         // method for single-point constraints: "lagrange", "elim" or "penalty"
+	Factor float64        // penalty method: factor multiplying the largest diagonal entry of Kb
+	Spcs   []*EssentialBc // active single-point constraints enforced by elimination or penalty
+	κ      float64        // penalty coefficient; computed by AddSpcsToKb
+	spceq  []bool         // [ny] equation has single-point constraint enforced by elimination or penalty
+	δspc   []float64      // [len(Spcs)] last increments computed by SpcsIncrements
+
 	// temporary
 	BcsTmp eqbcpairs // temporary essential bcs / constraints, including inactive ones. maps the first equation number to bcs
 }
 
 // Reset initialises this structure. It also performs a reset of internal structures.
-func (o *EssentialBcs) Init(hydfcn *HydroStatic) {
+//  method -- method for single-point constraints: "" or "lagrange", "elim" or "penalty"
+//  factor -- penalty method: factor multiplying the largest diagonal entry of Kb
+func (o *EssentialBcs) Init(hydfcn *HydroStatic, method string, factor float64) {
 	o.BcsTmp = make([]eqbcpair, 0)
 	o.Eq2idx = make(map[int][]int)
 	o.Bcs = make([]*EssentialBc, 0)
 	o.HydFcn = hydfcn
+	o.Method = method
+	if o.Method == "" {
+		o.Method = "lagrange"
+	}
+	o.Factor = factor
+	o.Spcs = make([]*EssentialBc, 0)
+	o.κ = 0
+	o.spceq = nil
+	o.δspc = nil
 }
 
 // Build builds this structure and its iternal data
@@ -67,21 +90,29 @@ func (o *EssentialBcs) Build(ny int) (nλ, nnzA int) {
 	sort.Sort(o.BcsTmp)
 
 	// count number of active constraints and non-zeros in matrix A
+	o.spceq = make([]bool, ny)
 	for _, pair := range o.BcsTmp {
 		if !pair.bc.Inact {
+			if o.Method != "lagrange" && pair.bc.single() {
+				o.Spcs = append(o.Spcs, pair.bc)
+				o.spceq[pair.bc.Eqs[0]] = true
+				continue
+			}
 			o.Bcs = append(o.Bcs, pair.bc)
 			nλ += 1
 			nnzA += len(pair.bc.ValsA)
 		}
 	}
 
+	o.δspc = make([]float64, len(o.Spcs))
+
 	// skip if there are no constraints
 	if nλ == 0 {
 		return
 	}
 
 	// set matrix A
-	o.A.Init(nλ, ny, nnzA)
+	o.A.Init(nλ, ny, nnzA, true, true)
 	for i, c := range o.Bcs {
 		for j, eq := range c.Eqs {
 			o.A.Put(i, eq, c.ValsA[j])
@@ -115,6 +146,88 @@ func (o EssentialBcs) AddToRhs(fb []float64, sol *Solution) {
 	la.SpMatVecMulAdd(fb[ny:], -1, o.Am, sol.Y) // fb += -1 * A * y
 }
 
+// AddSpcsToKb adds the terms of single-point constraints enforced by elimination or penalty to Kb
+//  Input:
+//   Kb    -- Jacobian matrix with all element (and A) terms already assembled
+//   root  -- this is the root processor; diagonal terms are added by the root processor only
+//  Note: (1) elimination: rows and columns of constrained equations are removed and ones are put
+//            on the diagonal. Kb remains symmetric if the element matrices are symmetric
+//        (2) penalty: κ = Factor × max(|Kii|) is added to the diagonal of constrained equations
+func (o *EssentialBcs) AddSpcsToKb(Kb *Triplet, root bool) (err error) {
+
+	// skip if there are no single-point constraints without Lagrange multipliers
+	if len(o.Spcs) == 0 {
+		return
+	}
+
+	// elimination
+	if o.Method == "elim" {
+		Kb.Filter(func(i, j int) bool {
+			return !(i < len(o.spceq) && o.spceq[i]) && !(j < len(o.spceq) && o.spceq[j])
+		})
+		if root {
+			for _, bc := range o.Spcs {
+				Kb.Put(bc.Eqs[0], bc.Eqs[0], 1)
+			}
+		}
+		return
+	}
+
+	// penalty
+	diag := make([]float64, len(o.spceq))
+	for k := 0; k < len(Kb.I); k++ {
+		if Kb.I[k] == Kb.J[k] && Kb.I[k] < len(diag) {
+			diag[Kb.I[k]] += Kb.X[k]
+		}
+	}
+	var dmax float64
+	for _, v := range diag {
+		dmax = utl.Max(dmax, math.Abs(v))
+	}
+	if dmax < 1e-15 {
+		dmax = 1
+	}
+	o.κ = o.Factor * dmax
+	if root {
+		for _, bc := range o.Spcs {
+			Kb.Put(bc.Eqs[0], bc.Eqs[0], o.κ)
+		}
+	}
+	return
+}
+
+// AddSpcsToRhs adds the terms of single-point constraints enforced by elimination or penalty to fb
+//  Note: this function must be called after all other terms have been added to fb
+func (o *EssentialBcs) AddSpcsToRhs(fb []float64, sol *Solution) {
+	for _, bc := range o.Spcs {
+		eq := bc.Eqs[0]
+		if o.Method == "elim" {
+			fb[eq] = bc.Fcn.F(sol.T, nil) - sol.Y[eq]
+		} else {
+			fb[eq] += o.κ * (bc.Fcn.F(sol.T, nil) - sol.Y[eq])
+		}
+	}
+}
+
+// SpcsIncrements computes the increments of y that satisfy the single-point constraints enforced
+// by elimination or penalty at time t
+//  Input:
+//   δy -- [≥ ny] increments; the entries of non-constrained equations are set to zero
+//  Output:
+//   has -- there are single-point constraints without Lagrange multipliers
+func (o *EssentialBcs) SpcsIncrements(δy []float64, sol *Solution, t float64) (has bool) {
+	if len(o.Spcs) == 0 {
+		return
+	}
+	la.VecFill(δy, 0)
+	for k, bc := range o.Spcs {
+		eq := bc.Eqs[0]
+		o.δspc[k] = bc.Fcn.F(t, nil) - sol.Y[eq]
+		δy[eq] = o.δspc[k]
+	}
+	return true
+}
+
 // add adds new essential bcs / constraint and sets map eq2idx
 func (o *EssentialBcs) add(key string, eqs []int, valsA []float64, fcn fun.Func) {
 	idx := len(o.BcsTmp)
@@ -136,6 +249,22 @@ func (o *EssentialBcs) add_single(key string, eq int, fcn fun.Func) {
 	o.add(key, []int{eq}, []float64{1}, fcn)
 }
 
+// single returns whether this is a single-point constraint; e.g. "ux" or "pl" (from "hst")
+func (o *EssentialBc) single() bool {
+	return len(o.Eqs) == 1 && o.Key != "mpc"
+}
+
+// has_single returns whether equation eq has an active single-point constraint
+func (o *EssentialBcs) has_single(eq int) bool {
+	for _, idx := range o.Eq2idx[eq] {
+		bc := o.BcsTmp[idx].bc
+		if !bc.Inact && bc.single() {
+			return true
+		}
+	}
+	return false
+}
+
 // GetFirstYandCmap returns the initial "yandc" map with additional keys that EssentialBcs can handle
 //  rigid  -- define rigid element constraints
 //  incsup -- inclined support constraints
@@ -145,8 +274,8 @@ func GetIsEssenKeyMap() map[string]bool {
 }
 
 // Set sets a constraint if it does NOT exist yet.
-//  key   -- can be Dof key such as "ux", "uy" or constraint type such as "mpc" or "rigid"
-//  extra -- is a keycode-style data. e.g. "!type:incsup2d !alp:30"
+//  key   -- can be Dof key such as "ux", "uy" or constraint type such as "rigid"; see SetMpc for "mpc"
+//  extra -- is a keycode-style data. e.g. "!alp:30" or "!type:line !d:1,1,0"; see incsup_coefficients
 //  Notes: 1) the default for key is single point constraint; e.g. "ux", "uy", ...
 //         2) hydraulic head can be set with key == "H"
 func (o *EssentialBcs) Set(key string, nodes []*Node, fcn fun.Func, extra string) (err error) {
@@ -176,37 +305,34 @@ func (o *EssentialBcs) Set(key string, nodes []*Node, fcn fun.Func, extra string
 	// inclined support
 	if key == "incsup" {
 
-		// check
-		if ndim != 2 {
-			return chk.Err("inclined support works only in 2D for now")
-		}
-
 		// get data
-		var α float64
-		if val, found := io.Keycode(extra, "alp"); found {
-			α = io.Atof(val) * math.Pi / 180.0
+		rows, err := incsup_coefficients(ndim, extra)
+		if err != nil {
+			return err
 		}
-		co, si := math.Cos(α), math.Sin(α)
 
 		// set for all nodes
+		ukeys := []string{"ux", "uy", "uz"}
 		for _, nod := range nodes {
 
-			// find existent constraints and deactivate them
-			eqx := nod.Dofs[0].Eq
-			eqy := nod.Dofs[1].Eq
-			for _, eq := range []int{eqx, eqy} {
-				for _, idx := range o.Eq2idx[eq] {
-					pair := o.BcsTmp[idx]
-					if pair.bc.Key != "rigid" {
-						pair.bc.Inact = true
-					}
+			// equations
+			eqs := make([]int, ndim)
+			for i := 0; i < ndim; i++ {
+				eqs[i] = nod.GetEq(ukeys[i])
+				if eqs[i] < 0 {
+					return chk.Err("dof=%q cannot be found in node=%d for setting inclined support", ukeys[i], nod.Vert.Id)
 				}
 			}
 
-			// set constraint
-			o.add(key, []int{eqx, eqy}, []float64{co, si}, &fun.Zero)
+			// find existent constraints and deactivate them
+			o.deactivate_for_incsup(eqs, rows)
+
+			// set constraints
+			for _, vals := range rows {
+				o.add(key, eqs, vals, &fun.Zero)
+			}
 		}
-		return // success
+		return nil // success
 	}
 
 	// hydraulic head
@@ -253,8 +379,165 @@ func (o *EssentialBcs) Set(key string, nodes []*Node, fcn fun.Func, extra string
 	return
 }
 
+// SetMpc sets a linear multi-point constraint: Σ coefs[i] * y(nodes[i], keys[i]) = fcn(t)
+//  Note: (1) mpcs are not deactivated by single-point constraints set later; thus, mpcs must not
+//            be redundant with other constraints otherwise Kb becomes singular
+//        (2) the same dof may appear more than once; the coefficients are then added
+func (o *EssentialBcs) SetMpc(nodes []*Node, keys []string, coefs []float64, fcn fun.Func) (err error) {
+
+	// check
+	if len(nodes) < 1 || len(keys) != len(nodes) || len(coefs) != len(nodes) {
+		return chk.Err("numbers of nodes, keys and coefficients of multi-point constraint must be equal and greater than zero. %d, %d, %d", len(nodes), len(keys), len(coefs))
+	}
+
+	// equations
+	eqs := make([]int, len(nodes))
+	for i, nod := range nodes {
+		if nod == nil {
+			return chk.Err("node %d of multi-point constraint is not active", i)
+		}
+		eqs[i] = nod.GetEq(keys[i])
+		if eqs[i] < 0 {
+			return chk.Err("dof=%q cannot be found in node=%d for setting multi-point constraint", keys[i], nod.Vert.Id)
+		}
+	}
+
+	// set constraint
+	vals := make([]float64, len(coefs))
+	copy(vals, coefs)
+	o.add("mpc", eqs, vals, fcn)
+	return
+}
+
 // auxiliary /////////////////////////////////////////////////////////////////////////////////////////
 
+// deactivate_for_incsup deactivates existing constraints on the equations of a new inclined support
+//  Note: (1) 2D: all constraints but rigid ones are replaced
+//        (2) 3D: rigid and multi-point constraints are kept
+//        (3) 3D: plane supports keep existing inclined supports of the same node with non-parallel
+//            normals; e.g. at edges and corners where support planes meet
+//        (4) 3D: line supports replace all other constraints at the node
+func (o *EssentialBcs) deactivate_for_incsup(eqs []int, rows [][]float64) {
+	if len(eqs) == 2 {
+		for _, eq := range eqs {
+			for _, idx := range o.Eq2idx[eq] {
+				pair := o.BcsTmp[idx]
+				if pair.bc.Key != "rigid" {
+					pair.bc.Inact = true
+				}
+			}
+		}
+		return
+	}
+	plane := len(rows) == 1
+	for _, eq := range eqs {
+		for _, idx := range o.Eq2idx[eq] {
+			bc := o.BcsTmp[idx].bc
+			switch bc.Key {
+			case "rigid", "mpc":
+				continue
+			case "incsup":
+				if plane && (len(bc.Eqs) != len(eqs) || bc.Eqs[0] != eqs[0] || math.Abs(la.VecDot(bc.ValsA, rows[0])) < 1.0-1e-10) {
+					continue
+				}
+			}
+			bc.Inact = true
+		}
+	}
+}
+
+// incsup_coefficients returns the (unit) coefficients of inclined support constraints
+//  Input:
+//   ndim  -- space dimension
+//   extra -- keycode-style data:
+//      2D: "!alp:30"  => support with normal (cos(α), sin(α)); or "!n:nx,ny"
+//          "!type:incsup2d !alp:30" is also accepted (legacy syntax)
+//      3D: "!type:plane" (default) => 1 constraint; u ⋅ n = 0, with n given by "!n:nx,ny,nz"
+//          "!type:line"            => 2 constraints; u moves along d, given by "!d:dx,dy,dz"
+//          alternatively, n or d can be given by the "!alp:" and "!bet:" angles (degrees):
+//            v = (cos(β) cos(α), cos(β) sin(α), sin(β))
+//  Output:
+//   rows -- [nconstraints][ndim] coefficients
+func incsup_coefficients(ndim int, extra string) (rows [][]float64, err error) {
+
+	// type
+	typ := "plane"
+	if val, found := io.Keycode(extra, "type"); found {
+		typ = val
+	}
+	if typ == "incsup2d" {
+		if ndim != 2 {
+			return nil, chk.Err("inclined support of type \"incsup2d\" is available in 2D only")
+		}
+		typ = "plane"
+	}
+	if typ != "plane" && typ != "line" {
+		return nil, chk.Err("inclined support type %q is not available. options are \"plane\" or \"line\"", typ)
+	}
+	if ndim == 2 && typ == "line" {
+		return nil, chk.Err("inclined support of type \"line\" is available in 3D only")
+	}
+
+	// vector
+	vkey := "n"
+	if typ == "line" {
+		vkey = "d"
+	}
+	v := make([]float64, ndim)
+	if val, found := io.Keycode(extra, vkey); found {
+		comps := strings.Split(val, ",")
+		if len(comps) != ndim {
+			return nil, chk.Err("inclined support vector %q must have %d components", val, ndim)
+		}
+		for i, c := range comps {
+			v[i] = io.Atof(c)
+		}
+	} else {
+		var α, β float64
+		if val, found := io.Keycode(extra, "alp"); found {
+			α = io.Atof(val) * math.Pi / 180.0
+		}
+		if val, found := io.Keycode(extra, "bet"); found {
+			β = io.Atof(val) * math.Pi / 180.0
+		}
+		v[0], v[1] = math.Cos(β)*math.Cos(α), math.Cos(β)*math.Sin(α)
+		if ndim == 3 {
+			v[2] = math.Sin(β)
+		}
+	}
+	norm := la.VecNorm(v)
+	if norm < 1e-10 {
+		return nil, chk.Err("inclined support vector must not be zero")
+	}
+	for i := 0; i < ndim; i++ {
+		v[i] /= norm
+	}
+
+	// plane: one constraint
+	if typ == "plane" {
+		return [][]float64{v}, nil
+	}
+
+	// line: two constraints perpendicular to d = v
+	a := []float64{0, 0, 0}
+	imin := 0
+	for i := 1; i < 3; i++ {
+		if math.Abs(v[i]) < math.Abs(v[imin]) {
+			imin = i
+		}
+	}
+	a[imin] = 1
+	e1 := make([]float64, 3)
+	e2 := make([]float64, 3)
+	utl.CrossProduct3d(e1, v, a)
+	norm = la.VecNorm(e1)
+	for i := 0; i < 3; i++ {
+		e1[i] /= norm
+	}
+	utl.CrossProduct3d(e2, v, e1)
+	return [][]float64{e1, e2}, nil
+}
+
 type eqbcpair struct {
 	eq int
 	bc *EssentialBc
@@ -269,18 +552,30 @@ func (o eqbcpairs) Less(i, j int) bool { return o[i].eq < o[j].eq }
 // List returns a simple list logging bcs at time t
 func (o *EssentialBcs) List(t float64) (l string) {
 	var pairs eqbcpairs
-	for _, bc := range o.Bcs {
+	bc2idx := make(map[*EssentialBc]string)
+	for i, bc := range o.Bcs {
+		bc2idx[bc] = io.Sf("%d", i)
 		for _, eq := range bc.Eqs {
 			pairs = append(pairs, eqbcpair{eq, bc})
 		}
 	}
+	for _, bc := range o.Spcs {
+		bc2idx[bc] = o.Method
+		pairs = append(pairs, eqbcpair{bc.Eqs[0], bc})
+	}
 	sort.Sort(pairs)
-	l = "\n  ====================================================================================\n"
-	l += io.Sf("  %8s%8s%23s%23s\n", "eq", "key", "value @ t=0", io.Sf("value @ t=%g", t))
-	l += "  ------------------------------------------------------------------------------------\n"
+	l = "\n  ================================================================================================\n"
+	l += io.Sf("  %8s%8s%8s%12s%23s%23s\n", "eq", "key", "λ", "coef", "value @ t=0", io.Sf("value @ t=%g", t))
+	l += "  ------------------------------------------------------------------------------------------------\n"
 	for _, p := range pairs {
-		l += io.Sf("  %8d%8s%23.13f%23.13f\n", p.eq, p.bc.Key, p.bc.Fcn.F(0, nil), p.bc.Fcn.F(t, nil))
+		coef := 0.0
+		for j, eq := range p.bc.Eqs {
+			if eq == p.eq {
+				coef += p.bc.ValsA[j]
+			}
+		}
+		l += io.Sf("  %8d%8s%8s%12g%23.13f%23.13f\n", p.eq, p.bc.Key, bc2idx[p.bc], coef, p.bc.Fcn.F(0, nil), p.bc.Fcn.F(t, nil))
 	}
-	l += "  ====================================================================================\n"
+	l += "  ================================================================================================\n"
 	return
 }
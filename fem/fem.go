@@ -82,10 +82,13 @@ func NewFEM(simfilepath, alias string, erasePrev, saveSummary, readSummary, allo
 			o.Nproc = mpi.Size()
 			distr = o.Nproc > 1
 			if distr {
+				if is_iterative(o.Sim.LinSol.Name) {
+					chk.Panic("iterative linear solvers cannot run in parallel yet")
+				}
 				o.Sim.LinSol.Name = "mumps"
 			}
 		}
-	} else {
+	} else if !is_iterative(o.Sim.LinSol.Name) {
 		o.Sim.LinSol.Name = "umfpack"
 	}
 	o.Verbose = verbose && (o.Proc == 0)
@@ -151,6 +154,14 @@ func (o *FEM) Run() (err error) {
 		if err != nil {
 			return
 		}
+
+		// save checkpoint
+		if stg.Save {
+			err = o.SaveCheckpoints(ckp_key_stg(o.Sim.DirOut, o.Sim.Key, stgidx))
+			if err != nil {
+				return
+			}
+		}
 	}
 	return
 }
@@ -158,8 +169,20 @@ func (o *FEM) Run() (err error) {
 // SetStage sets stage for all domains
 //  Input:
 //   stgidx -- stage index (in o.Sim.Stages)
+//  Note: if Stage.Load is given, the checkpoint files are read here and the state is set by ZeroStage
 func (o *FEM) SetStage(stgidx int) (err error) {
-	for _, d := range o.Domains {
+	stg := o.Sim.Stages[stgidx]
+	for i, d := range o.Domains {
+		d.ckp = nil
+		if stg.Load != "" {
+			err = d.ReadCheckpoint(ckp_path(stg.Load, o.Proc, i))
+			if err != nil {
+				return chk.Err("cannot read checkpoint %q:\n%v", stg.Load, err)
+			}
+			if i == 0 && o.Summary != nil {
+				o.Summary.restore_from(d.ckp, o.Sim.DirOut, o.Sim.Key)
+			}
+		}
 		err = d.SetStage(stgidx)
 		if err != nil {
 			return
@@ -168,6 +191,20 @@ func (o *FEM) SetStage(stgidx int) (err error) {
 	return
 }
 
+// SaveCheckpoints saves the complete state of all domains
+//  Input:
+//   ckey -- checkpoint key with path; e.g. /tmp/gofem/sim_stg0 => /tmp/gofem/sim_stg0_p0_d0.ckp
+//  Note: each processor saves its own files
+func (o *FEM) SaveCheckpoints(ckey string) (err error) {
+	for i, d := range o.Domains {
+		err = d.SaveCheckpoint(ckp_path(ckey, o.Proc, i), o.Summary, o.Verbose)
+		if err != nil {
+			return chk.Err("cannot save checkpoint:\n%v", err)
+		}
+	}
+	return
+}
+
 // ZeroStage zeroes solution varaibles; i.e. it initialises solution vectors (Y, dYdt, internal
 // values such as States.Sig, etc.) in all domains for all nodes and all elements
 //  Input:
@@ -71,6 +71,10 @@ func (o Domain) SaveSol(tidx int, verbose bool) (err error) {
 	if err != nil {
 		return chk.Err("cannot encode Domain.Sol.D2ydt2\n%v", err)
 	}
+	err = enc.Encode(o.Sol.React)
+	if err != nil {
+		return chk.Err("cannot encode Domain.Sol.React\n%v", err)
+	}
 
 	// save file
 	fn := out_nod_path(o.Sim.DirOut, o.Sim.Key, o.Sim.EncType, tidx, o.Proc)
@@ -108,6 +112,13 @@ func (o *Domain) ReadSol(dir, fnkey, enctype string, tidx int) (err error) {
 	if err != nil {
 		return chk.Err("cannot decode Domain.Sol.D2ydt2\n%v", err)
 	}
+	err = dec.Decode(&o.Sol.React)
+	if err == goio.EOF { // results saved without reactions
+		o.Sol.React, err = nil, nil
+	}
+	if err != nil {
+		return chk.Err("cannot decode Domain.Sol.React\n%v", err)
+	}
 	return
 }
 
@@ -0,0 +1,146 @@
+// Copyright 2015 Dorival Pedroso and Raul Durand. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fem
+
+import (
+	"math"
+
+	"github.com/cpmech/gosl/chk"
+	"github.com/cpmech/gosl/io"
+	"github.com/cpmech/gosl/mpi"
+)
+
+// IntegrateStress computes the integral of stresses over all solid (u) elements of this domain
+//  Input:
+//   sum -- [ncp] (Mandel) components of ∫σ dV; it is zeroed first
+//  Output:
+//   vol -- volume (or area × thickness in 2D) of solid elements
+func (o *Domain) IntegrateStress(sum []float64) (vol float64, err error) {
+	for i := 0; i < len(sum); i++ {
+		sum[i] = 0
+	}
+	for _, ele := range o.Elems {
+		e, ok := ele.(*ElemU)
+		if !ok {
+			continue
+		}
+		for idx, ip := range e.IpsElem {
+			err = e.Cell.Shp.CalcAtIp(e.X, ip, false)
+			if err != nil {
+				return
+			}
+			coef := e.Cell.Shp.J * ip[3] * e.Thickness
+			if o.Sim.Data.Axisym {
+				coef *= e.Cell.Shp.AxisymGetRadius(e.X)
+			}
+			for i, σ := range e.States[idx].Sig {
+				sum[i] += coef * σ
+			}
+			vol += coef
+		}
+	}
+	return
+}
+
+// Homogenize computes the effective stiffness of a representative volume element (RVE)
+//  Input:
+//   stgidx -- index of stage with periodic boundary conditions (see inp.PeriodicData)
+//   δ      -- magnitude of each macroscopic strain (Mandel) component applied in turn
+//  Output:
+//   C -- [ncp][ncp] effective stiffness in Mandel basis: σ̄ = C ⋅ ε̄, where σ̄ is the average
+//        stress over all solid elements of all domains
+//  Note: (1) one load case is solved for each strain component; in 2D (plane-strain), the column
+//            corresponding to εzz is zero
+//        (2) stresses are computed from the zero state; thus, for non-linear models, C is the
+//            secant stiffness that approximates the initial tangent stiffness if δ is small
+//        (3) the macroscopic strain of the stage is restored at the end
+func (o *FEM) Homogenize(stgidx int, δ float64) (C [][]float64, err error) {
+
+	// check
+	stg := o.Sim.Stages[stgidx]
+	if stg.Periodic == nil {
+		return nil, chk.Err("stage %d must have periodic boundary conditions for homogenisation", stgidx)
+	}
+	if math.Abs(δ) < 1e-15 {
+		return nil, chk.Err("magnitude of macroscopic strain must be non-zero. δ = %g is invalid", δ)
+	}
+
+	// restore macroscopic strain at the end
+	eps := stg.Periodic.Eps
+	defer func() { stg.Periodic.Eps = eps }()
+
+	// load cases
+	ndim := o.Sim.Ndim
+	ncp := 2 * ndim
+	cases := []int{0, 1, 3}
+	if ndim == 3 {
+		cases = []int{0, 1, 2, 3, 4, 5}
+	}
+
+	// effective stiffness
+	C = make([][]float64, ncp)
+	for i := 0; i < ncp; i++ {
+		C[i] = make([]float64, ncp)
+	}
+	sum := make([]float64, ncp+1)
+	wrk := make([]float64, ncp+1)
+	for _, j := range cases {
+
+		// macroscopic strain: Mandel => tensor components
+		ε := make([]float64, ncp)
+		ε[j] = δ
+		if j > 2 {
+			ε[j] = δ / math.Sqrt2
+		}
+		stg.Periodic.Eps = ε
+
+		// solve
+		err = o.SetStage(stgidx)
+		if err != nil {
+			return
+		}
+		err = o.SolveOneStage(stgidx, true)
+		if err != nil {
+			return
+		}
+
+		// average stress
+		for i := 0; i <= ncp; i++ {
+			sum[i] = 0
+		}
+		for _, d := range o.Domains {
+			var vol float64
+			vol, err = d.IntegrateStress(wrk[:ncp])
+			if err != nil {
+				return
+			}
+			for i := 0; i < ncp; i++ {
+				sum[i] += wrk[i]
+			}
+			sum[ncp] += vol
+		}
+		if o.Nproc > 1 {
+			mpi.AllReduceSum(sum, wrk)
+		}
+		if sum[ncp] < 1e-15 {
+			return nil, chk.Err("volume of solid elements is zero")
+		}
+		for i := 0; i < ncp; i++ {
+			C[i][j] = sum[i] / (sum[ncp] * δ)
+		}
+	}
+
+	// message
+	if o.Verbose {
+		io.Pf("\neffective stiffness (Mandel basis):\n")
+		for i := 0; i < ncp; i++ {
+			for j := 0; j < ncp; j++ {
+				io.Pf("%15g", C[i][j])
+			}
+			io.Pf("\n")
+		}
+	}
+	return
+}
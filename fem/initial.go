@@ -5,11 +5,21 @@
 package fem
 
 import (
+	"encoding/csv"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
 	"github.com/cpmech/gofem/inp"
 	"github.com/cpmech/gosl/chk"
+	"github.com/cpmech/gosl/io"
 )
 
 // SetInitial sets the initial state
+//  Note: (1) values from file are set first; thus functions may override them
+//        (2) internal variables of elements are initialised with the new initial values
 func (o *Domain) SetInitial(stg *inp.Stage) (err error) {
 
 	// check
@@ -17,6 +27,14 @@ func (o *Domain) SetInitial(stg *inp.Stage) (err error) {
 		return chk.Err("number of functions (fcns) must be equal to number of dofs for setting initial values. %d != %d", len(stg.Initial.Fcns), len(stg.Initial.Dofs))
 	}
 
+	// values from file
+	if stg.Initial.File != "" {
+		err = o.set_initial_from_file(stg.Initial)
+		if err != nil {
+			return chk.Err("cannot set initial values from file %q:\n%v", stg.Initial.File, err)
+		}
+	}
+
 	// loop over functions
 	for i, fname := range stg.Initial.Fcns {
 
@@ -36,5 +54,443 @@ func (o *Domain) SetInitial(stg *inp.Stage) (err error) {
 			o.Sol.Y[eq] = fcn.F(0, nod.Vert.C)
 		}
 	}
+
+	// internal variables
+	for _, e := range o.ElemIntvars {
+		err = e.SetIniIvs(o.Sol, nil)
+		if err != nil {
+			return
+		}
+	}
 	return
 }
+
+// set_initial_from_file sets initial values of Y, dY/dt and d²Y/dt² from file
+func (o *Domain) set_initial_from_file(dat *inp.InitialData) (err error) {
+
+	// format
+	format := dat.Fmt
+	if format == "" {
+		format = strings.TrimPrefix(io.FnExt(dat.File), ".")
+	}
+
+	// results file; e.g. dirout/fnkey_p0_nod_0000000010.gob
+	if format == "gob" || format == "json" {
+		return o.set_initial_from_results(dat, format)
+	}
+	if format != "csv" {
+		return chk.Err("format %q is not available. options are \"csv\", \"gob\" or \"json\"", format)
+	}
+
+	// csv file
+	tab, err := read_initial_csv(dat.File)
+	if err != nil {
+		return
+	}
+	mapping := dat.Map
+	if mapping == "" {
+		mapping = "nearest"
+		if tab.ids != nil {
+			mapping = "id"
+		}
+	}
+	return o.set_initial_from_table(tab, mapping)
+}
+
+// set_initial_from_table sets initial values of nodes from table
+//  Input:
+//   tab     -- table with values
+//   mapping -- "id", "nearest" or "interp"
+func (o *Domain) set_initial_from_table(tab *initialTable, mapping string) (err error) {
+
+	// mapping
+	switch mapping {
+	case "id":
+		if tab.ids == nil {
+			return chk.Err("column \"id\" is required for mapping by vertex id")
+		}
+	case "nearest", "interp":
+		if len(tab.x) < o.Msh.Ndim {
+			return chk.Err("columns with %d coordinates are required for mapping by coordinates", o.Msh.Ndim)
+		}
+	default:
+		return chk.Err("mapping %q is not available. options are \"id\", \"nearest\" or \"interp\"", mapping)
+	}
+	id2row := make(map[int]int)
+	for i, id := range tab.ids {
+		id2row[id] = i
+	}
+
+	// set nodes
+	vals := make([]float64, len(tab.cols))
+	for _, nod := range o.Nodes {
+
+		// values at node
+		switch mapping {
+		case "id":
+			i, ok := id2row[nod.Vert.Id]
+			if !ok {
+				continue
+			}
+			for k, col := range tab.cols {
+				vals[k] = col[i]
+			}
+		case "nearest":
+			i := tab.nearest(nod.Vert.C, o.Msh.Ndim)
+			for k, col := range tab.cols {
+				vals[k] = col[i]
+			}
+		case "interp":
+			tab.interp(vals, nod.Vert.C, o.Msh.Ndim)
+		}
+
+		// set solution
+		for k, key := range tab.keys {
+			eq := nod.GetEq(key)
+			if eq < 0 {
+				continue
+			}
+			switch tab.ders[k] {
+			case 0:
+				o.Sol.Y[eq] = vals[k]
+			case 1:
+				if len(o.Sol.Dydt) > 0 {
+					o.Sol.Dydt[eq] = vals[k]
+				}
+			case 2:
+				if len(o.Sol.D2ydt2) > 0 {
+					o.Sol.D2ydt2[eq] = vals[k]
+				}
+			}
+		}
+	}
+	return
+}
+
+// set_initial_from_results sets initial values from a results (nodes) file
+//  Note: (1) results files do not record the equation numbers; thus, these are obtained from the
+//            simulation (Initial.Sim) and stage (Initial.Stg) that wrote the file. Only the
+//            element information is used; i.e. elements and solvers are not allocated
+//        (2) by default, values are set by vertex id and dof key (as with checkpoints); the
+//            "nearest" and "interp" mappings (Initial.Map) use the coordinates of vertices
+//            instead. In all cases, dofs that do not exist in this domain are skipped
+func (o *Domain) set_initial_from_results(dat *inp.InitialData, format string) (err error) {
+
+	// simulation that wrote the file; read again because stages change data of regions
+	if dat.Sim == "" {
+		return chk.Err("simulation file (sim) that wrote the results file is required")
+	}
+	if _, err = os.Stat(dat.Sim); err != nil {
+		return chk.Err("cannot find simulation file of results:\n%v", err)
+	}
+	sim := inp.ReadSim(dat.Sim, "", false, o.Sim.GoroutineId)
+	if dat.Stg < 0 || dat.Stg >= len(sim.Stages) {
+		return chk.Err("stage index of results file is invalid: %d", dat.Stg)
+	}
+	ridx := 0
+	for i, reg := range o.Sim.Regions {
+		if reg == o.Reg {
+			ridx = i
+		}
+	}
+	if ridx >= len(sim.Regions) {
+		return chk.Err("simulation of results file has no region with index %d", ridx)
+	}
+
+	// equation numbers of results
+	nodes, ny, err := results_nodes(sim, ridx, dat.Stg)
+	if err != nil {
+		return chk.Err("cannot number equations of results file:\n%v", err)
+	}
+
+	// read results
+	var res Solution
+	fil, err := os.Open(dat.File)
+	if err != nil {
+		return
+	}
+	defer fil.Close()
+	dec := GetDecoder(fil, format)
+	for _, v := range []interface{}{&res.T, &res.Y, &res.Dydt, &res.D2ydt2} {
+		err = dec.Decode(v)
+		if err != nil {
+			return chk.Err("cannot decode results:\n%v", err)
+		}
+	}
+	if len(res.Y) != ny {
+		return chk.Err("number of primary variables in results file is not equal to the number of equations of stage %d. %d != %d", dat.Stg, len(res.Y), ny)
+	}
+
+	// set nodes
+	mapping := dat.Map
+	if mapping == "" {
+		mapping = "id"
+	}
+	for _, tab := range results_tables(nodes, &res, o.Msh.Ndim) {
+		err = o.set_initial_from_table(tab, mapping)
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+// results_nodes returns the nodes of a stage of a simulation with equation numbers as in SetStage
+//  Note: only the element information is used; i.e. elements are not allocated
+//  Output:
+//   nodes -- active nodes
+//   ny    -- number of equations
+func results_nodes(sim *inp.Simulation, ridx, stgidx int) (nodes []*Node, ny int, err error) {
+
+	// activate and deactivate elements as in the previous stages
+	reg := sim.Regions[ridx]
+	for i := 1; i <= stgidx; i++ {
+		for j, tags := range [][]int{sim.Stages[i].Activate, sim.Stages[i].Deactivate} {
+			for _, tag := range tags {
+				if tag >= 0 { // tag == cell.Id
+					tag = reg.Msh.Cells[tag].Tag
+				}
+				edat := reg.Etag2data(tag)
+				if edat == nil {
+					return nil, 0, chk.Err("cannot get element's data with etag=%d", tag)
+				}
+				edat.Inact = j == 1
+			}
+		}
+	}
+
+	// dofs of active elements
+	stg := sim.Stages[stgidx]
+	vid2node := make([]*Node, len(reg.Msh.Verts))
+	for _, cell := range reg.Msh.Cells {
+		err = cell.SetFaceConds(stg, sim.Functions)
+		if err != nil {
+			return
+		}
+		info, inactive, err := GetElemInfo(cell, reg, sim)
+		if err != nil {
+			return nil, 0, err
+		}
+		if inactive || cell.IsJoint {
+			continue
+		}
+		for j, v := range cell.Verts {
+			if vid2node[v] == nil {
+				vid2node[v] = NewNode(reg.Msh.Verts[v])
+				nodes = append(nodes, vid2node[v])
+			}
+			for _, ukey := range info.Dofs[j] {
+				ny = vid2node[v].AddDofAndEq(ukey, ny)
+			}
+		}
+	}
+	return
+}
+
+// results_tables returns one table for each dof key with the results at nodes
+//  Note: the tables have the vertex ids and coordinates of nodes; dY/dt and d²Y/dt² are
+//        included if available
+func results_tables(nodes []*Node, res *Solution, ndim int) (tabs []*initialTable) {
+	transient := len(res.Dydt) == len(res.Y) && len(res.D2ydt2) == len(res.Y)
+	key2tab := make(map[string]*initialTable)
+	for _, nod := range nodes {
+		for _, dof := range nod.Dofs {
+			tab, ok := key2tab[dof.Key]
+			if !ok {
+				tab = &initialTable{keys: []string{dof.Key}, ders: []int{0}, x: make([][]float64, ndim)}
+				if transient {
+					tab.keys = append(tab.keys, dof.Key, dof.Key)
+					tab.ders = append(tab.ders, 1, 2)
+				}
+				tab.cols = make([][]float64, len(tab.keys))
+				key2tab[dof.Key] = tab
+				tabs = append(tabs, tab)
+			}
+			tab.ids = append(tab.ids, nod.Vert.Id)
+			for k := 0; k < ndim; k++ {
+				tab.x[k] = append(tab.x[k], nod.Vert.C[k])
+			}
+			tab.cols[0] = append(tab.cols[0], res.Y[dof.Eq])
+			if transient {
+				tab.cols[1] = append(tab.cols[1], res.Dydt[dof.Eq])
+				tab.cols[2] = append(tab.cols[2], res.D2ydt2[dof.Eq])
+			}
+		}
+	}
+	return
+}
+
+// initialTable holds the initial values read from a csv file
+type initialTable struct {
+	ids  []int       // [nrows] vertex ids; nil if column "id" is not given
+	x    [][]float64 // [ndim][nrows] coordinates; from columns "x", "y" and "z"
+	keys []string    // [ncols] dof keys of values; e.g. "ux"
+	ders []int       // [ncols] time derivative of values: 0 => Y, 1 => dY/dt, 2 => d²Y/dt²
+	cols [][]float64 // [ncols][nrows] values
+}
+
+// read_initial_csv reads initial values from a csv file
+//  Note: (1) the first (non-comment) line must contain the names of columns: "id", "x", "y", "z"
+//            and dof keys. dY/dt and d²Y/dt² are given by the "_dt" and "_dt2" suffixes; e.g.
+//              id, x, y, pl, ux, ux_dt, ux_dt2
+//        (2) lines starting with "#" are ignored
+func read_initial_csv(fn string) (o *initialTable, err error) {
+
+	// read records
+	fil, err := os.Open(fn)
+	if err != nil {
+		return
+	}
+	defer fil.Close()
+	r := csv.NewReader(fil)
+	r.Comment = '#'
+	r.TrimLeadingSpace = true
+	records, err := r.ReadAll()
+	if err != nil {
+		return
+	}
+	if len(records) < 2 {
+		return nil, chk.Err("at least one line with names of columns and one line with values are required")
+	}
+
+	// columns
+	o = new(initialTable)
+	nrows := len(records) - 1
+	var idcol int
+	var xcols []int
+	var vcols []int
+	for j, name := range records[0] {
+		name = strings.TrimSpace(name)
+		switch name {
+		case "id":
+			idcol = j
+			o.ids = make([]int, nrows)
+		case "x", "y", "z":
+			if len(xcols) > 2 || name != []string{"x", "y", "z"}[len(xcols)] {
+				return nil, chk.Err("coordinates must be given in the \"x\", \"y\", \"z\" order")
+			}
+			xcols = append(xcols, j)
+		default:
+			der := 0
+			if strings.HasSuffix(name, "_dt2") {
+				name, der = strings.TrimSuffix(name, "_dt2"), 2
+			} else if strings.HasSuffix(name, "_dt") {
+				name, der = strings.TrimSuffix(name, "_dt"), 1
+			}
+			o.keys = append(o.keys, name)
+			o.ders = append(o.ders, der)
+			vcols = append(vcols, j)
+		}
+	}
+
+	// values
+	o.x = make([][]float64, len(xcols))
+	for k := range o.x {
+		o.x[k] = make([]float64, nrows)
+	}
+	o.cols = make([][]float64, len(vcols))
+	for k := range o.cols {
+		o.cols[k] = make([]float64, nrows)
+	}
+	for i, rec := range records[1:] {
+		if len(rec) != len(records[0]) {
+			return nil, chk.Err("line %d has %d columns; but %d are required", i+2, len(rec), len(records[0]))
+		}
+		if o.ids != nil {
+			o.ids[i], err = strconv.Atoi(strings.TrimSpace(rec[idcol]))
+			if err != nil {
+				return nil, chk.Err("cannot parse vertex id in line %d:\n%v", i+2, err)
+			}
+		}
+		for k, j := range xcols {
+			o.x[k][i], err = strconv.ParseFloat(strings.TrimSpace(rec[j]), 64)
+			if err != nil {
+				return nil, chk.Err("cannot parse coordinate in line %d:\n%v", i+2, err)
+			}
+		}
+		for k, j := range vcols {
+			o.cols[k][i], err = strconv.ParseFloat(strings.TrimSpace(rec[j]), 64)
+			if err != nil {
+				return nil, chk.Err("cannot parse value in line %d:\n%v", i+2, err)
+			}
+		}
+	}
+	return
+}
+
+// dist returns the distance between point x and the point at row i
+func (o *initialTable) dist(x []float64, ndim, i int) float64 {
+	var d float64
+	for k := 0; k < ndim; k++ {
+		d += (x[k] - o.x[k][i]) * (x[k] - o.x[k][i])
+	}
+	return math.Sqrt(d)
+}
+
+// nearest returns the row of the point nearest to x
+func (o *initialTable) nearest(x []float64, ndim int) (row int) {
+	dmin := math.MaxFloat64
+	for i := 0; i < len(o.x[0]); i++ {
+		d := o.dist(x, ndim, i)
+		if d < dmin {
+			row, dmin = i, d
+		}
+	}
+	return
+}
+
+// nearest_rows returns the rows of the n points nearest to x (sorted by distance)
+func (o *initialTable) nearest_rows(x []float64, ndim, n int) (rows []int) {
+	nrows := len(o.x[0])
+	rows = make([]int, nrows)
+	dists := make([]float64, nrows)
+	for i := 0; i < nrows; i++ {
+		rows[i] = i
+		dists[i] = o.dist(x, ndim, i)
+	}
+	sort.Stable(&rowsByDist{rows, dists})
+	if n < nrows {
+		rows = rows[:n]
+	}
+	return
+}
+
+// interp computes values at x by inverse distance weighting of the 4 (2D) or 8 (3D) nearest points
+func (o *initialTable) interp(vals, x []float64, ndim int) {
+	npts := 4
+	if ndim == 3 {
+		npts = 8
+	}
+	rows := o.nearest_rows(x, ndim, npts)
+	if o.dist(x, ndim, rows[0]) < 1e-13 {
+		for k, col := range o.cols {
+			vals[k] = col[rows[0]]
+		}
+		return
+	}
+	for k := range vals {
+		vals[k] = 0
+	}
+	var sumw float64
+	for _, i := range rows {
+		d := o.dist(x, ndim, i)
+		w := 1.0 / (d * d)
+		sumw += w
+		for k, col := range o.cols {
+			vals[k] += w * col[i]
+		}
+	}
+	for k := range vals {
+		vals[k] /= sumw
+	}
+}
+
+// rowsByDist sorts rows by distance
+type rowsByDist struct {
+	rows  []int     // row indices
+	dists []float64 // [nrows] distances
+}
+
+func (o *rowsByDist) Len() int           { return len(o.rows) }
+func (o *rowsByDist) Swap(i, j int)      { o.rows[i], o.rows[j] = o.rows[j], o.rows[i] }
+func (o *rowsByDist) Less(i, j int) bool { return o.dists[o.rows[i]] < o.dists[o.rows[j]] }
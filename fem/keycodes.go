@@ -6,6 +6,7 @@ package fem
 
 import (
 	"math"
+	"strings"
 
 	"github.com/cpmech/gosl/io"
 )
@@ -111,3 +112,29 @@ func GetContactFaceFlags(extra string) (Macaulay bool, BetRamp, Kappa float64) {
 	}
 	return
 }
+
+// GetBeamFlags returns the flags of beam elements
+//  Input:
+//   extra -- e.g. "!vxy:0,0,1 !relA:rz !relB:ry,rz"
+//  Output:
+//   vxy  -- orientation vector on the local x-y plane; nil if not given
+//   relA -- keys of local rotations released at the first node; e.g. ["rz"]
+//   relB -- keys of local rotations released at the second node
+func GetBeamFlags(extra string) (vxy []float64, relA, relB []string) {
+
+	// orientation vector
+	if s_vxy, found := io.Keycode(extra, "vxy"); found {
+		for _, v := range strings.Split(s_vxy, ",") {
+			vxy = append(vxy, io.Atof(v))
+		}
+	}
+
+	// end releases
+	if s_rel, found := io.Keycode(extra, "relA"); found {
+		relA = strings.Split(s_rel, ",")
+	}
+	if s_rel, found := io.Keycode(extra, "relB"); found {
+		relB = strings.Split(s_rel, ",")
+	}
+	return
+}
@@ -0,0 +1,648 @@
+// Copyright 2015 Dorival Pedroso and Raul Durand. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fem
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/cpmech/gosl/chk"
+	"github.com/cpmech/gosl/io"
+	"github.com/cpmech/gosl/la"
+
+	"github.com/cpmech/gofem/inp"
+)
+
+// LinSolIter implements pure-Go preconditioned iterative linear solvers for the assembled Kb
+//  Note: (1) methods:
+//             "cg"       -- preconditioned conjugate gradients (symmetric positive-definite systems)
+//             "gmres"    -- restarted GMRES with right preconditioning
+//             "bicgstab" -- BiCGStab with right preconditioning
+//        (2) preconditioners:
+//             "none"   -- identity
+//             "jacobi" -- diagonal of Kb
+//             "ilu0"   -- incomplete LU factorisation without fill-in
+//             "block"  -- block Jacobi with one dense block per node; see SetBlocks
+//        (3) rows without diagonal entries, i.e. the rows of the Lagrange multipliers in
+//             Kb = [[K, Aᵀ], [A, 0]]
+//            are excluded from the preconditioners above. Instead, these rows are preconditioned
+//            with the diagonal approximation of the Schur complement: S ≈ -A.diag(K)⁻¹.Aᵀ.
+//            The couplings between K and A are also disregarded by "ilu0" and "block"
+//        (4) the initial guess is always zero and convergence is reached when |b - A.x| ≤ tol.|b|
+//        (5) this solver cannot run in parallel yet
+type LinSolIter struct {
+
+	// input
+	method  string   // "cg", "gmres" or "bicgstab"
+	precond string   // "none", "jacobi", "ilu0" or "block"
+	tol     float64  // relative tolerance
+	maxit   int      // max number of iterations
+	m       int      // GMRES: number of iterations before restart
+	verbose bool     // show messages
+	timing  bool     // show timing statistics
+	tR      *Triplet // the matrix; see SetTriplet
+	blocks  [][]int  // [nblocks][nvarsInBlock] equations of each block
+
+	// matrix
+	n    int       // dimension
+	rp   []int     // [n+1] pointers to start of rows
+	cj   []int     // [nnz] column indices (sorted in each row)
+	ax   []float64 // [nnz] values
+	dg   []int     // [n] positions of diagonal entries; -1 if absent
+	lam  []bool    // [n] row of Lagrange multiplier
+	nlam int       // number of rows of Lagrange multipliers
+
+	// preconditioner
+	dinv []float64     // [n] inverse of diagonal (Jacobi and regular rows without block) or -1/S (Lagrange rows)
+	lu   []float64     // [nnz] ILU(0) factors: unit-lower L and upper U
+	binv [][][]float64 // [nblocks][nv][nv] inverses of diagonal blocks
+
+	// auxiliary
+	NumIt int         // number of iterations in last solution
+	Res   float64     // relative residual after last solution
+	wrk   [][]float64 // workspace vectors
+}
+
+// is_iterative returns whether name corresponds to an iterative linear solver
+func is_iterative(name string) bool {
+	switch name {
+	case "cg", "gmres", "bicgstab":
+		return true
+	}
+	return false
+}
+
+// get_linsol returns a linear solver
+func get_linsol(dat *inp.LinSolData) la.LinSol {
+	if is_iterative(dat.Name) {
+		return NewLinSolIter(dat)
+	}
+	return la.GetSolver(dat.Name)
+}
+
+// NewLinSolIter returns a new iterative linear solver
+func NewLinSolIter(dat *inp.LinSolData) (o *LinSolIter) {
+	o = new(LinSolIter)
+	o.method = dat.Name
+	o.precond = dat.Precond
+	if o.precond == "" {
+		o.precond = "jacobi"
+	}
+	o.tol = dat.ItTol
+	o.maxit = dat.ItMaxIt
+	o.m = dat.ItRestart
+	if !is_iterative(o.method) {
+		chk.Panic("cannot find iterative linear solver named %q", o.method)
+	}
+	switch o.precond {
+	case "none", "jacobi", "ilu0", "block":
+	default:
+		chk.Panic("cannot find preconditioner named %q", o.precond)
+	}
+	if o.tol <= 0 || o.maxit < 1 || o.m < 1 {
+		chk.Panic("iterative linear solver requires tol > 0, maxit ≥ 1 and restart ≥ 1. tol=%g, maxit=%d and restart=%d are invalid", o.tol, o.maxit, o.m)
+	}
+	return
+}
+
+// SetBlocks sets the equations of each block for the "block" preconditioner; e.g. the equations
+// of each node. Equations not included in any block are preconditioned with the diagonal
+func (o *LinSolIter) SetBlocks(blocks [][]int) {
+	o.blocks = blocks
+}
+
+// SetTriplet sets the matrix to be given to InitR. This is required because the entries of
+// la.Triplet cannot be read; e.g. LinSolIter.SetTriplet(d.Kb) and LinSolIter.InitR(&d.Kb.Triplet)
+//  Note: the triplet must be readable; its la.Triplet is not used and need not be allocated
+func (o *LinSolIter) SetTriplet(t *Triplet) {
+	o.tR = t
+}
+
+// InitR initialises solver for real numbers
+func (o *LinSolIter) InitR(tR *la.Triplet, symmetric, verbose, timing bool) (err error) {
+	if o.tR == nil || &o.tR.Triplet != tR {
+		return chk.Err("iterative linear solver requires the matrix to be given by SetTriplet first")
+	}
+	if !o.tR.Readable() {
+		return chk.Err("iterative linear solver requires a readable triplet")
+	}
+	o.verbose = verbose
+	o.timing = timing
+	m, n := o.tR.Size()
+	if m != n {
+		return chk.Err("iterative linear solver requires a square matrix. %d×%d is invalid", m, n)
+	}
+	o.n = n
+	o.rp = make([]int, n+1)
+	o.dg = make([]int, n)
+	o.lam = make([]bool, n)
+	o.dinv = make([]float64, n)
+	nw := 5
+	if o.method == "bicgstab" {
+		nw = 8
+	}
+	o.wrk = la.MatAlloc(nw, n)
+	return
+}
+
+// InitC initialises solver for complex numbers
+func (o *LinSolIter) InitC(tC *la.TripletC, symmetric, verbose, timing bool) (err error) {
+	return chk.Err("iterative linear solver cannot handle complex numbers yet")
+}
+
+// SetOrdScal sets the ordering and scaling methods; not used by iterative solvers
+func (o *LinSolIter) SetOrdScal(ordering, scaling string) (err error) {
+	return
+}
+
+// Fact converts the matrix to the compressed-row format and computes the preconditioner
+func (o *LinSolIter) Fact() (err error) {
+
+	// start time
+	if o.rp == nil {
+		return chk.Err("iterative linear solver must be initialised first")
+	}
+	var t0 time.Time
+	if o.timing {
+		t0 = time.Now()
+	}
+
+	// matrix
+	err = o.to_csr()
+	if err != nil {
+		return
+	}
+
+	// Lagrange multipliers
+	o.nlam = 0
+	for i := 0; i < o.n; i++ {
+		o.lam[i] = o.dg[i] < 0 || o.ax[o.dg[i]] == 0
+		if o.lam[i] {
+			o.nlam++
+		}
+	}
+	if o.method == "cg" && o.nlam > 0 {
+		return chk.Err("CG method cannot handle the %d rows of Lagrange multipliers; use gmres or bicgstab instead", o.nlam)
+	}
+
+	// diagonal
+	for i := 0; i < o.n; i++ {
+		if o.lam[i] {
+			s := 0.0
+			for k := o.rp[i]; k < o.rp[i+1]; k++ {
+				j := o.cj[k]
+				if !o.lam[j] {
+					s += o.ax[k] * o.ax[k] / o.ax[o.dg[j]]
+				}
+			}
+			if s == 0 {
+				return chk.Err("cannot precondition row %d of Lagrange multiplier", i)
+			}
+			o.dinv[i] = -1.0 / s
+			continue
+		}
+		o.dinv[i] = 1.0 / o.ax[o.dg[i]]
+	}
+
+	// preconditioner
+	switch o.precond {
+	case "ilu0":
+		err = o.ilu0()
+	case "block":
+		err = o.block_inverses()
+	}
+	if err != nil {
+		return
+	}
+
+	// message
+	if o.timing {
+		io.Pfcyan("%s: time spent in Fact = %v\n", o.method, time.Now().Sub(t0))
+	}
+	return
+}
+
+// SolveR solves the linear system for real numbers
+func (o *LinSolIter) SolveR(xR, bR []float64, sum_b_to_root bool) (err error) {
+
+	// check
+	if sum_b_to_root {
+		return chk.Err("iterative linear solver cannot run in parallel yet")
+	}
+	var t0 time.Time
+	if o.timing {
+		t0 = time.Now()
+	}
+
+	// solve
+	la.VecFill(xR, 0)
+	bnorm := la.VecNorm(bR)
+	o.NumIt, o.Res = 0, 0
+	if bnorm > 0 {
+		switch o.method {
+		case "cg":
+			err = o.cg(xR, bR, bnorm)
+		case "gmres":
+			err = o.gmres(xR, bR, bnorm)
+		case "bicgstab":
+			err = o.bicgstab(xR, bR, bnorm)
+		}
+	}
+
+	// message
+	if o.verbose {
+		io.Pf("%s+%s: number of iterations = %d, relative residual = %g\n", o.method, o.precond, o.NumIt, o.Res)
+	}
+	if o.timing {
+		io.Pfcyan("%s: time spent in SolveR = %v\n", o.method, time.Now().Sub(t0))
+	}
+	return
+}
+
+// SolveC solves the linear system for complex numbers
+func (o *LinSolIter) SolveC(xR, xC, bR, bC []float64, sum_b_to_root bool) (err error) {
+	return chk.Err("iterative linear solver cannot handle complex numbers yet")
+}
+
+// Clean deletes temporary data structures
+func (o *LinSolIter) Clean() {
+}
+
+// Krylov methods //////////////////////////////////////////////////////////////////////////////////
+
+// cg implements the preconditioned conjugate gradients method
+func (o *LinSolIter) cg(x, b []float64, bnorm float64) (err error) {
+	r, z, p, q := o.wrk[0], o.wrk[1], o.wrk[2], o.wrk[3]
+	copy(r, b)
+	o.psolve(z, r)
+	copy(p, z)
+	ρ := la.VecDot(r, z)
+	for o.NumIt = 1; o.NumIt <= o.maxit; o.NumIt++ {
+		o.mulvec(q, p)
+		pq := la.VecDot(p, q)
+		if pq == 0 {
+			return chk.Err("CG method failed: breakdown with p.A.p = 0")
+		}
+		α := ρ / pq
+		la.VecAdd(x, α, p)
+		la.VecAdd(r, -α, q)
+		o.Res = la.VecNorm(r) / bnorm
+		if o.Res <= o.tol {
+			return
+		}
+		o.psolve(z, r)
+		ρnew := la.VecDot(r, z)
+		β := ρnew / ρ
+		ρ = ρnew
+		for i := 0; i < o.n; i++ {
+			p[i] = z[i] + β*p[i]
+		}
+	}
+	return chk.Err("CG method did not converge after %d iterations. relative residual = %g", o.maxit, o.Res)
+}
+
+// gmres implements the restarted GMRES method with right preconditioning
+func (o *LinSolIter) gmres(x, b []float64, bnorm float64) (err error) {
+
+	// workspace
+	m := o.m
+	V := la.MatAlloc(m+1, o.n) // Krylov basis
+	Z := la.MatAlloc(m, o.n)   // preconditioned basis
+	H := la.MatAlloc(m+1, m)   // Hessenberg matrix
+	c := make([]float64, m)    // Givens rotations: cosines
+	s := make([]float64, m)    // Givens rotations: sines
+	g := make([]float64, m+1)  // right-hand side of least-squares problem
+	y := make([]float64, m)    // least-squares solution
+	r, w := o.wrk[0], o.wrk[1]
+
+	// initial residual
+	copy(r, b)
+	β := bnorm
+	for o.NumIt < o.maxit {
+
+		// start Arnoldi process
+		la.VecFill(g, 0)
+		g[0] = β
+		for i := 0; i < o.n; i++ {
+			V[0][i] = r[i] / β
+		}
+
+		// Arnoldi iterations
+		k := 0
+		for j := 0; j < m && o.NumIt < o.maxit; j++ {
+			o.NumIt++
+			k = j + 1
+			o.psolve(Z[j], V[j])
+			o.mulvec(w, Z[j])
+			for i := 0; i <= j; i++ {
+				H[i][j] = la.VecDot(w, V[i])
+				la.VecAdd(w, -H[i][j], V[i])
+			}
+			H[j+1][j] = la.VecNorm(w)
+			if H[j+1][j] > 0 {
+				for i := 0; i < o.n; i++ {
+					V[j+1][i] = w[i] / H[j+1][j]
+				}
+			}
+
+			// apply previous rotations and compute new one
+			for i := 0; i < j; i++ {
+				h := c[i]*H[i][j] + s[i]*H[i+1][j]
+				H[i+1][j] = -s[i]*H[i][j] + c[i]*H[i+1][j]
+				H[i][j] = h
+			}
+			den := math.Sqrt(H[j][j]*H[j][j] + H[j+1][j]*H[j+1][j])
+			if den == 0 {
+				return chk.Err("GMRES method failed: breakdown with zero Hessenberg column")
+			}
+			c[j], s[j] = H[j][j]/den, H[j+1][j]/den
+			H[j][j], H[j+1][j] = den, 0
+			g[j+1] = -s[j] * g[j]
+			g[j] = c[j] * g[j]
+			o.Res = math.Abs(g[j+1]) / bnorm
+			if o.Res <= o.tol {
+				break
+			}
+		}
+
+		// update solution: x += Z.y with H.y = g
+		for i := k - 1; i >= 0; i-- {
+			y[i] = g[i]
+			for l := i + 1; l < k; l++ {
+				y[i] -= H[i][l] * y[l]
+			}
+			y[i] /= H[i][i]
+		}
+		for i := 0; i < k; i++ {
+			la.VecAdd(x, y[i], Z[i])
+		}
+
+		// true residual
+		o.mulvec(w, x)
+		for i := 0; i < o.n; i++ {
+			r[i] = b[i] - w[i]
+		}
+		β = la.VecNorm(r)
+		o.Res = β / bnorm
+		if o.Res <= o.tol {
+			return
+		}
+	}
+	return chk.Err("GMRES method did not converge after %d iterations. relative residual = %g", o.maxit, o.Res)
+}
+
+// bicgstab implements the BiCGStab method with right preconditioning
+func (o *LinSolIter) bicgstab(x, b []float64, bnorm float64) (err error) {
+	r, r0, p, v := o.wrk[0], o.wrk[1], o.wrk[2], o.wrk[3]
+	ph, sh, s, t := o.wrk[4], o.wrk[5], o.wrk[6], o.wrk[7]
+	copy(r, b)
+	copy(r0, b)
+	la.VecFill(p, 0)
+	la.VecFill(v, 0)
+	ρ, α, ω := 1.0, 1.0, 1.0
+	for o.NumIt = 1; o.NumIt <= o.maxit; o.NumIt++ {
+		ρnew := la.VecDot(r0, r)
+		if ρnew == 0 {
+			return chk.Err("BiCGStab method failed: breakdown with r0.r = 0")
+		}
+		β := (ρnew / ρ) * (α / ω)
+		ρ = ρnew
+		for i := 0; i < o.n; i++ {
+			p[i] = r[i] + β*(p[i]-ω*v[i])
+		}
+		o.psolve(ph, p)
+		o.mulvec(v, ph)
+		r0v := la.VecDot(r0, v)
+		if r0v == 0 {
+			return chk.Err("BiCGStab method failed: breakdown with r0.v = 0")
+		}
+		α = ρ / r0v
+		for i := 0; i < o.n; i++ {
+			s[i] = r[i] - α*v[i]
+		}
+		o.Res = la.VecNorm(s) / bnorm
+		if o.Res <= o.tol {
+			la.VecAdd(x, α, ph)
+			return
+		}
+		o.psolve(sh, s)
+		o.mulvec(t, sh)
+		tt := la.VecDot(t, t)
+		if tt == 0 {
+			return chk.Err("BiCGStab method failed: breakdown with t.t = 0")
+		}
+		ω = la.VecDot(t, s) / tt
+		for i := 0; i < o.n; i++ {
+			x[i] += α*ph[i] + ω*sh[i]
+			r[i] = s[i] - ω*t[i]
+		}
+		o.Res = la.VecNorm(r) / bnorm
+		if o.Res <= o.tol {
+			return
+		}
+		if ω == 0 {
+			return chk.Err("BiCGStab method failed: breakdown with ω = 0")
+		}
+	}
+	return chk.Err("BiCGStab method did not converge after %d iterations. relative residual = %g", o.maxit, o.Res)
+}
+
+// matrix and preconditioners //////////////////////////////////////////////////////////////////////
+
+// mulvec computes y = A.x
+func (o *LinSolIter) mulvec(y, x []float64) {
+	for i := 0; i < o.n; i++ {
+		y[i] = 0
+		for k := o.rp[i]; k < o.rp[i+1]; k++ {
+			y[i] += o.ax[k] * x[o.cj[k]]
+		}
+	}
+}
+
+// psolve computes z = M⁻¹.r where M is the preconditioner
+func (o *LinSolIter) psolve(z, r []float64) {
+
+	// no preconditioner
+	if o.precond == "none" {
+		copy(z, r)
+		return
+	}
+
+	// diagonal: Jacobi and Lagrange multipliers
+	for i := 0; i < o.n; i++ {
+		z[i] = o.dinv[i] * r[i]
+	}
+
+	// ILU(0): z = U⁻¹.L⁻¹.r for regular rows
+	if o.precond == "ilu0" {
+		for i := 0; i < o.n; i++ {
+			if o.lam[i] {
+				continue
+			}
+			z[i] = r[i]
+			for k := o.rp[i]; k < o.dg[i]; k++ {
+				if !o.lam[o.cj[k]] {
+					z[i] -= o.lu[k] * z[o.cj[k]]
+				}
+			}
+		}
+		for i := o.n - 1; i >= 0; i-- {
+			if o.lam[i] {
+				continue
+			}
+			for k := o.dg[i] + 1; k < o.rp[i+1]; k++ {
+				if !o.lam[o.cj[k]] {
+					z[i] -= o.lu[k] * z[o.cj[k]]
+				}
+			}
+			z[i] /= o.lu[o.dg[i]]
+		}
+		return
+	}
+
+	// block Jacobi
+	if o.precond == "block" {
+		for b, eqs := range o.blocks {
+			for i, I := range eqs {
+				z[I] = 0
+				for j, J := range eqs {
+					z[I] += o.binv[b][i][j] * r[J]
+				}
+			}
+		}
+	}
+}
+
+// ilu0 computes the incomplete LU factorisation of the regular rows without fill-in
+func (o *LinSolIter) ilu0() (err error) {
+	o.lu = make([]float64, len(o.ax))
+	copy(o.lu, o.ax)
+	pos := make([]int, o.n) // position of column in current row
+	for i := 0; i < o.n; i++ {
+		pos[i] = -1
+	}
+	for i := 0; i < o.n; i++ {
+		if o.lam[i] {
+			continue
+		}
+		for k := o.rp[i]; k < o.rp[i+1]; k++ {
+			pos[o.cj[k]] = k
+		}
+		for k := o.rp[i]; k < o.dg[i]; k++ {
+			c := o.cj[k]
+			if o.lam[c] {
+				continue
+			}
+			o.lu[k] /= o.lu[o.dg[c]]
+			for l := o.dg[c] + 1; l < o.rp[c+1]; l++ {
+				if p := pos[o.cj[l]]; p >= 0 && !o.lam[o.cj[l]] {
+					o.lu[p] -= o.lu[k] * o.lu[l]
+				}
+			}
+		}
+		for k := o.rp[i]; k < o.rp[i+1]; k++ {
+			pos[o.cj[k]] = -1
+		}
+		if o.lu[o.dg[i]] == 0 {
+			return chk.Err("ILU(0) preconditioner failed: zero pivot at row %d", i)
+		}
+	}
+	return
+}
+
+// block_inverses computes the inverses of the diagonal blocks
+func (o *LinSolIter) block_inverses() (err error) {
+	if len(o.blocks) == 0 {
+		return chk.Err("block preconditioner requires the blocks of equations; see SetBlocks")
+	}
+	o.binv = make([][][]float64, len(o.blocks))
+	for b, eqs := range o.blocks {
+		a := la.MatAlloc(len(eqs), len(eqs))
+		for i, I := range eqs {
+			if o.lam[I] {
+				return chk.Err("block %d cannot contain the row %d of Lagrange multiplier", b, I)
+			}
+			for j, J := range eqs {
+				k := sort.SearchInts(o.cj[o.rp[I]:o.rp[I+1]], J) + o.rp[I]
+				if k < o.rp[I+1] && o.cj[k] == J {
+					a[i][j] = o.ax[k]
+				}
+			}
+		}
+		o.binv[b] = la.MatAlloc(len(eqs), len(eqs))
+		_, err = la.MatInv(o.binv[b], a, 1e-14)
+		if err != nil {
+			return chk.Err("block preconditioner failed: cannot invert block %d:\n%v", b, err)
+		}
+	}
+	return
+}
+
+// to_csr converts the triplet to the compressed-row format; duplicates are summed up
+func (o *LinSolIter) to_csr() (err error) {
+
+	// entries
+	I, J, X := o.tR.I, o.tR.J, o.tR.X
+
+	// count entries per row
+	for i := 0; i <= o.n; i++ {
+		o.rp[i] = 0
+	}
+	for _, i := range I {
+		o.rp[i+1]++
+	}
+	for i := 0; i < o.n; i++ {
+		o.rp[i+1] += o.rp[i]
+	}
+
+	// fill rows
+	nnz := len(I)
+	cj := make([]int, nnz)
+	ax := make([]float64, nnz)
+	next := make([]int, o.n)
+	copy(next, o.rp[:o.n])
+	for k, i := range I {
+		cj[next[i]] = J[k]
+		ax[next[i]] = X[k]
+		next[i]++
+	}
+
+	// sort columns in each row and sum duplicates
+	o.cj = make([]int, 0, nnz)
+	o.ax = make([]float64, 0, nnz)
+	for i := 0; i < o.n; i++ {
+		row := csrRow{cj[o.rp[i]:o.rp[i+1]], ax[o.rp[i]:o.rp[i+1]]}
+		sort.Sort(row)
+		o.rp[i] = len(o.cj)
+		o.dg[i] = -1
+		for k := 0; k < len(row.j); k++ {
+			if k > 0 && row.j[k] == row.j[k-1] {
+				o.ax[len(o.ax)-1] += row.x[k]
+				continue
+			}
+			if row.j[k] == i {
+				o.dg[i] = len(o.cj)
+			}
+			o.cj = append(o.cj, row.j[k])
+			o.ax = append(o.ax, row.x[k])
+		}
+	}
+	o.rp[o.n] = len(o.cj)
+	return
+}
+
+// csrRow implements sort.Interface to sort the entries of one row by column
+type csrRow struct {
+	j []int
+	x []float64
+}
+
+func (o csrRow) Len() int           { return len(o.j) }
+func (o csrRow) Less(a, b int) bool { return o.j[a] < o.j[b] }
+func (o csrRow) Swap(a, b int) {
+	o.j[a], o.j[b] = o.j[b], o.j[a]
+	o.x[a], o.x[b] = o.x[b], o.x[a]
+}
@@ -18,7 +18,8 @@ import (
 
 // GetAndInitPorousModel get porous model from material name
 // It returns nil on errors, after logging
-func GetAndInitPorousModel(mdb *inp.MatDb, matname, simfnk string) (mdl *mporous.Model, err error) {
+//  Note: getnew => allocate new models instead of sharing them; e.g. with concurrent element computations
+func GetAndInitPorousModel(mdb *inp.MatDb, matname, simfnk string, getnew bool) (mdl *mporous.Model, err error) {
 
 	// materials
 	cndmat, lrmmat, pormat, err := mdb.GroupGet3(matname, "c", "l", "p")
@@ -28,7 +29,6 @@ func GetAndInitPorousModel(mdb *inp.MatDb, matname, simfnk string) (mdl *mporous
 	}
 
 	// conductivity models
-	getnew := false
 	cnd := mconduct.GetModel(simfnk, cndmat.Name, cndmat.Model, getnew)
 	if cnd == nil {
 		err = chk.Err("cannot allocate conductivity models with name=%q", cndmat.Model)
@@ -69,7 +69,9 @@ func GetAndInitPorousModel(mdb *inp.MatDb, matname, simfnk string) (mdl *mporous
 	return
 }
 
-func GetAndInitSolidModel(mdb *inp.MatDb, matname, simfnk string, ndim int, pstress bool) (mdl msolid.Model, prms fun.Prms, err error) {
+// GetAndInitSolidModel gets solid model from material name
+//  Note: getnew => allocate a new model instead of sharing it; e.g. with concurrent element computations
+func GetAndInitSolidModel(mdb *inp.MatDb, matname, simfnk string, ndim int, pstress, getnew bool) (mdl msolid.Model, prms fun.Prms, err error) {
 
 	// material name
 	matdata := mdb.Get(matname)
@@ -96,7 +98,7 @@ func GetAndInitSolidModel(mdb *inp.MatDb, matname, simfnk string, ndim int, pstr
 	}
 
 	// initialise model
-	mdl, existent := msolid.GetModel(simfnk, matname, mdlname, false)
+	mdl, existent := msolid.GetModel(simfnk, matname, mdlname, getnew)
 	if mdl == nil {
 		err = chk.Err("cannot find solid model named %q", mdlname)
 		return
@@ -18,13 +18,13 @@ import (
 //   tolM      -- tolerance to clip absolute values of M
 //   coef      -- coefficient to scale max(dimension) divided by max(Y); e.g. 0.1
 //  Output:
-//   beams -- all beam elements
+//   beams -- all beam elements; 3D beams are skipped
 //   allM  -- bending moments corresponding to all beams
 func PlotAllBendingMoments(dom *Domain, nstations int, withtext bool, numfmt string, tolM, coef float64) (beams []*Beam, allM [][]float64) {
 
 	// collect beams
 	for _, elem := range dom.Elems {
-		if beam, ok := elem.(*Beam); ok {
+		if beam, ok := elem.(*Beam); ok && beam.Ndim == 2 {
 			beams = append(beams, beam)
 		}
 	}
@@ -35,6 +35,52 @@ func PlotAllBendingMoments(dom *Domain, nstations int, withtext bool, numfmt str
 		_, allM[i] = beam.CalcVandM(dom.Sol, 0, nstations)
 	}
 
+	// draw
+	plot_all_moments(dom, coef, allM, func(i int, sf float64) {
+		beams[i].PlotDiagMoment(allM[i], withtext, numfmt, tolM, sf)
+	})
+	return
+}
+
+// PlotAllBeamMoments plots the bending moments of all beam elements; e.g. Beam and TimoBeam
+//  Input:
+//   see PlotAllBendingMoments
+//  Output:
+//   beams -- all beam elements; 3D beams are skipped
+//   allM  -- bending moments corresponding to all beams
+func PlotAllBeamMoments(dom *Domain, nstations int, withtext bool, numfmt string, tolM, coef float64) (beams []ElemBeam, allM [][]float64) {
+
+	// collect beams
+	for _, elem := range dom.Elems {
+		if beam, ok := elem.(*Beam); ok && beam.Ndim == 3 {
+			continue
+		}
+		if beam, ok := elem.(ElemBeam); ok {
+			beams = append(beams, beam)
+		}
+	}
+
+	// compute bending moments
+	allM = make([][]float64, len(beams))
+	for i, beam := range beams {
+		_, allM[i] = beam.CalcVandM(dom.Sol, 0, nstations)
+	}
+
+	// draw
+	plot_all_moments(dom, coef, allM, func(i int, sf float64) {
+		beams[i].PlotDiagMoment(allM[i], withtext, numfmt, tolM, sf)
+	})
+	return
+}
+
+// plot_all_moments draws the mesh and calls plot(i, sf) for each beam i with scaling factor sf
+func plot_all_moments(dom *Domain, coef float64, allM [][]float64, plot func(i int, sf float64)) {
+
+	// skip if there are no beams
+	if len(allM) == 0 {
+		return
+	}
+
 	// scaling factor
 	maxAbsM := la.MatLargest(allM, 1)
 	dist := utl.Max(dom.Msh.Xmax-dom.Msh.Xmin, dom.Msh.Ymax-dom.Msh.Ymin)
@@ -45,8 +91,7 @@ func PlotAllBendingMoments(dom *Domain, nstations int, withtext bool, numfmt str
 
 	// draw
 	dom.Msh.Draw2d()
-	for i, beam := range beams {
-		beam.PlotDiagMoment(allM[i], withtext, numfmt, tolM, sf)
+	for i := range allM {
+		plot(i, sf)
 	}
-	return
 }
@@ -0,0 +1,151 @@
+// Copyright 2015 Dorival Pedroso and Raul Durand. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fem
+
+import (
+	"math"
+	"sort"
+
+	"github.com/cpmech/gofem/inp"
+	"github.com/cpmech/gosl/chk"
+	"github.com/cpmech/gosl/fun"
+)
+
+// set_periodic sets periodic constraints between pairs of opposite faces
+//  Note: (1) each vertex on a "plus" face is tied to its "root" vertex; i.e. the vertex found by
+//            following the pairs of faces until a vertex that is not on any "plus" face is reached.
+//            thus, vertices on edges and corners are constrained only once and redundant
+//            constraints are avoided
+//        (2) the constraints are multi-point constraints ("mpc") and must be set after
+//            single-point constraints; see EssentialBcs.SetMpc
+func (o *Domain) set_periodic(dat *inp.PeriodicData) (err error) {
+
+	// keys
+	ndim := o.Msh.Ndim
+	ukeys := []string{"ux", "uy", "uz"}
+	keys := dat.Keys
+	if len(keys) == 0 {
+		keys = ukeys[:ndim]
+	}
+
+	// macroscopic strain
+	var ε [3][3]float64
+	switch len(dat.Eps) {
+	case 0:
+	case 4, 6:
+		ε[0][0], ε[1][1], ε[2][2] = dat.Eps[0], dat.Eps[1], dat.Eps[2]
+		ε[0][1], ε[1][0] = dat.Eps[3], dat.Eps[3]
+		if len(dat.Eps) == 6 {
+			ε[1][2], ε[2][1] = dat.Eps[4], dat.Eps[4]
+			ε[2][0], ε[0][2] = dat.Eps[5], dat.Eps[5]
+		}
+	default:
+		return chk.Err("macroscopic strain must have 4 or 6 components. %d is invalid", len(dat.Eps))
+	}
+
+	// function
+	var fcn fun.Func = &fun.Cte{C: 1}
+	if dat.Func != "" {
+		fcn = o.Sim.Functions.Get(dat.Func)
+		if fcn == nil {
+			return chk.Err("cannot find function named %q\n", dat.Func)
+		}
+	}
+
+	// tolerance
+	tol := dat.Tol
+	if tol <= 0 {
+		tol = 1e-8
+	}
+
+	// match vertices on opposite faces
+	master := make(map[int]int) // maps vertex on "plus" face to vertex on "minus" face
+	for k, pair := range dat.Pairs {
+		if len(pair) != 2 {
+			return chk.Err("periodic pair %d must have two face tags", k)
+		}
+		plus, okp := o.Msh.FaceTag2verts[pair[0]]
+		minus, okm := o.Msh.FaceTag2verts[pair[1]]
+		if !okp || !okm {
+			return chk.Err("periodic pair %d: cannot find faces with tags = %v", k, pair)
+		}
+		if len(plus) != len(minus) {
+			return chk.Err("periodic pair %d: number of vertices on opposite faces must be equal. %d != %d", k, len(plus), len(minus))
+		}
+		shift := make([]float64, ndim)
+		for i := 0; i < ndim; i++ {
+			for j := 0; j < len(plus); j++ {
+				shift[i] += o.Msh.Verts[plus[j]].C[i] - o.Msh.Verts[minus[j]].C[i]
+			}
+			shift[i] /= float64(len(plus))
+		}
+		for _, p := range plus {
+			xp := o.Msh.Verts[p].C
+			found := false
+			for _, m := range minus {
+				xm := o.Msh.Verts[m].C
+				var d float64
+				for i := 0; i < ndim; i++ {
+					d += math.Pow(xp[i]-shift[i]-xm[i], 2)
+				}
+				if math.Sqrt(d) < tol {
+					if _, ok := master[p]; !ok {
+						master[p] = m
+					}
+					found = true
+					break
+				}
+			}
+			if !found {
+				return chk.Err("periodic pair %d: cannot find vertex on face %d matching vertex %d", k, pair[1], p)
+			}
+		}
+	}
+
+	// set constraints; sorted by vertex id to obtain the same order of constraints in all runs
+	slaves := make([]int, 0, len(master))
+	for p := range master {
+		slaves = append(slaves, p)
+	}
+	sort.Ints(slaves)
+	for _, p := range slaves {
+
+		// root vertex
+		r := master[p]
+		for i := 0; ; i++ {
+			m, ok := master[r]
+			if !ok {
+				break
+			}
+			if i > len(master) {
+				return chk.Err("cannot find root vertex of periodic vertex %d", p)
+			}
+			r = m
+		}
+
+		// constraints: y(p) - y(r) = f(t) ⋅ ε̄ ⋅ (x(p) - x(r))
+		xp, xr := o.Msh.Verts[p].C, o.Msh.Verts[r].C
+		nodes := []*Node{o.Vid2node[p], o.Vid2node[r]}
+		for _, key := range keys {
+			var δ float64
+			for i := 0; i < ndim; i++ {
+				if key == ukeys[i] {
+					for j := 0; j < ndim; j++ {
+						δ += ε[i][j] * (xp[j] - xr[j])
+					}
+				}
+			}
+			var rhs fun.Func = &fun.Zero
+			if δ != 0 {
+				rhs = &fun.Add{A: δ, Fa: fcn, B: 0, Fb: &fun.Zero}
+			}
+			err = o.EssenBcs.SetMpc(nodes, []string{key, key}, []float64{1, -1}, rhs)
+			if err != nil {
+				return chk.Err("cannot set periodic constraint between vertices %d and %d:\n%v", p, r, err)
+			}
+		}
+	}
+	return
+}
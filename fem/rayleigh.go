@@ -0,0 +1,106 @@
+// Copyright 2015 Dorival Pedroso and Raul Durand. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fem
+
+import (
+	"math"
+
+	"github.com/cpmech/gosl/chk"
+	"github.com/cpmech/gosl/fun"
+	"github.com/cpmech/gosl/io"
+)
+
+// Rayleigh holds the coefficients of the Rayleigh damping matrix C = α.M + β.K
+//  Note: (1) the coefficients can be given directly (RayA and RayB) or computed from two target
+//            frequencies f1 and f2 [Hz] and a damping ratio ξ (RayXi, RayF1 and RayF2):
+//             α = 2.ξ.ω1.ω2 / (ω1 + ω2)  and  β = 2.ξ / (ω1 + ω2)  with  ω = 2.π.f
+//        (2) the coefficients are read from the material parameters first; then the values given
+//            in ElemData.Extra, e.g. "!rayA:0.1 !rayB:0.001" or "!rayXi:0.05 !rayF1:1 !rayF2:10",
+//            override the material data
+//        (3) K is the initial stiffness matrix; i.e. computed with the states at the first time C
+//            is required. Thus C is constant throughout the simulation
+type Rayleigh struct {
+	A float64 // α: mass-proportional coefficient
+	B float64 // β: stiffness-proportional coefficient
+}
+
+// On returns true if damping is active
+func (o Rayleigh) On() bool {
+	return o.A > 0 || o.B > 0
+}
+
+// GetRayleigh returns the Rayleigh damping coefficients from material parameters and element data
+func GetRayleigh(prms fun.Prms, extra string) (o Rayleigh, err error) {
+
+	// material parameters
+	var ξ, f1, f2 float64
+	for _, p := range prms {
+		switch p.N {
+		case "RayA":
+			o.A = p.V
+		case "RayB":
+			o.B = p.V
+		case "RayXi":
+			ξ = p.V
+		case "RayF1":
+			f1 = p.V
+		case "RayF2":
+			f2 = p.V
+		}
+	}
+
+	// element data
+	if val, found := io.Keycode(extra, "rayA"); found {
+		o.A = io.Atof(val)
+	}
+	if val, found := io.Keycode(extra, "rayB"); found {
+		o.B = io.Atof(val)
+	}
+	if val, found := io.Keycode(extra, "rayXi"); found {
+		ξ = io.Atof(val)
+	}
+	if val, found := io.Keycode(extra, "rayF1"); found {
+		f1 = io.Atof(val)
+	}
+	if val, found := io.Keycode(extra, "rayF2"); found {
+		f2 = io.Atof(val)
+	}
+
+	// coefficients from damping ratio and target frequencies
+	if ξ > 0 {
+		if f1 <= 0 || f2 <= 0 {
+			err = chk.Err("Rayleigh damping with ξ=%g requires positive target frequencies. f1=%g and f2=%g are invalid", ξ, f1, f2)
+			return
+		}
+		ω1, ω2 := 2.0*math.Pi*f1, 2.0*math.Pi*f2
+		o.A = 2.0 * ξ * ω1 * ω2 / (ω1 + ω2)
+		o.B = 2.0 * ξ / (ω1 + ω2)
+	}
+
+	// check
+	if o.A < 0 || o.B < 0 {
+		err = chk.Err("Rayleigh damping coefficients must be non-negative. α=%g and β=%g are invalid", o.A, o.B)
+	}
+	return
+}
+
+// Matrix computes C = α.M + β.K
+func (o Rayleigh) Matrix(C, M, K [][]float64) {
+	for i := 0; i < len(C); i++ {
+		for j := 0; j < len(C[i]); j++ {
+			C[i][j] = o.A*M[i][j] + o.B*K[i][j]
+		}
+	}
+}
+
+// rayleigh_add_to_rhs adds -C.v to fb, where v = α4.u - χ* are the nodal velocities
+func rayleigh_add_to_rhs(fb []float64, C [][]float64, umap []int, sol *Solution) {
+	α4 := sol.DynCfs.α4
+	for i, I := range umap {
+		for j, J := range umap {
+			fb[I] -= C[i][j] * (α4*sol.Y[J] - sol.Chi[J])
+		}
+	}
+}
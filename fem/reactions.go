@@ -0,0 +1,73 @@
+// Copyright 2015 Dorival Pedroso and Raul Durand. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fem
+
+import "github.com/cpmech/gosl/la"
+
+// CalcReactions computes the reactions at equations with essential bcs / constraints and stores
+// them in Sol.React
+//  Note: (1) reactions are computed from the residuals of internal and external forces, including
+//            inertia terms in dynamics: R = fint - fext. Thus, they are available for all methods
+//            of enforcing constraints; i.e. Lagrange multipliers (R = -Atλ), elimination or penalty
+//        (2) reactions are zero at equations without constraints
+//        (3) equations with multi-point constraints have reactions as well; e.g. forces transmitted
+//            by ties between regions
+//        (4) with the generalized-α method, the reactions correspond to the equilibrium at t(n+1-αf)
+//            solved for: R(n+1-α) = (1-αf).[R'(n+1) + En] (see genalpha_prev_terms); i.e. the
+//            residuals include the cm factor of inertia terms and the terms of the previous state
+//        (5) the residuals of the last iteration are used if the solver has converged on fb;
+//            otherwise, they are assembled again at the current state
+func (o *Domain) CalcReactions() (err error) {
+
+	// allocate
+	if len(o.Sol.React) != o.Ny {
+		o.Sol.React = make([]float64, o.Ny)
+	}
+
+	// negative of residuals without constraints terms
+	fr := o.fr
+	if !o.frok {
+		err = assemble_fr(fr, o, o.Sol.T)
+		if err != nil {
+			return
+		}
+	}
+
+	// generalized-α method: equilibrium divided by (1-αf)
+	coef := 1.0
+	if o.fbn != nil {
+		coef = 1.0 - o.DynCfs.αf
+	}
+
+	// reactions at constrained equations
+	la.VecFill(o.Sol.React, 0)
+	for _, bcs := range [][]*EssentialBc{o.EssenBcs.Bcs, o.EssenBcs.Spcs} {
+		for _, bc := range bcs {
+			for _, eq := range bc.Eqs {
+				o.Sol.React[eq] = -coef * fr[eq]
+			}
+		}
+	}
+	return
+}
+
+// SumReactions sums the reactions corresponding to a dof key over a set of vertices
+//  Input:
+//   react -- [ny] reactions; e.g. Sol.React
+//   key   -- dof key; e.g. "ux", "uy" or "pl"
+//   vids  -- ids of vertices; inactive vertices or vertices without key are skipped
+func (o *Domain) SumReactions(react []float64, key string, vids []int) (sum float64) {
+	for _, vid := range vids {
+		nod := o.Vid2node[vid]
+		if nod == nil {
+			continue
+		}
+		eq := nod.GetEq(key)
+		if eq >= 0 && eq < len(react) {
+			sum += react[eq]
+		}
+	}
+	return
+}
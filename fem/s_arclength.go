@@ -0,0 +1,430 @@
+// Copyright 2015 Dorival Pedroso and Raul Durand. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fem
+
+import (
+	"math"
+
+	"github.com/cpmech/gosl/chk"
+	"github.com/cpmech/gosl/fun"
+	"github.com/cpmech/gosl/io"
+	"github.com/cpmech/gosl/la"
+	"github.com/cpmech/gosl/utl"
+)
+
+// SolverArcLength solves (steady) FEM problems using the arc-length method (Riks/Crisfield)
+//  Note: (1) the load factor λ corresponds to the (pseudo) time; i.e. all loads and prescribed
+//            values are computed by the time functions with t = λ
+//        (2) the reference load vector q = dfb/dλ is computed numerically; thus, loads can
+//            be given by any function of t, including the constraints of essential bcs
+//        (3) the constraint is: ΔuᵀΔu + ψ².Δλ².qᵀq = Δl² (spherical) or ΔuᵀΔu = Δl² (cylindrical)
+//        (4) the analysis stops (without error) when λ ≥ tf, when the control DOF reaches ALctrlMax
+//            or after ALnsmax steps; e.g. after passing a limit point, λ may never reach tf
+type SolverArcLength struct {
+	dom *Domain
+	sum *Summary
+	dc  *DynCoefs
+
+	// auxiliary
+	q   []float64 // [nyb] reference load vector q = dfb/dλ
+	fb2 []float64 // [nyb] perturbed fb
+	wq  []float64 // [nyb] tangent solution: Kb⁻¹.q
+	wr  []float64 // [nyb] residual solution: Kb⁻¹.fb
+	ΔUp []float64 // [ny] converged increments of previous step
+	aux []float64 // [ny] auxiliary vector
+}
+
+// set factory
+func init() {
+	solverallocators["arc"] = func(doms []*Domain, sum *Summary, dc *DynCoefs) FEsolver {
+		if len(doms) != 1 {
+			chk.Panic("SolverArcLength works with one domain only")
+		}
+		solver := new(SolverArcLength)
+		solver.dom = doms[0]
+		solver.sum = sum
+		solver.dc = dc
+		return solver
+	}
+}
+
+// Run runs the arc-length method
+//  Input:
+//   tf      -- max load factor
+//   dtFunc  -- gives the first increment of load factor (if ALdl == 0)
+//   dtoFunc -- increment of load factor (absolute and accumulated) for output
+func (o *SolverArcLength) Run(tf float64, dtFunc, dtoFunc fun.Func, verbose bool, dbgKb DebugKb_t) (err error) {
+
+	// check
+	d := o.dom
+	dat := d.Sim.Solver
+	if !d.Sim.Data.Steady {
+		return chk.Err("arc-length solver works with steady simulations only")
+	}
+
+	// control DOF
+	ctrlEq := -1
+	if dat.ALctrlKey != "" {
+		if dat.ALctrlVid < 0 || dat.ALctrlVid >= len(d.Vid2node) || d.Vid2node[dat.ALctrlVid] == nil {
+			return chk.Err("cannot find control vertex with id=%d", dat.ALctrlVid)
+		}
+		ctrlEq = d.Vid2node[dat.ALctrlVid].GetEq(dat.ALctrlKey)
+		if ctrlEq < 0 {
+			return chk.Err("cannot find control DOF %q at vertex %d", dat.ALctrlKey, dat.ALctrlVid)
+		}
+	}
+
+	// allocate auxiliary vectors
+	o.q = make([]float64, d.Nyb)
+	o.fb2 = make([]float64, d.Nyb)
+	o.wq = make([]float64, d.Nyb)
+	o.wr = make([]float64, d.Nyb)
+	o.ΔUp = make([]float64, d.Ny)
+	o.aux = make([]float64, d.Ny)
+
+	// control
+	λ := d.Sol.T
+	Δl := dat.ALdl
+	Δl0 := dat.ALdl
+	Δλp := 0.0 // converged increment of load factor of previous step
+	sλ := 0.0  // accumulated |Δλ| for output
+	dλout := dtoFunc.F(λ, nil)
+
+	// first output
+	o.record(λ, ctrlEq)
+	if o.sum != nil {
+		err = o.sum.SaveDomains(λ, []*Domain{d}, false)
+		if err != nil {
+			return chk.Err("cannot save results:\n%v", err)
+		}
+	}
+
+	// message
+	if verbose {
+		io.Pf("\n%8s%23s%23s%5s\n", "step", "λ", "Δl", "nit")
+	}
+
+	// steps
+	var step, nit int
+	var converged bool
+	var Δλ float64
+	for step = 0; step < dat.ALnsmax; step++ {
+
+		// check load factor and control DOF
+		if λ >= tf {
+			break
+		}
+		if ctrlEq >= 0 && dat.ALctrlMax > 0 {
+			if math.Abs(d.Sol.Y[ctrlEq]) >= dat.ALctrlMax {
+				break
+			}
+		}
+
+		// backup solution
+		d.backup()
+
+		// run iterations
+		Δλ, nit, converged, err = o.iterations(λ, Δλp, &Δl, dtFunc.F(λ, nil), dbgKb)
+		if err != nil {
+			return chk.Err("arc-length iterations failed:\n%v", err)
+		}
+		if Δl0 == 0 {
+			Δl0 = Δl
+		}
+
+		// restore solution and reduce arc-length if not converged
+		if !converged {
+			if verbose {
+				io.Pfred(". . . arc-length iterations failed: reducing arc-length . . .\n")
+			}
+			d.restore()
+			Δl *= 0.5
+			if Δl < dat.ALmmin*Δl0 {
+				return chk.Err("arc-length is too small: %g < %g", Δl, dat.ALmmin*Δl0)
+			}
+			continue
+		}
+
+		// update load factor
+		λ += Δλ
+		d.Sol.T = λ
+		Δλp = Δλ
+		copy(o.ΔUp, d.Sol.ΔY)
+		o.record(λ, ctrlEq)
+
+		// message
+		if verbose {
+			io.Pf("%8d%23.15e%23.15e%5d\n", step, λ, Δl, nit)
+		}
+
+		// perform output
+		sλ += math.Abs(Δλ)
+		if sλ >= dλout {
+			if o.sum != nil {
+				err = o.sum.SaveDomains(λ, []*Domain{d}, false)
+				if err != nil {
+					return chk.Err("cannot save results:\n%v", err)
+				}
+			}
+			sλ = 0
+			dλout = dtoFunc.F(λ, nil)
+		}
+
+		// adapt arc-length
+		Δl *= math.Sqrt(float64(dat.ALnopt) / float64(utl.Imax(nit, 1)))
+		Δl = utl.Min(utl.Max(Δl, dat.ALmmin*Δl0), dat.ALmmax*Δl0)
+	}
+
+	// last output
+	if sλ > 0 && o.sum != nil {
+		err = o.sum.SaveDomains(λ, []*Domain{d}, false)
+		if err != nil {
+			return chk.Err("cannot save results:\n%v", err)
+		}
+	}
+	if step == dat.ALnsmax && verbose {
+		io.Pfyel(". . . max number of arc-length steps reached: %d . . .\n", step)
+	}
+	return
+}
+
+// iterations performs the predictor-corrector iterations of one arc-length step
+//  Input:
+//   λ     -- load factor at beginning of step
+//   Δλp   -- converged increment of load factor of previous step
+//   Δl    -- arc-length; it's computed if zero
+//   Δλini -- first increment of load factor to compute Δl if Δl == 0
+//  Output:
+//   Δλ        -- increment of load factor
+//   nit       -- number of iterations
+//   converged -- iterations converged
+func (o *SolverArcLength) iterations(λ, Δλp float64, Δl *float64, Δλini float64, dbgKb DebugKb_t) (Δλ float64, nit int, converged bool, err error) {
+
+	// zero accumulated increments
+	d := o.dom
+	dat := d.Sim.Solver
+	la.VecFill(d.Sol.ΔY, 0)
+	ψ2 := dat.ALpsi * dat.ALpsi
+	if dat.ALcyl {
+		ψ2 = 0
+	}
+
+	// auxiliary variables
+	var it int
+	var largFb, largQ, Lδu, δλ float64
+
+	// message
+	if dat.ShowR {
+		io.Pf("\n%13s%4s%23s%23s\n", "λ", "it", "largFb", "Lδu")
+	}
+
+	// iterations
+	for it = 0; it < dat.NmaxIt; it++ {
+
+		// residual and reference load vector
+		err = o.assemble_fb(d.Fb, λ+Δλ)
+		if err != nil {
+			return
+		}
+		h := math.Sqrt(dat.Eps) * utl.Max(1.0, math.Abs(λ+Δλ))
+		err = o.assemble_fb(o.fb2, λ+Δλ+h)
+		if err != nil {
+			return
+		}
+		for i := 0; i < d.Nyb; i++ {
+			o.q[i] = (o.fb2[i] - d.Fb[i]) / h
+		}
+		d.Sol.T = λ + Δλ
+
+		// find largest absolute component of fb
+		largFb = la.VecLargest(d.Fb, 1)
+		largQ = la.VecLargest(o.q, 1)
+
+		// save residual
+		if d.Sim.Data.Stat {
+			if o.sum != nil {
+				o.sum.Resids.Append(it == 0, largFb)
+			}
+		}
+
+		// check convergence on fb
+		if it > 0 {
+			if largFb < dat.FbTol*largQ*math.Abs(Δλ) { // converged on fb
+				converged = true
+				break
+			}
+			if largFb < dat.FbMin { // converged with smallest value of fb
+				converged = true
+				break
+			}
+		}
+
+		// assemble Jacobian matrix
+		do_asm_fact := (it == 0 || !dat.CteTg)
+		if do_asm_fact {
+
+			// assemble element matrices
+			d.Kb.Start()
+			err = elems_add_to_kb(d, it == 0)
+			if err != nil {
+				return
+			}
+
+			// debug
+			if dbgKb != nil {
+				dbgKb(d, it)
+			}
+
+			// join A and tr(A) matrices into Kb
+			if d.Proc == 0 {
+				d.Kb.PutMatAndMatT(&d.EssenBcs.A)
+			}
+
+			// initialise linear solver
+			if d.InitLSol {
+				err = d.LinSol.InitR(&d.Kb.Triplet, d.Sim.LinSol.Symmetric, d.Sim.LinSol.Verbose, d.Sim.LinSol.Timing)
+				if err != nil {
+					err = chk.Err("cannot initialise linear solver:\n%v", err)
+					return
+				}
+				d.InitLSol = false
+			}
+
+			// perform factorisation
+			err = d.LinSol.Fact()
+			if err != nil {
+				err = chk.Err("factorisation failed:\n%v", err)
+				return
+			}
+		}
+
+		// solve for wr := Kb⁻¹.fb and wq := Kb⁻¹.q
+		err = d.LinSol.SolveR(o.wr, d.Fb, false)
+		if err != nil {
+			err = chk.Err("solve failed:%v\n", err)
+			return
+		}
+		err = d.LinSol.SolveR(o.wq, o.q, false)
+		if err != nil {
+			err = chk.Err("solve failed:%v\n", err)
+			return
+		}
+
+		// auxiliary
+		uq := o.wq[:d.Ny]
+		ur := o.wr[:d.Ny]
+		qq := ψ2 * la.VecDot(o.q[:d.Ny], o.q[:d.Ny])
+
+		// predictor
+		if it == 0 {
+			a := math.Sqrt(la.VecDot(uq, uq) + qq)
+			if *Δl == 0 {
+				*Δl = math.Abs(Δλini) * a
+			}
+			δλ = *Δl / a
+			if la.VecDot(o.ΔUp, uq)+qq*Δλp < 0 {
+				δλ = -δλ
+			}
+
+			// corrector
+		} else {
+			for i := 0; i < d.Ny; i++ {
+				o.aux[i] = d.Sol.ΔY[i] + ur[i]
+			}
+			a1 := la.VecDot(uq, uq) + qq
+			a2 := 2.0*la.VecDot(uq, o.aux) + 2.0*qq*Δλ
+			a3 := la.VecDot(o.aux, o.aux) + qq*Δλ*Δλ - (*Δl)*(*Δl)
+			disc := a2*a2 - 4.0*a1*a3
+			if disc < 0 {
+				return // not converged: complex roots
+			}
+			δλ1 := (-a2 + math.Sqrt(disc)) / (2.0 * a1)
+			δλ2 := (-a2 - math.Sqrt(disc)) / (2.0 * a1)
+
+			// select root with smallest angle between old and new increments
+			var c1, c2 float64
+			for i := 0; i < d.Ny; i++ {
+				c1 += (o.aux[i] + δλ1*uq[i]) * d.Sol.ΔY[i]
+				c2 += (o.aux[i] + δλ2*uq[i]) * d.Sol.ΔY[i]
+			}
+			c1 += qq * (Δλ + δλ1) * Δλ
+			c2 += qq * (Δλ + δλ2) * Δλ
+			δλ = δλ1
+			if c2 > c1 {
+				δλ = δλ2
+			}
+		}
+
+		// update load factor and primary variables (y)
+		Δλ += δλ
+		d.Sol.T = λ + Δλ
+		for i := 0; i < d.Nyb; i++ {
+			d.Wb[i] = o.wr[i] + δλ*o.wq[i]
+		}
+		for i := 0; i < d.Ny; i++ {
+			d.Sol.Y[i] += d.Wb[i]  // y += δy
+			d.Sol.ΔY[i] += d.Wb[i] // ΔY += δy
+		}
+
+		// update Lagrange multipliers (λ)
+		for i := 0; i < d.Nlam; i++ {
+			d.Sol.L[i] += d.Wb[d.Ny+i] // λ += δλ
+		}
+
+		// backup / restore
+		if it == 0 {
+			// create backup copy of all secondary variables
+			for _, e := range d.ElemIntvars {
+				e.BackupIvs(false)
+			}
+		} else {
+			// recover last converged state from backup copy
+			for _, e := range d.ElemIntvars {
+				e.RestoreIvs(false)
+			}
+		}
+
+		// update secondary variables
+		err = elems_update(d)
+		if err != nil {
+			err = nil
+			return // not converged: e.g. stress update failed
+		}
+
+		// compute RMS norm of δu and check convegence on δu
+		Lδu = la.VecRmsErr(d.Wb[:d.Ny], dat.Atol, dat.Rtol, d.Sol.Y[:d.Ny])
+
+		// message
+		if dat.ShowR {
+			io.Pf("%13.6e%4d%23.15e%23.15e\n", λ+Δλ, it, largFb, Lδu)
+		}
+
+		// stop if converged on δu
+		if it > 0 && Lδu < dat.Itol {
+			converged = true
+			break
+		}
+	}
+	nit = it + 1
+	return
+}
+
+// assemble_fb assembles the right-hand side vector (fb) with **negative** of residuals for a given
+// load factor
+func (o *SolverArcLength) assemble_fb(fb []float64, λ float64) (err error) {
+	o.dom.Sol.T = λ
+	return assemble_fb(fb, o.dom, λ)
+}
+
+// record records load factor and control DOF into summary
+func (o *SolverArcLength) record(λ float64, ctrlEq int) {
+	if o.sum == nil {
+		return
+	}
+	o.sum.LoadFactors = append(o.sum.LoadFactors, λ)
+	if ctrlEq >= 0 {
+		o.sum.CtrlVals = append(o.sum.CtrlVals, o.dom.Sol.Y[ctrlEq])
+	}
+}
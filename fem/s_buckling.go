@@ -0,0 +1,138 @@
+// Copyright 2015 Dorival Pedroso and Raul Durand. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fem
+
+import (
+	"github.com/cpmech/gosl/chk"
+	"github.com/cpmech/gosl/fun"
+	"github.com/cpmech/gosl/io"
+	"github.com/cpmech/gosl/la"
+)
+
+// SolverBuckling performs a linear buckling (eigen-buckling) analysis by solving the eigenproblem
+// (K + λ.Kg).φ = 0, where Kg is the geometric stiffness matrix computed with the axial forces of a
+// reference state. The reference loads times λ are the critical loads
+//  Note: (1) the reference state is computed by solving the static problem with the loads of the
+//            current stage at t = tf; or it is taken from the previous stage if BKprev is true
+//        (2) the constraints are honoured as in SolverModal and the same subspace iteration
+//            parameters (MDnmodes, MDnsub, MDtol and MDnmaxIt) are employed
+//        (3) only elements implementing ElemGeoStiff can be used
+//        (4) each mode is saved as one output "time" equal to the mode number (1, 2, ...),
+//            with the largest absolute component of the mode shape equal to one. The critical load
+//            factors are saved in Summary.CritFactors
+type SolverBuckling struct {
+	dom *Domain
+	sum *Summary
+	dc  *DynCoefs
+
+	// results
+	Λ []float64   // [nmodes] critical load factors
+	Φ [][]float64 // [nmodes][ny] buckling modes
+
+	// auxiliary
+	Kg   *la.Triplet  // [ny][ny] geometric stiffness matrix
+	Kgcc *la.CCMatrix // [ny][ny] geometric stiffness matrix (compressed-column format)
+}
+
+// set factory
+func init() {
+	solverallocators["buckling"] = func(doms []*Domain, sum *Summary, dc *DynCoefs) FEsolver {
+		if len(doms) != 1 {
+			chk.Panic("SolverBuckling works with one domain only")
+		}
+		solver := new(SolverBuckling)
+		solver.dom = doms[0]
+		solver.sum = sum
+		solver.dc = dc
+		return solver
+	}
+}
+
+// Run computes the reference state and the lowest MDnmodes critical load factors and buckling modes
+//  Note: dtFunc and dtoFunc are not used
+func (o *SolverBuckling) Run(tf float64, dtFunc, dtoFunc fun.Func, verbose bool, dbgKb DebugKb_t) (err error) {
+
+	// check
+	d := o.dom
+	dat := d.Sim.Solver
+	if !d.Sim.Data.Steady {
+		return chk.Err("buckling solver works with steady simulations only")
+	}
+	if d.Distr {
+		return chk.Err("buckling solver cannot run in parallel yet")
+	}
+	nfree := d.Ny - d.Nlam
+	p := dat.MDnmodes
+	if p < 1 || p > nfree {
+		return chk.Err("number of modes must be in [1, %d]. nmodes=%d is invalid", nfree, p)
+	}
+
+	// reference state
+	if !dat.BKprev {
+		t := d.Sol.T
+		d.Sol.T = tf
+		d.Sol.Dt = tf - t
+		_, _, err = run_iterations(tf, tf-t, d, o.dc, o.sum, dbgKb)
+		if err != nil {
+			return chk.Err("cannot compute reference state:\n%v", err)
+		}
+	}
+
+	// assemble and factorise K
+	d.Kb.Start()
+	err = elems_add_to_kb(d, true)
+	if err != nil {
+		return
+	}
+	d.Kb.PutMatAndMatT(&d.EssenBcs.A)
+	if d.InitLSol {
+		err = d.LinSol.InitR(&d.Kb.Triplet, d.Sim.LinSol.Symmetric, d.Sim.LinSol.Verbose, d.Sim.LinSol.Timing)
+		if err != nil {
+			return chk.Err("cannot initialise linear solver:\n%v", err)
+		}
+		d.InitLSol = false
+	}
+	err = d.LinSol.Fact()
+	if err != nil {
+		return chk.Err("factorisation failed:\n%v", err)
+	}
+
+	// assemble Kg
+	o.Kg = new(la.Triplet)
+	o.Kg.Init(d.Ny, d.Ny, d.NnzKb)
+	for _, e := range d.Elems {
+		eg, ok := e.(ElemGeoStiff)
+		if !ok {
+			return chk.Err("element eid=%d cannot be used with the buckling solver", e.Id())
+		}
+		err = eg.AddToKg(o.Kg, d.Sol)
+		if err != nil {
+			return
+		}
+	}
+	o.Kgcc = o.Kg.ToMatrix(nil)
+
+	// subspace iteration
+	o.Λ, o.Φ, err = subspace_iteration(d, o.Kgcc, p, true)
+	if err != nil {
+		return
+	}
+	if verbose {
+		io.Pf("\n%6s%23s\n", "mode", "λcr")
+		for k := 0; k < p; k++ {
+			io.Pf("%6d%23.15e\n", k+1, o.Λ[k])
+		}
+	}
+
+	// output
+	if o.sum != nil {
+		err = save_modes(o.sum, d, o.Φ)
+		if err != nil {
+			return
+		}
+		o.sum.CritFactors = append(o.sum.CritFactors, o.Λ...)
+	}
+	return
+}
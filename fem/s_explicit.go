@@ -0,0 +1,348 @@
+// Copyright 2015 Dorival Pedroso and Raul Durand. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fem
+
+import (
+	"math"
+
+	"github.com/cpmech/gosl/chk"
+	"github.com/cpmech/gosl/fun"
+	"github.com/cpmech/gosl/io"
+	"github.com/cpmech/gosl/la"
+	"github.com/cpmech/gosl/utl"
+)
+
+// SolverExplicit solves FEM problem using an explicit procedure (central-difference method)
+//  Note: (1) a diagonal (lumped) mass matrix is employed; thus the accelerations are directly
+//            computed from a = M⁻¹ . (fext - fint)
+//        (2) the essential boundary conditions / constraints A.y = c are enforced by projecting
+//            the predicted displacements with S.μ = c - A.y, where S = A.M⁻¹.tr(A)
+//        (3) only elements implementing ElemExplicit can be used
+type SolverExplicit struct {
+	doms []*Domain
+	sum  *Summary
+	dc   *DynCoefs
+
+	// auxiliary
+	dcx  DynCoefs        // coefficients used when computing forces. all zero => no inertial terms
+	data []*explicitData // [ndom] auxiliary data for each domain
+}
+
+// explicitData holds auxiliary data for each domain solved with the explicit solver
+type explicitData struct {
+	mb []float64  // [ny] diagonal (lumped) mass matrix
+	vh []float64  // [ny] velocities at half step: v(t+Δt/2)
+	δu []float64  // [ny] corrections of displacements due to constraints: δu = M⁻¹.tr(A).μ
+	μ  []float64  // [nλ] multipliers for the projection of constraints
+	rc []float64  // [nλ] residual of constraints: rc = c - A.y
+	S  la.Triplet // [nλ][nλ] S = A.M⁻¹.tr(A)
+	ls la.LinSol  // linear solver for S.μ = rc
+}
+
+// set factory
+func init() {
+	solverallocators["exp"] = func(doms []*Domain, sum *Summary, dc *DynCoefs) FEsolver {
+		solver := new(SolverExplicit)
+		solver.doms = doms
+		solver.sum = sum
+		solver.dc = dc
+		return solver
+	}
+}
+
+func (o *SolverExplicit) Run(tf float64, dtFunc, dtoFunc fun.Func, verbose bool, dbgKb DebugKb_t) (err error) {
+
+	// control
+	t := o.doms[0].Sol.T
+	dat := o.doms[0].Sim.Solver
+	tout := t + dtoFunc.F(t, nil)
+	if o.doms[0].Sim.Data.Steady {
+		return chk.Err("explicit solver cannot be used with steady simulations")
+	}
+
+	// lumped masses, critical time step and initial accelerations
+	Δtcr, err := o.init_data(t)
+	defer o.clean_data()
+	if err != nil {
+		return chk.Err("cannot initialise explicit solver:\n%v", err)
+	}
+
+	// first output
+	if o.sum != nil {
+		err = o.sum.SaveDomains(t, o.doms, false)
+		if err != nil {
+			return chk.Err("cannot save results:\n%v", err)
+		}
+	}
+
+	// time loop
+	var Δt float64
+	var lasttimestep bool
+	for t < tf {
+
+		// time increment
+		Δt = dtFunc.F(t, nil)
+		if !dat.EXnocrit {
+			Δt = utl.Min(Δt, dat.EXfcrit*Δtcr)
+		}
+		if t+Δt >= tf-dat.DtMin {
+			Δt = tf - t
+			lasttimestep = true
+		}
+		if Δt < dat.DtMin {
+			return chk.Err("Δt increment is too small: %g < %g", Δt, dat.DtMin)
+		}
+		t += Δt
+		if lasttimestep {
+			t = tf
+		}
+
+		// message
+		if verbose {
+			io.PfWhite("%30.15f\r", t)
+		}
+
+		// for all domains
+		for i, d := range o.doms {
+			d.Sol.T = t
+			d.Sol.Dt = Δt
+			err = o.step(d, o.data[i], Δt)
+			if err != nil {
+				return chk.Err("explicit step failed:\n%v", err)
+			}
+		}
+
+		// perform output
+		if t >= tout || lasttimestep {
+			if o.sum != nil {
+				err = o.sum.SaveDomains(t, o.doms, false)
+				if err != nil {
+					return chk.Err("cannot save results:\n%v", err)
+				}
+			}
+			tout += dtoFunc.F(t, nil)
+		}
+	}
+	return
+}
+
+// step performs one time step of the central-difference method
+func (o *SolverExplicit) step(d *Domain, x *explicitData, Δt float64) (err error) {
+
+	// auxiliary
+	Y := d.Sol.Y
+	ΔY := d.Sol.ΔY
+	v := d.Sol.Dydt
+	a := d.Sol.D2ydt2
+
+	// velocities at half step and predicted displacements
+	for I := 0; I < d.Ny; I++ {
+		x.vh[I] = v[I] + Δt*a[I]/2.0
+		ΔY[I] = Δt * x.vh[I]
+		Y[I] += ΔY[I]
+	}
+
+	// enforce constraints
+	err = o.project(d, x, Δt)
+	if err != nil {
+		return
+	}
+
+	// update secondary variables
+	err = elems_update(d)
+	if err != nil {
+		return
+	}
+
+	// accelerations and velocities at the end of step
+	err = o.accelerations(d, x)
+	if err != nil {
+		return
+	}
+	for I := 0; I < d.Ny; I++ {
+		v[I] = x.vh[I] + Δt*a[I]/2.0
+	}
+	return
+}
+
+// project corrects the predicted displacements such that A.y = c
+func (o *SolverExplicit) project(d *Domain, x *explicitData, Δt float64) (err error) {
+
+	// skip if there are no constraints
+	if d.Nlam == 0 {
+		return
+	}
+
+	// residual of constraints: rc = c - A.y
+	for i, c := range d.EssenBcs.Bcs {
+		x.rc[i] = c.Fcn.F(d.Sol.T, nil)
+	}
+	la.SpMatVecMulAdd(x.rc, -1, d.EssenBcs.Am, d.Sol.Y) // rc += -1 * A * y
+
+	// solve S.μ = rc
+	err = x.ls.SolveR(x.μ, x.rc, false)
+	if err != nil {
+		return chk.Err("cannot solve projection of constraints:\n%v", err)
+	}
+
+	// corrections: δu = M⁻¹.tr(A).μ
+	la.VecFill(x.δu, 0)
+	la.SpMatTrVecMulAdd(x.δu, 1, d.EssenBcs.Am, x.μ) // δu += tr(A) * μ
+	for I := 0; I < d.Ny; I++ {
+		x.δu[I] /= x.mb[I]
+		d.Sol.Y[I] += x.δu[I]
+		d.Sol.ΔY[I] += x.δu[I]
+		x.vh[I] += x.δu[I] / Δt
+	}
+
+	// Lagrange multipliers (reactions); note that δu = -Δt²/2 . M⁻¹.tr(A).δλ
+	for i := 0; i < d.Nlam; i++ {
+		d.Sol.L[i] -= 2.0 * x.μ[i] / (Δt * Δt)
+	}
+	return
+}
+
+// accelerations computes a = M⁻¹ . (fext - fint - tr(A).λ)
+func (o *SolverExplicit) accelerations(d *Domain, x *explicitData) (err error) {
+
+	// set starred variables such that elements give the gravity and damping terms only
+	for I := 0; I < d.Ny; I++ {
+		d.Sol.Zet[I] = 0
+		d.Sol.Chi[I] = -x.vh[I]
+	}
+	for _, e := range d.Elems {
+		err = e.InterpStarVars(d.Sol)
+		if err != nil {
+			return chk.Err("cannot compute starred variables:\n%v", err)
+		}
+	}
+
+	// assemble right-hand side vector (fb) with **negative** of residuals
+	la.VecFill(d.Fb, 0)
+	err = elems_add_to_rhs(d.Fb, d)
+	if err != nil {
+		return
+	}
+
+	// point natural boundary conditions; e.g. concentrated loads
+	d.PtNatBcs.AddToRhs(d.Fb, d.Sol.T)
+
+	// reactions due to constraints
+	if d.Nlam > 0 {
+		la.SpMatTrVecMulAdd(d.Fb, -1, d.EssenBcs.Am, d.Sol.L) // fb += -1 * tr(A) * λ
+	}
+
+	// accelerations
+	for I := 0; I < d.Ny; I++ {
+		d.Sol.D2ydt2[I] = d.Fb[I] / x.mb[I]
+	}
+	return
+}
+
+// init_data allocates auxiliary data, computes the lumped mass matrices, the critical time step
+// and the initial accelerations
+func (o *SolverExplicit) init_data(t float64) (Δtcr float64, err error) {
+
+	// for all domains
+	Δtcr = math.MaxFloat64
+	o.data = make([]*explicitData, len(o.doms))
+	for i, d := range o.doms {
+
+		// check
+		if d.Distr {
+			return 0, chk.Err("explicit solver cannot run in parallel yet")
+		}
+		if len(d.T1eqs) > 0 || len(d.T2eqs) != d.Ny {
+			return 0, chk.Err("explicit solver can only handle second order (t2) variables. ny=%d, nt1=%d, nt2=%d", d.Ny, len(d.T1eqs), len(d.T2eqs))
+		}
+
+		// allocate data
+		x := new(explicitData)
+		x.mb = make([]float64, d.Ny)
+		x.vh = make([]float64, d.Ny)
+		x.δu = make([]float64, d.Ny)
+		o.data[i] = x
+
+		// elements do not compute inertial terms
+		d.Sol.DynCfs = &o.dcx
+
+		// lumped masses and critical time step
+		for _, e := range d.Elems {
+			ee, ok := e.(ElemExplicit)
+			if !ok {
+				return 0, chk.Err("element eid=%d cannot be used with the explicit solver", e.Id())
+			}
+			err = ee.AddToLumpedM(x.mb, d.Sol)
+			if err != nil {
+				return
+			}
+			var Δte float64
+			Δte, err = ee.CritDt(d.Sol)
+			if err != nil {
+				return
+			}
+			Δtcr = utl.Min(Δtcr, Δte)
+		}
+		for I := 0; I < d.Ny; I++ {
+			if x.mb[I] < 1e-15 {
+				return 0, chk.Err("lumped mass of equation %d must be positive. m=%g is invalid", I, x.mb[I])
+			}
+		}
+
+		// matrix for the projection of constraints: S = A.M⁻¹.tr(A)
+		if d.Nlam > 0 {
+			x.μ = make([]float64, d.Nlam)
+			x.rc = make([]float64, d.Nlam)
+			idx := make([][]int, d.Ny)
+			vals := make([][]float64, d.Ny)
+			for j, c := range d.EssenBcs.Bcs {
+				for k, eq := range c.Eqs {
+					idx[eq] = append(idx[eq], j)
+					vals[eq] = append(vals[eq], c.ValsA[k])
+				}
+			}
+			nnz := 0
+			for I := 0; I < d.Ny; I++ {
+				nnz += len(idx[I]) * len(idx[I])
+			}
+			x.S.Init(d.Nlam, d.Nlam, nnz)
+			for I := 0; I < d.Ny; I++ {
+				for p, r := range idx[I] {
+					for q, c := range idx[I] {
+						x.S.Put(r, c, vals[I][p]*vals[I][q]/x.mb[I])
+					}
+				}
+			}
+			x.ls = la.GetSolver(d.Sim.LinSol.Name)
+			err = x.ls.InitR(&x.S, true, d.Sim.LinSol.Verbose, d.Sim.LinSol.Timing)
+			if err != nil {
+				return 0, chk.Err("cannot initialise linear solver for constraints:\n%v", err)
+			}
+			err = x.ls.Fact()
+			if err != nil {
+				return 0, chk.Err("factorisation of constraints matrix failed:\n%v", err)
+			}
+		}
+
+		// initial accelerations
+		d.Sol.T = t
+		copy(x.vh, d.Sol.Dydt)
+		err = o.accelerations(d, x)
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+// clean_data cleans auxiliary data and restores the dynamic coefficients of each domain
+func (o *SolverExplicit) clean_data() {
+	for i, d := range o.doms {
+		d.Sol.DynCfs = o.dc
+		if i < len(o.data) && o.data[i] != nil && o.data[i].ls != nil {
+			o.data[i].ls.Clean()
+		}
+	}
+}
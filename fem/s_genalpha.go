@@ -0,0 +1,69 @@
+// Copyright 2015 Dorival Pedroso and Raul Durand. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fem
+
+import (
+	"github.com/cpmech/gosl/chk"
+)
+
+// genalpha_prev_terms computes the terms of the generalized-α method corresponding to the previous
+// (converged) state and stores their negative in d.fbn
+//  Input:
+//   tn -- time of previous state
+//  Note: (1) the equilibrium is divided by (1-αf); thus, with R = M.a + C.v + fint - fext:
+//             R(n+1-α)/(1-αf) = R'(n+1) + En  where  R'(n+1) = cm.M.a(n+1) + C.v(n+1) + fint(n+1) - fext(n+1)
+//            is computed by the elements with the (modified) coefficients in DynCoefs and
+//             En = [αm.M.a(n) + αf.C.v(n) + αf.(fint(n) - fext(n))] / (1-αf)
+//        (2) En is obtained with two assemblies of fb at the previous state: one with the dynamic
+//            terms αm.M.a(n) + αf.C.v(n) (using zero coefficients and the starred variables
+//            ζ = -αm.a(n) and χ = -αf.v(n)) and another one with the static terms only
+//        (3) only second-order (t2) variables can be used
+func genalpha_prev_terms(tn float64, d *Domain, dc *DynCoefs) (err error) {
+
+	// check
+	if len(d.T1eqs) > 0 {
+		return chk.Err("generalized-α method works with second-order (t2) variables only")
+	}
+
+	// set previous state (zero coefficients)
+	var dcx DynCoefs
+	fbn := d.fbn
+	t, dcfs := d.Sol.T, d.Sol.DynCfs
+	d.fbn, d.Sol.T, d.Sol.DynCfs = nil, tn, &dcx
+	defer func() {
+		d.fbn, d.Sol.T, d.Sol.DynCfs = fbn, t, dcfs
+	}()
+
+	// dynamic terms: -(αm.M.a(n) + αf.C.v(n) + fint(n) - fext(n))
+	for _, I := range d.T2eqs {
+		d.Sol.Zet[I] = -dc.αm * d.Sol.D2ydt2[I]
+		d.Sol.Chi[I] = -dc.αf * d.Sol.Dydt[I]
+	}
+	for _, e := range d.Elems {
+		err = e.InterpStarVars(d.Sol)
+		if err != nil {
+			return
+		}
+	}
+	err = assemble_fb(fbn, d, tn)
+	if err != nil {
+		return
+	}
+
+	// static terms: -(fint(n) - fext(n))
+	fbs := make([]float64, d.Nyb)
+	d.Sol.Steady = true
+	err = assemble_fb(fbs, d, tn)
+	d.Sol.Steady = false
+	if err != nil {
+		return
+	}
+
+	// fbn = -En
+	for i := 0; i < d.Nyb; i++ {
+		fbn[i] = fbn[i]/(1.0-dc.αf) - fbs[i]
+	}
+	return
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/cpmech/gosl/io"
 	"github.com/cpmech/gosl/la"
 	"github.com/cpmech/gosl/mpi"
+	"github.com/cpmech/gosl/utl"
 )
 
 // SolverImplicit solves FEM problem using an implicit procedure (with Newthon-Raphson method)
@@ -42,6 +43,10 @@ func (o *SolverImplicit) Run(tf float64, dtFunc, dtoFunc fun.Func, verbose bool,
 	tout := t + dtoFunc.F(t, nil)
 	steady := o.doms[0].Sim.Data.Steady
 
+	// automatic time stepping
+	Δtad := dtFunc.F(t, nil) // adapted time step size
+	clipped := false         // Δt has been reduced to reach output time or final time
+
 	// first output
 	if o.sum != nil {
 		err = o.sum.SaveDomains(t, o.doms, false)
@@ -50,6 +55,9 @@ func (o *SolverImplicit) Run(tf float64, dtFunc, dtoFunc fun.Func, verbose bool,
 		}
 	}
 
+	// BDF2: first step with backward Euler method
+	o.dc.SetPrevDt(0)
+
 	// time loop
 	var Δt float64
 	var lasttimestep bool
@@ -61,16 +69,30 @@ func (o *SolverImplicit) Run(tf float64, dtFunc, dtoFunc fun.Func, verbose bool,
 		}
 
 		// time increment
-		Δt = dtFunc.F(t, nil) * md
-		if t+Δt >= tf {
-			lasttimestep = true
-		}
-		if Δt < dat.DtMin {
-			if md < 1 {
+		if dat.Adapt {
+			Δt, clipped, lasttimestep = o.adapted_dt(t, Δtad, tout, tf)
+			if Δt < dat.DtMin {
 				return chk.Err("Δt increment is too small: %g < %g", Δt, dat.DtMin)
 			}
+			if lasttimestep {
+				t = tf
+			} else if clipped {
+				t = tout
+			} else {
+				t += Δt
+			}
+		} else {
+			Δt = dtFunc.F(t, nil) * md
+			if t+Δt >= tf {
+				lasttimestep = true
+			}
+			if Δt < dat.DtMin {
+				if md < 1 {
+					return chk.Err("Δt increment is too small: %g < %g", Δt, dat.DtMin)
+				}
+			}
+			t += Δt
 		}
-		t += Δt
 
 		// dynamic coefficients
 		if !steady {
@@ -89,42 +111,63 @@ func (o *SolverImplicit) Run(tf float64, dtFunc, dtoFunc fun.Func, verbose bool,
 
 		// for all domains
 		docontinue := false
-		for _, d := range o.doms {
+		nitmax := 0
+		for i, d := range o.doms {
 
-			// backup solution if divergence control is on
-			if dat.DvgCtrl {
+			// backup solution if divergence control or automatic time stepping is on
+			if dat.DvgCtrl || dat.Adapt {
 				d.backup()
 			}
 
 			// run iterations
 			d.Sol.T = t
 			d.Sol.Dt = Δt
-			diverging, err := run_iterations(t, Δt, d, o.dc, o.sum, dbgKb)
+			nit, diverging, err := run_iterations(t, Δt, d, o.dc, o.sum, dbgKb)
 			if err != nil {
 				return chk.Err("run_iterations failed:\n%v", err)
 			}
+			nitmax = utl.Imax(nitmax, nit)
 
 			// restore solution and reduce time step if divergence control is on
-			if dat.DvgCtrl {
+			if dat.DvgCtrl || dat.Adapt {
 				if diverging {
 					if verbose {
 						io.Pfred(". . . iterations diverging (%2d) . . .\n", ndiverg+1)
 					}
-					d.restore()
+					for _, dd := range o.doms[:i+1] {
+						dd.restore()
+					}
 					t -= Δt
-					d.Sol.T = t
+					for _, dd := range o.doms {
+						dd.Sol.T = t
+					}
 					md *= 0.5
+					Δtad = 0.5 * Δt
 					ndiverg += 1
+					lasttimestep = false
 					docontinue = true
 					break
 				}
-				ndiverg = 0
-				md = 1.0
 			}
 		}
 		if docontinue {
 			continue
 		}
+		ndiverg = 0
+		md = 1.0
+
+		// BDF2: accepted time step
+		o.dc.SetPrevDt(Δt)
+
+		// automatic time stepping: new time step size based on the number of iterations
+		if dat.Adapt {
+			m := utl.Min(dat.ADmmax, utl.Max(dat.ADmmin, float64(dat.ADnopt)/float64(utl.Imax(nitmax, 1))))
+			if clipped {
+				Δtad = utl.Min(Δtad, m*Δt)
+			} else {
+				Δtad = m * Δt
+			}
+		}
 
 		// perform output
 		if t >= tout || lasttimestep {
@@ -140,18 +183,48 @@ func (o *SolverImplicit) Run(tf float64, dtFunc, dtoFunc fun.Func, verbose bool,
 	return
 }
 
+// adapted_dt returns the time step size for automatic time stepping. Δt is bounded by DtMax
+// and is reduced in order to reach the output time (tout) or the final time (tf) exactly
+func (o *SolverImplicit) adapted_dt(t, Δtad, tout, tf float64) (Δt float64, clipped, lasttimestep bool) {
+	dat := o.doms[0].Sim.Solver
+	Δt = Δtad
+	if dat.DtMax > 0 {
+		Δt = utl.Min(Δt, dat.DtMax)
+	}
+	if t+Δt >= tf-dat.DtMin {
+		return tf - t, true, true
+	}
+	if t+Δt >= tout-dat.DtMin {
+		return tout - t, true, false
+	}
+	return
+}
+
 // run_iterations solves the nonlinear problem
-func run_iterations(t, Δt float64, d *Domain, dc *DynCoefs, sum *Summary, dbgKb DebugKb_t) (diverging bool, err error) {
+//  Output:
+//   nit       -- number of iterations
+//   diverging -- iterations are diverging (with DvgCtrl) or the max number of iterations has
+//                been reached (with automatic time stepping)
+func run_iterations(t, Δt float64, d *Domain, dc *DynCoefs, sum *Summary, dbgKb DebugKb_t) (nit int, diverging bool, err error) {
 
-	// zero accumulated increments
-	la.VecFill(d.Sol.ΔY, 0)
+	// residuals for reactions are only available if converged on fb (see below)
+	d.frok = false
 
 	// calculate global starred vectors and interpolate starred variables from nodes to integration points
 	if !d.Sim.Data.Steady {
 
+		// generalized-α method: terms of the previous state
+		if d.fbn != nil {
+			err = genalpha_prev_terms(t-Δt, d, dc)
+			if err != nil {
+				err = chk.Err("cannot compute generalized-α terms:\n%v", err)
+				return
+			}
+		}
+
 		// compute starred vectors
 		for _, I := range d.T1eqs {
-			d.Sol.Psi[I] = dc.β1*d.Sol.Y[I] + dc.β2*d.Sol.Dydt[I]
+			d.Sol.Psi[I] = dc.β1*d.Sol.Y[I] + dc.β2*d.Sol.Dydt[I] + dc.β3*d.Sol.ΔY[I]
 		}
 		for _, I := range d.T2eqs {
 			d.Sol.Zet[I] = dc.α1*d.Sol.Y[I] + dc.α2*d.Sol.Dydt[I] + dc.α3*d.Sol.D2ydt2[I]
@@ -168,10 +241,26 @@ func run_iterations(t, Δt float64, d *Domain, dc *DynCoefs, sum *Summary, dbgKb
 		}
 	}
 
+	// zero accumulated increments
+	//  Note: after computing the starred vectors because BDF2 needs the increments of the previous step
+	la.VecFill(d.Sol.ΔY, 0)
+
+	// single-point constraints by elimination or penalty: set prescribed values first; thus, the
+	// increments of constrained equations are zero during iterations
+	backup := true
+	if d.EssenBcs.SpcsIncrements(d.Wb, d.Sol, t) {
+		err = update_state(d, dc, d.Wb, 1, true)
+		if err != nil {
+			return
+		}
+		backup = false
+	}
+
 	// auxiliary variables
 	var it int
 	var largFb, largFb0, Lδu float64
 	var prevFb, prevLδu float64
+	var fbdone bool
 	dat := d.Sim.Solver
 
 	// message
@@ -186,24 +275,14 @@ func run_iterations(t, Δt float64, d *Domain, dc *DynCoefs, sum *Summary, dbgKb
 	for it = 0; it < dat.NmaxIt; it++ {
 
 		// assemble right-hand side vector (fb) with negative of residuals
-		la.VecFill(d.Fb, 0)
-		for _, e := range d.Elems {
-			err = e.AddToRhs(d.Fb, d.Sol)
+		// (unless it has been computed by the line search)
+		if !fbdone {
+			err = assemble_fb(d.Fb, d, t)
 			if err != nil {
 				return
 			}
 		}
-
-		// join all fb
-		if d.Distr {
-			mpi.AllReduceSum(d.Fb, d.Wb) // this must be done here because there might be nodes sharing boundary conditions
-		}
-
-		// point natural boundary conditions; e.g. concentrated loads
-		d.PtNatBcs.AddToRhs(d.Fb, t)
-
-		// essential boundary conditioins; e.g. constraints
-		d.EssenBcs.AddToRhs(d.Fb, d.Sol)
+		fbdone = false
 
 		// find largest absolute component of fb
 		largFb = la.VecLargest(d.Fb, 1)
@@ -222,10 +301,12 @@ func run_iterations(t, Δt float64, d *Domain, dc *DynCoefs, sum *Summary, dbgKb
 		} else {
 			// check convergence on Lf0
 			if largFb < dat.FbTol*largFb0 { // converged on fb
+				d.frok = true
 				break
 			}
 			// check convergence on fb_min
 			if largFb < dat.FbMin { // converged with smallest value of fb
+				d.frok = true
 				break
 			}
 		}
@@ -239,17 +320,25 @@ func run_iterations(t, Δt float64, d *Domain, dc *DynCoefs, sum *Summary, dbgKb
 		}
 		prevFb = largFb
 
+		// quasi-Newton: add pair of increments and residuals; Kb is assembled again if full
+		refact := false
+		if d.qn != nil && it > 0 {
+			refact, err = d.qn.Add(d.Fb)
+			if err != nil {
+				err = chk.Err("quasi-Newton update failed:\n%v", err)
+				return
+			}
+		}
+
 		// assemble Jacobian matrix
-		do_asm_fact := (it == 0 || !dat.CteTg)
+		do_asm_fact := (it == 0 || refact || (!dat.CteTg && d.qn == nil))
 		if do_asm_fact {
 
 			// assemble element matrices
 			d.Kb.Start()
-			for _, e := range d.Elems {
-				err = e.AddToKb(d.Kb, d.Sol, it == 0)
-				if err != nil {
-					return
-				}
+			err = elems_add_to_kb(d, it == 0)
+			if err != nil {
+				return
 			}
 
 			// debug
@@ -262,9 +351,15 @@ func run_iterations(t, Δt float64, d *Domain, dc *DynCoefs, sum *Summary, dbgKb
 				d.Kb.PutMatAndMatT(&d.EssenBcs.A)
 			}
 
+			// single-point constraints by elimination or penalty
+			err = d.EssenBcs.AddSpcsToKb(d.Kb, d.Proc == 0)
+			if err != nil {
+				return
+			}
+
 			// initialise linear solver
 			if d.InitLSol {
-				err = d.LinSol.InitR(d.Kb, d.Sim.LinSol.Symmetric, d.Sim.LinSol.Verbose, d.Sim.LinSol.Timing)
+				err = d.LinSol.InitR(&d.Kb.Triplet, d.Sim.LinSol.Symmetric, d.Sim.LinSol.Verbose, d.Sim.LinSol.Timing)
 				if err != nil {
 					err = chk.Err("cannot initialise linear solver:\n%v", err)
 					return
@@ -281,51 +376,37 @@ func run_iterations(t, Δt float64, d *Domain, dc *DynCoefs, sum *Summary, dbgKb
 		}
 
 		// solve for wb := δyb
-		err = d.LinSol.SolveR(d.Wb, d.Fb, false)
+		if d.qn != nil {
+			if do_asm_fact {
+				d.qn.Init(d.Fb)
+			}
+			err = d.qn.Dir(d.Wb, d.Fb)
+		} else {
+			err = d.LinSol.SolveR(d.Wb, d.Fb, false)
+		}
 		if err != nil {
 			err = chk.Err("solve failed:%v\n", err)
 			return
 		}
 
-		// update primary variables (y)
-		for i := 0; i < d.Ny; i++ {
-			d.Sol.Y[i] += d.Wb[i]  // y += δy
-			d.Sol.ΔY[i] += d.Wb[i] // ΔY += δy
-		}
-		if !d.Sim.Data.Steady {
-			for _, I := range d.T1eqs {
-				d.Sol.Dydt[I] = dc.β1*d.Sol.Y[I] - d.Sol.Psi[I]
-			}
-			for _, I := range d.T2eqs {
-				d.Sol.Dydt[I] = dc.α4*d.Sol.Y[I] - d.Sol.Chi[I]
-				d.Sol.D2ydt2[I] = dc.α1*d.Sol.Y[I] - d.Sol.Zet[I]
+		// update primary variables (y), Lagrange multipliers (λ) and secondary variables
+		if dat.LSearch != "" {
+			var s float64
+			s, err = line_search(t, d, dc, it == 0 && backup)
+			if err != nil {
+				return
 			}
-		}
-
-		// update Lagrange multipliers (λ)
-		for i := 0; i < d.Nlam; i++ {
-			d.Sol.L[i] += d.Wb[d.Ny+i] // λ += δλ
-		}
-
-		// backup / restore
-		if it == 0 {
-			// create backup copy of all secondary variables
-			for _, e := range d.ElemIntvars {
-				e.BackupIvs(false)
+			fbdone = true
+			if d.Sim.Data.Stat {
+				if sum != nil {
+					sum.LsSteps.Append(it == 0, s)
+				}
 			}
 		} else {
-			// recover last converged state from backup copy
-			for _, e := range d.ElemIntvars {
-				e.RestoreIvs(false)
-			}
+			err = update_state(d, dc, d.Wb, 1, it == 0 && backup)
 		}
-
-		// update secondary variables
-		for _, e := range d.Elems {
-			err = e.Update(d.Sol)
-			if err != nil {
-				break
-			}
+		if d.qn != nil {
+			d.qn.SetStep(d.Wb)
 		}
 
 		// compute RMS norm of δu and check convegence on δu
@@ -352,8 +433,103 @@ func run_iterations(t, Δt float64, d *Domain, dc *DynCoefs, sum *Summary, dbgKb
 	}
 
 	// check if iterations diverged
+	nit = it + 1
 	if it == dat.NmaxIt {
+		nit = it
+		if dat.Adapt {
+			diverging = true
+			return
+		}
 		err = chk.Err("max number of iterations reached: it = %d\n", it)
 	}
 	return
 }
+
+// assemble_fb assembles the right-hand side vector (fb) with **negative** of residuals
+func assemble_fb(fb []float64, d *Domain, t float64) (err error) {
+
+	// residuals without constraint terms; these are kept for computing the reactions
+	err = assemble_fr(fb, d, t)
+	if err != nil {
+		return
+	}
+	copy(d.fr, fb)
+
+	// essential boundary conditioins; e.g. constraints
+	d.EssenBcs.AddToRhs(fb, d.Sol)
+
+	// single-point constraints by elimination or penalty
+	d.EssenBcs.AddSpcsToRhs(fb, d.Sol)
+	return
+}
+
+// assemble_fr assembles the right-hand side vector (fr) with **negative** of residuals without the
+// terms due to essential boundary conditions and constraints
+func assemble_fr(fr []float64, d *Domain, t float64) (err error) {
+
+	// assemble right-hand side vector (fr) with negative of residuals
+	la.VecFill(fr, 0)
+	err = elems_add_to_rhs(fr, d)
+	if err != nil {
+		return
+	}
+
+	// join all fr
+	if d.Distr {
+		mpi.AllReduceSum(fr, d.Wb) // this must be done here because there might be nodes sharing boundary conditions
+	}
+
+	// point natural boundary conditions; e.g. concentrated loads
+	d.PtNatBcs.AddToRhs(fr, t)
+
+	// generalized-α method: terms of the previous state
+	if d.fbn != nil {
+		la.VecAdd(fr, 1, d.fbn) // fr += fbn
+	}
+	return
+}
+
+// update_state updates primary variables (y), Lagrange multipliers (λ) and secondary variables
+// with the increment α.δyb
+//  Input:
+//   δyb    -- [nyb] increments of y and λ
+//   α      -- multiplier of δyb; e.g. step length
+//   backup -- create backup copy of secondary variables; otherwise, recover them from backup copy
+func update_state(d *Domain, dc *DynCoefs, δyb []float64, α float64, backup bool) (err error) {
+
+	// update primary variables (y)
+	for i := 0; i < d.Ny; i++ {
+		d.Sol.Y[i] += α * δyb[i]  // y += δy
+		d.Sol.ΔY[i] += α * δyb[i] // ΔY += δy
+	}
+	if !d.Sim.Data.Steady {
+		for _, I := range d.T1eqs {
+			d.Sol.Dydt[I] = dc.β1*d.Sol.Y[I] - d.Sol.Psi[I]
+		}
+		for _, I := range d.T2eqs {
+			d.Sol.Dydt[I] = dc.α4*d.Sol.Y[I] - d.Sol.Chi[I]
+			d.Sol.D2ydt2[I] = (dc.α1*d.Sol.Y[I] - d.Sol.Zet[I]) / dc.cm
+		}
+	}
+
+	// update Lagrange multipliers (λ)
+	for i := 0; i < d.Nlam; i++ {
+		d.Sol.L[i] += α * δyb[d.Ny+i] // λ += δλ
+	}
+
+	// backup / restore
+	if backup {
+		// create backup copy of all secondary variables
+		for _, e := range d.ElemIntvars {
+			e.BackupIvs(false)
+		}
+	} else {
+		// recover last converged state from backup copy
+		for _, e := range d.ElemIntvars {
+			e.RestoreIvs(false)
+		}
+	}
+
+	// update secondary variables
+	return elems_update(d)
+}
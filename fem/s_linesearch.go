@@ -0,0 +1,89 @@
+// Copyright 2015 Dorival Pedroso and Raul Durand. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fem
+
+import (
+	"math"
+
+	"github.com/cpmech/gosl/chk"
+	"github.com/cpmech/gosl/la"
+	"github.com/cpmech/gosl/utl"
+)
+
+// line_search finds the step length s along the direction δyb = d.Wb and updates the state to
+// yb + s.δyb (primary variables, Lagrange multipliers and secondary variables)
+//  Input:
+//   t      -- current time
+//   backup -- create backup copy of secondary variables (first iteration)
+//  Output:
+//   s    -- step length
+//   d.Wb -- the increment s.δyb
+//   d.Fb -- (negative of) residuals at yb + s.δyb
+//  Note: methods:
+//   "energy" -- find s such that |G(s)| ≤ LStol.|G(0)| where G(s) = δybᵀ.fb(yb + s.δyb);
+//               using the secant method (Crisfield, Vol 1, section 9.3)
+//   "back"   -- backtracking with quadratic interpolation until fbᵀ.fb(s) ≤ (1 - 2.LSc1.s).fbᵀ.fb(0)
+func line_search(t float64, d *Domain, dc *DynCoefs, backup bool) (s float64, err error) {
+
+	// auxiliary
+	dat := d.Sim.Solver
+	energy := dat.LSearch == "energy"
+	G0 := la.VecDot(d.Wb, d.Fb)
+	f0 := la.VecDot(d.Fb, d.Fb)
+
+	// iterations
+	s = 1.0
+	var sp, snew, G, f float64 // sp: step length of current state
+	for k := 0; k < dat.LSnmaxIt; k++ {
+
+		// update state to yb + s.δyb and compute residuals
+		err = update_state(d, dc, d.Wb, s-sp, backup && k == 0)
+		sp = s
+		if err == nil {
+			err = assemble_fb(d.Fb, d, t)
+		}
+
+		// failure: e.g. stress update failed => reduce step length
+		if err != nil {
+			if s <= dat.LSsmin {
+				err = chk.Err("line search failed with min step length s=%g:\n%v", s, err)
+				return
+			}
+			s = utl.Max(s/2.0, dat.LSsmin)
+			continue
+		}
+
+		// energy method: check ratio and compute new step length by the secant method
+		if energy {
+			G = la.VecDot(d.Wb, d.Fb)
+			if math.Abs(G) <= dat.LStol*math.Abs(G0) || G0 == G {
+				break
+			}
+			snew = utl.Min(utl.Max(s*G0/(G0-G), dat.LSsmin), dat.LSsmax)
+
+			// backtracking: check sufficient decrease and compute new step length by interpolation
+		} else {
+			f = la.VecDot(d.Fb, d.Fb)
+			if f <= (1.0-2.0*dat.LSc1*s)*f0 {
+				break
+			}
+			snew = f0 * s * s / (f - f0 + 2.0*f0*s)
+			snew = utl.Max(utl.Min(utl.Max(snew, 0.1*s), 0.5*s), dat.LSsmin)
+		}
+
+		// stop if step length does not change
+		if math.Abs(snew-s) < dat.Eps*utl.Max(1.0, s) {
+			break
+		}
+		s = snew
+	}
+
+	// set increment
+	for i := 0; i < d.Nyb; i++ {
+		d.Wb[i] *= sp
+	}
+	s = sp
+	return
+}
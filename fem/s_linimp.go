@@ -36,6 +36,14 @@ func init() {
 
 func (o *SolverLinearImplicit) Run(tf float64, dtFunc, dtoFunc fun.Func, verbose bool, notused DebugKb_t) (err error) {
 
+	// check
+	if o.dc.GenAlpha {
+		return chk.Err("linear implicit solver cannot handle the generalized-α method yet")
+	}
+	if o.dc.BDF2 {
+		return chk.Err("linear implicit solver cannot handle the BDF2 method yet")
+	}
+
 	// control
 	t := o.dom.Sol.T
 	tout := t + dtoFunc.F(t, nil)
@@ -92,7 +100,7 @@ func (o *SolverLinearImplicit) Run(tf float64, dtFunc, dtoFunc fun.Func, verbose
 
 			// compute starred vectors
 			for _, I := range o.dom.T1eqs {
-				ψ[I] = Y[I] - o.dom.Sol.ΔY[I]
+				ψ[I] = o.dc.β1*Y[I] + o.dc.β2*dydt[I]
 			}
 
 			for _, I := range o.dom.T2eqs {
@@ -120,7 +128,7 @@ func (o *SolverLinearImplicit) Run(tf float64, dtFunc, dtoFunc fun.Func, verbose
 		// update velocity and acceleration
 		if !steady {
 			for _, I := range o.dom.T1eqs {
-				dydt[I] = Y[I] - ψ[I]
+				dydt[I] = o.dc.β1*Y[I] - ψ[I]
 			}
 			for _, I := range o.dom.T2eqs {
 				dydt[I] = o.dc.α4*Y[I] - χ[I]
@@ -145,15 +153,25 @@ func (o *SolverLinearImplicit) Run(tf float64, dtFunc, dtoFunc fun.Func, verbose
 // solve_linear_problem solves the linear problem
 func solve_linear_problem(t float64, d *Domain, dc *DynCoefs, sum *Summary, first bool) (err error) {
 
-	// assemble right-hand side vector (fb) with **negative** of residuals
-	la.VecFill(d.Fb, 0)
-	for _, e := range d.Elems {
-		err = e.AddToRhs(d.Fb, d.Sol)
+	// single-point constraints by elimination or penalty: set prescribed values first
+	if d.EssenBcs.SpcsIncrements(d.Wb, d.Sol, t) {
+		for i := 0; i < d.Ny; i++ {
+			d.Sol.Y[i] += d.Wb[i] // y += δy
+			d.Sol.ΔY[i] = d.Wb[i] // ΔY = δy
+		}
+		err = elems_update(d)
 		if err != nil {
 			return
 		}
 	}
 
+	// assemble right-hand side vector (fb) with **negative** of residuals
+	la.VecFill(d.Fb, 0)
+	err = elems_add_to_rhs(d.Fb, d)
+	if err != nil {
+		return
+	}
+
 	// join all fb
 	if d.Distr {
 		mpi.AllReduceSum(d.Fb, d.Wb) // this must be done here because there might be nodes sharing boundary conditions
@@ -164,17 +182,16 @@ func solve_linear_problem(t float64, d *Domain, dc *DynCoefs, sum *Summary, firs
 
 	// essential boundary conditioins; e.g. constraints
 	d.EssenBcs.AddToRhs(d.Fb, d.Sol)
+	d.EssenBcs.AddSpcsToRhs(d.Fb, d.Sol)
 
 	// assemble and factorise Jacobian matrix just once
 	if first {
 
 		// assemble element matrices
 		d.Kb.Start()
-		for _, e := range d.Elems {
-			err = e.AddToKb(d.Kb, d.Sol, true)
-			if err != nil {
-				return
-			}
+		err = elems_add_to_kb(d, true)
+		if err != nil {
+			return
 		}
 
 		// join A and tr(A) matrices into Kb
@@ -182,9 +199,15 @@ func solve_linear_problem(t float64, d *Domain, dc *DynCoefs, sum *Summary, firs
 			d.Kb.PutMatAndMatT(&d.EssenBcs.A)
 		}
 
+		// single-point constraints by elimination or penalty
+		err = d.EssenBcs.AddSpcsToKb(d.Kb, d.Proc == 0)
+		if err != nil {
+			return
+		}
+
 		// initialise linear solver (just once)
 		if d.InitLSol {
-			err = d.LinSol.InitR(d.Kb, d.Sim.LinSol.Symmetric, d.Sim.LinSol.Verbose, d.Sim.LinSol.Timing)
+			err = d.LinSol.InitR(&d.Kb.Triplet, d.Sim.LinSol.Symmetric, d.Sim.LinSol.Verbose, d.Sim.LinSol.Timing)
 			if err != nil {
 				err = chk.Err("cannot initialise linear solver:\n%v", err)
 				return
@@ -219,11 +242,14 @@ func solve_linear_problem(t float64, d *Domain, dc *DynCoefs, sum *Summary, firs
 	}
 
 	// update secondary variables
-	for _, e := range d.Elems {
-		err = e.Update(d.Sol)
-		if err != nil {
-			break
-		}
+	err = elems_update(d)
+	if err != nil {
+		return
+	}
+
+	// total increments of equations with prescribed values
+	for k, bc := range d.EssenBcs.Spcs {
+		d.Sol.ΔY[bc.Eqs[0]] += d.EssenBcs.δspc[k]
 	}
 	return
 }
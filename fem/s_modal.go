@@ -0,0 +1,455 @@
+// Copyright 2015 Dorival Pedroso and Raul Durand. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fem
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/cpmech/gosl/chk"
+	"github.com/cpmech/gosl/fun"
+	"github.com/cpmech/gosl/io"
+	"github.com/cpmech/gosl/la"
+)
+
+// SolverModal computes the natural frequencies and mode shapes of the (linearised) structure in
+// the current stage by solving the generalised eigenproblem K.φ = ω².M.φ
+//  Note: (1) the subspace iteration method is employed (Bathe, 1996, section 11.6)
+//        (2) the essential boundary conditions / constraints are honoured by solving
+//            [K tr(A); A 0] . [x; μ] = [M.y; 0]; thus all vectors satisfy A.x = 0
+//        (3) only elements implementing ElemMass can be used
+//        (4) each mode is saved as one output "time" equal to the mode number (1, 2, ...),
+//            with the largest absolute component of the mode shape equal to one. The natural
+//            frequencies f = ω/(2π) are saved in Summary.Freqs
+type SolverModal struct {
+	dom *Domain
+	sum *Summary
+	dc  *DynCoefs
+
+	// results
+	Λ []float64   // [nmodes] eigenvalues: ω²
+	Φ [][]float64 // [nmodes][ny] mode shapes (M-orthonormal)
+
+	// auxiliary
+	dcx DynCoefs     // coefficients used when assembling K. all zero => no inertial terms
+	M   *la.Triplet  // [ny][ny] mass matrix
+	Mcc *la.CCMatrix // [ny][ny] mass matrix (compressed-column format)
+}
+
+// set factory
+func init() {
+	solverallocators["modal"] = func(doms []*Domain, sum *Summary, dc *DynCoefs) FEsolver {
+		if len(doms) != 1 {
+			chk.Panic("SolverModal works with one domain only")
+		}
+		solver := new(SolverModal)
+		solver.dom = doms[0]
+		solver.sum = sum
+		solver.dc = dc
+		return solver
+	}
+}
+
+// Run computes the lowest MDnmodes natural frequencies and mode shapes
+//  Note: tf, dtFunc and dtoFunc are not used
+func (o *SolverModal) Run(tf float64, dtFunc, dtoFunc fun.Func, verbose bool, dbgKb DebugKb_t) (err error) {
+
+	// check
+	d := o.dom
+	dat := d.Sim.Solver
+	if d.Distr {
+		return chk.Err("modal solver cannot run in parallel yet")
+	}
+	nfree := d.Ny - d.Nlam
+	p := dat.MDnmodes
+	if p < 1 || p > nfree {
+		return chk.Err("number of modes must be in [1, %d]. nmodes=%d is invalid", nfree, p)
+	}
+
+	// assemble K and M
+	err = o.assemble(dbgKb)
+	if err != nil {
+		return
+	}
+
+	// subspace iteration
+	o.Λ, o.Φ, err = subspace_iteration(d, o.Mcc, p, false)
+	if err != nil {
+		return
+	}
+
+	// results
+	freqs := make([]float64, p)
+	for k := 0; k < p; k++ {
+		freqs[k] = math.Sqrt(o.Λ[k]) / (2.0 * math.Pi)
+	}
+	if verbose {
+		io.Pf("\n%6s%23s%23s\n", "mode", "ω", "f")
+		for k := 0; k < p; k++ {
+			io.Pf("%6d%23.15e%23.15e\n", k+1, math.Sqrt(o.Λ[k]), freqs[k])
+		}
+	}
+
+	// output
+	if o.sum != nil {
+		err = save_modes(o.sum, d, o.Φ)
+		if err != nil {
+			return
+		}
+		o.sum.Freqs = append(o.sum.Freqs, freqs...)
+	}
+	return
+}
+
+// save_modes saves mode shapes as outputs with "times" equal to the mode numbers (1, 2, ...). The
+// largest absolute component of each mode shape is set equal to one. The solution is restored
+// afterwards. Reactions are not saved
+func save_modes(sum *Summary, d *Domain, Φ [][]float64) (err error) {
+	t := d.Sol.T
+	d.backup()
+	for k, φ := range Φ {
+		φmax := la.VecLargest(φ, 1)
+		for i := 0; i < d.Ny; i++ {
+			d.Sol.Y[i] = φ[i] / φmax
+		}
+		d.Sol.T = float64(k + 1)
+		err = sum.save_domains(d.Sol.T, []*Domain{d}, false, false)
+		if err != nil {
+			return chk.Err("cannot save results:\n%v", err)
+		}
+	}
+	d.restore()
+	d.Sol.T = t
+	return
+}
+
+// assemble assembles and factorises the stiffness matrix (with constraints) and assembles the
+// mass matrix
+func (o *SolverModal) assemble(dbgKb DebugKb_t) (err error) {
+
+	// elements do not compute inertial terms
+	d := o.dom
+	d.Sol.DynCfs = &o.dcx
+	defer func() { d.Sol.DynCfs = o.dc }()
+
+	// stiffness matrix
+	d.Kb.Start()
+	err = elems_add_to_kb(d, true)
+	if err != nil {
+		return
+	}
+	if dbgKb != nil {
+		dbgKb(d, 0)
+	}
+	d.Kb.PutMatAndMatT(&d.EssenBcs.A)
+
+	// initialise linear solver and perform factorisation
+	if d.InitLSol {
+		err = d.LinSol.InitR(&d.Kb.Triplet, d.Sim.LinSol.Symmetric, d.Sim.LinSol.Verbose, d.Sim.LinSol.Timing)
+		if err != nil {
+			return chk.Err("cannot initialise linear solver:\n%v", err)
+		}
+		d.InitLSol = false
+	}
+	err = d.LinSol.Fact()
+	if err != nil {
+		return chk.Err("factorisation failed:\n%v", err)
+	}
+
+	// mass matrix
+	o.M = new(la.Triplet)
+	o.M.Init(d.Ny, d.Ny, d.NnzKb)
+	for _, e := range d.Elems {
+		em, ok := e.(ElemMass)
+		if !ok {
+			return chk.Err("element eid=%d cannot be used with the modal solver", e.Id())
+		}
+		err = em.AddToM(o.M, d.Sol)
+		if err != nil {
+			return
+		}
+	}
+	o.Mcc = o.M.ToMatrix(nil)
+	return
+}
+
+// subspace_iteration finds p eigenpairs using the subspace iteration method with the factorised
+// K (with constraints) in d.LinSol and the symmetric matrix B
+//  Input:
+//   buckling -- solve B.φ = ν.K.φ and return λ = -1/ν for the p most negative ν; i.e. K.φ = -λ.B.φ
+//               with B = Kg (geometric stiffness). Otherwise, solve K.φ = λ.B.φ for the p smallest λ,
+//               where B (e.g. the mass matrix) must be positive-definite
+//  Output:
+//   λ -- [p] eigenvalues
+//   Φ -- [p][ny] eigenvectors
+func subspace_iteration(d *Domain, B *la.CCMatrix, p int, buckling bool) (λ []float64, Φ [][]float64, err error) {
+
+	// dimensions
+	dat := d.Sim.Solver
+	q := dat.MDnsub
+	if q < p {
+		q = p + 8
+		if 2*p < q {
+			q = 2 * p
+		}
+	}
+	if q > d.Ny-d.Nlam {
+		q = d.Ny - d.Nlam
+	}
+
+	// starting vectors: X = B.R where R has ones in the first column and random values elsewhere
+	rnd := rand.New(rand.NewSource(13))
+	X := la.MatAlloc(q, d.Ny) // transposed: X[j] is the j-th vector
+	Y := la.MatAlloc(q, d.Ny)
+	r := make([]float64, d.Ny)
+	for j := 0; j < q; j++ {
+		for i := 0; i < d.Ny; i++ {
+			r[i] = 1
+			if j > 0 {
+				r[i] = 2.0*rnd.Float64() - 1.0
+			}
+		}
+		la.SpMatVecMul(Y[j], 1, B, r) // y := B.r
+	}
+
+	// auxiliary
+	xb := make([]float64, d.Nyb)
+	yb := make([]float64, d.Nyb)
+	Kr := la.MatAlloc(q, q)
+	Br := la.MatAlloc(q, q)
+	Q := la.MatAlloc(q, q)
+	ν := make([]float64, q)
+	νold := make([]float64, q)
+
+	// iterations
+	for it := 0; it < dat.MDnmaxIt; it++ {
+
+		// solve K.x = B.x_old (with constraints)
+		for j := 0; j < q; j++ {
+			copy(yb, Y[j])
+			err = d.LinSol.SolveR(xb, yb, false)
+			if err != nil {
+				err = chk.Err("solve failed:\n%v", err)
+				return
+			}
+			copy(X[j], xb[:d.Ny])
+		}
+
+		// reduced matrices: Kr = tr(X).K.X = tr(X).B.X_old and Br = tr(X).B.X
+		for i := 0; i < q; i++ {
+			for j := 0; j < q; j++ {
+				Kr[i][j] = la.VecDot(X[i], Y[j])
+			}
+		}
+		for j := 0; j < q; j++ {
+			la.SpMatVecMul(Y[j], 1, B, X[j]) // y := B.x
+		}
+		for i := 0; i < q; i++ {
+			for j := 0; j < q; j++ {
+				Br[i][j] = la.VecDot(X[i], Y[j])
+			}
+		}
+		for i := 0; i < q; i++ {
+			for j := i + 1; j < q; j++ {
+				Kr[i][j] = (Kr[i][j] + Kr[j][i]) / 2.0
+				Kr[j][i] = Kr[i][j]
+				Br[i][j] = (Br[i][j] + Br[j][i]) / 2.0
+				Br[j][i] = Br[i][j]
+			}
+		}
+
+		// reduced eigenproblem
+		if buckling {
+			err = gen_sym_eigen(ν, Q, Br, Kr) // Br.Q = Kr.Q.ν
+		} else {
+			err = gen_sym_eigen(ν, Q, Kr, Br) // Kr.Q = Br.Q.ν
+		}
+		if err != nil {
+			err = chk.Err("reduced eigenproblem failed:\n%v", err)
+			return
+		}
+
+		// new vectors: X := X.Q and B.X
+		Φ = la.MatAlloc(q, d.Ny)
+		for j := 0; j < q; j++ {
+			for k := 0; k < q; k++ {
+				la.VecAdd(Φ[j], Q[k][j], X[k]) // φ_j += Q_kj * x_k
+			}
+		}
+		for j := 0; j < q; j++ {
+			la.SpMatVecMul(Y[j], 1, B, Φ[j]) // y := B.φ
+		}
+
+		// check convergence
+		converged := it > 0
+		for k := 0; k < p; k++ {
+			if math.Abs(ν[k]-νold[k]) > dat.MDtol*math.Abs(ν[k]) {
+				converged = false
+			}
+		}
+		if converged {
+			λ = make([]float64, p)
+			for k := 0; k < p; k++ {
+				λ[k] = ν[k]
+				if buckling {
+					if ν[k] >= 0 {
+						err = chk.Err("cannot find %d buckling modes: only %d negative eigenvalues of (Kg, K) were found", p, k)
+						return
+					}
+					λ[k] = -1.0 / ν[k]
+				}
+			}
+			if λ[0] < 0 {
+				err = chk.Err("negative eigenvalue found: λ=%g. the stiffness matrix might not be positive-definite", λ[0])
+			}
+			Φ = Φ[:p]
+			return
+		}
+		copy(νold, ν)
+	}
+	err = chk.Err("subspace iteration did not converge after %d iterations", dat.MDnmaxIt)
+	return
+}
+
+// gen_sym_eigen solves the generalised symmetric eigenproblem A.q = λ.B.q where B is positive
+// definite. The eigenvalues are sorted in ascending order and the eigenvectors (columns of Q) are
+// normalised such that tr(Q).B.Q = I
+func gen_sym_eigen(λ []float64, Q, A, B [][]float64) (err error) {
+
+	// Cholesky factorisation: B = L.tr(L)
+	n := len(A)
+	L := la.MatAlloc(n, n)
+	for j := 0; j < n; j++ {
+		s := B[j][j]
+		for k := 0; k < j; k++ {
+			s -= L[j][k] * L[j][k]
+		}
+		if s <= 0 {
+			return chk.Err("matrix B is not positive-definite")
+		}
+		L[j][j] = math.Sqrt(s)
+		for i := j + 1; i < n; i++ {
+			s = B[i][j]
+			for k := 0; k < j; k++ {
+				s -= L[i][k] * L[j][k]
+			}
+			L[i][j] = s / L[j][j]
+		}
+	}
+
+	// C = L⁻¹.A.L⁻ᵀ (by forward substitutions)
+	W := la.MatAlloc(n, n) // W = L⁻¹.A
+	for c := 0; c < n; c++ {
+		for i := 0; i < n; i++ {
+			s := A[i][c]
+			for k := 0; k < i; k++ {
+				s -= L[i][k] * W[k][c]
+			}
+			W[i][c] = s / L[i][i]
+		}
+	}
+	C := la.MatAlloc(n, n) // C = W.L⁻ᵀ => L.tr(C) = tr(W)
+	for r := 0; r < n; r++ {
+		for i := 0; i < n; i++ {
+			s := W[r][i]
+			for k := 0; k < i; k++ {
+				s -= L[i][k] * C[r][k]
+			}
+			C[r][i] = s / L[i][i]
+		}
+	}
+
+	// standard eigenproblem: C.z = λ.z
+	Z := la.MatAlloc(n, n)
+	err = jacobi_eigen(λ, Z, C)
+	if err != nil {
+		return
+	}
+
+	// sort eigenvalues (insertion sort of indices)
+	idx := make([]int, n)
+	for i := 0; i < n; i++ {
+		idx[i] = i
+		for j := i; j > 0 && λ[idx[j]] < λ[idx[j-1]]; j-- {
+			idx[j], idx[j-1] = idx[j-1], idx[j]
+		}
+	}
+	λcpy := make([]float64, n)
+	copy(λcpy, λ)
+
+	// eigenvectors: Q = L⁻ᵀ.Z (by backward substitutions)
+	for c := 0; c < n; c++ {
+		λ[c] = λcpy[idx[c]]
+		for i := n - 1; i >= 0; i-- {
+			s := Z[i][idx[c]]
+			for k := i + 1; k < n; k++ {
+				s -= L[k][i] * Q[k][c]
+			}
+			Q[i][c] = s / L[i][i]
+		}
+	}
+	return
+}
+
+// jacobi_eigen computes the eigenvalues λ and eigenvectors (columns of Z) of the symmetric matrix
+// C using the cyclic Jacobi method. C is modified
+func jacobi_eigen(λ []float64, Z, C [][]float64) (err error) {
+	n := len(C)
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			Z[i][j] = 0
+		}
+		Z[i][i] = 1
+	}
+	for sweep := 0; sweep < 100; sweep++ {
+
+		// check off-diagonal norm
+		var off, dia float64
+		for i := 0; i < n; i++ {
+			dia += C[i][i] * C[i][i]
+			for j := i + 1; j < n; j++ {
+				off += C[i][j] * C[i][j]
+			}
+		}
+		if off <= 1e-30*dia || off == 0 {
+			for i := 0; i < n; i++ {
+				λ[i] = C[i][i]
+			}
+			return
+		}
+
+		// rotations
+		for p := 0; p < n; p++ {
+			for q := p + 1; q < n; q++ {
+				if C[p][q] == 0 {
+					continue
+				}
+				θ := (C[q][q] - C[p][p]) / (2.0 * C[p][q])
+				t := 1.0 / (math.Abs(θ) + math.Sqrt(θ*θ+1.0))
+				if θ < 0 {
+					t = -t
+				}
+				c := 1.0 / math.Sqrt(t*t+1.0)
+				s := t * c
+				for k := 0; k < n; k++ {
+					ckp, ckq := C[k][p], C[k][q]
+					C[k][p] = c*ckp - s*ckq
+					C[k][q] = s*ckp + c*ckq
+				}
+				for k := 0; k < n; k++ {
+					cpk, cqk := C[p][k], C[q][k]
+					C[p][k] = c*cpk - s*cqk
+					C[q][k] = s*cpk + c*cqk
+				}
+				for k := 0; k < n; k++ {
+					zkp, zkq := Z[k][p], Z[k][q]
+					Z[k][p] = c*zkp - s*zkq
+					Z[k][q] = s*zkp + c*zkq
+				}
+			}
+		}
+	}
+	return chk.Err("Jacobi method did not converge")
+}
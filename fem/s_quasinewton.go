@@ -0,0 +1,151 @@
+// Copyright 2015 Dorival Pedroso and Raul Durand. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fem
+
+import (
+	"github.com/cpmech/gosl/la"
+)
+
+// QuasiNewton implements quasi-Newton updates of the inverse of the Jacobian matrix: H ≈ Kb⁻¹
+//  Note: (1) H is never computed; instead, H.r is evaluated using the last factorisation of Kb
+//            (H0 = Kb⁻¹) and the pairs of increments (p) and differences of residuals (y)
+//        (2) methods:
+//             "bfgs"    -- Broyden-Fletcher-Goldfarb-Shanno with the two-loop recursion
+//             "broyden" -- Broyden's second method: H_{k+1} = H_k + (p - H_k.y) ⊗ y / (y.y)
+type QuasiNewton struct {
+	bfgs  bool                       // BFGS method; otherwise Broyden's
+	nmax  int                        // max number of pairs
+	solve func(x, b []float64) error // computes x = H0 . b
+
+	// pairs
+	P [][]float64 // [npairs][nyb] increments: p = y_{k+1} - y_k
+	Y [][]float64 // [npairs][nyb] differences of residuals: y = fb_k - fb_{k+1}
+	U [][]float64 // [npairs][nyb] Broyden: u = (p - H_k.y) / (y.y)
+	ρ []float64   // [npairs] BFGS: ρ = 1 / (y.p)
+
+	// auxiliary
+	α   []float64 // [nmax] coefficients of two-loop recursion
+	fbp []float64 // [nyb] previous fb
+	dyp []float64 // [nyb] previous increment
+	q   []float64 // [nyb] auxiliary vector
+}
+
+// NewQuasiNewton allocates a new QuasiNewton structure
+//  Input:
+//   method -- "bfgs" or "broyden"
+//   nmax   -- max number of pairs
+//   nyb    -- total number of equations
+//   solve  -- function to compute x = Kb⁻¹ . b using the last factorisation of Kb
+func NewQuasiNewton(method string, nmax, nyb int, solve func(x, b []float64) error) (o *QuasiNewton) {
+	o = new(QuasiNewton)
+	o.bfgs = method == "bfgs"
+	o.nmax = nmax
+	o.solve = solve
+	o.α = make([]float64, nmax)
+	o.fbp = make([]float64, nyb)
+	o.dyp = make([]float64, nyb)
+	o.q = make([]float64, nyb)
+	return
+}
+
+// Init removes all pairs and sets the current residual vector; e.g. after Kb has been factorised
+func (o *QuasiNewton) Init(fb []float64) {
+	o.P = o.P[:0]
+	o.Y = o.Y[:0]
+	o.U = o.U[:0]
+	o.ρ = o.ρ[:0]
+	copy(o.fbp, fb)
+}
+
+// Add adds a new pair using the increment set by SetStep and the new residual vector
+//  Output:
+//   full -- the max number of pairs has been reached; thus Kb must be assembled and factorised
+//           again and Init must be called. In this case, no pair is added
+func (o *QuasiNewton) Add(fb []float64) (full bool, err error) {
+
+	// check number of pairs
+	if len(o.P) == o.nmax {
+		return true, nil
+	}
+
+	// new pair
+	p := la.VecClone(o.dyp)
+	y := make([]float64, len(fb))
+	for i := 0; i < len(fb); i++ {
+		y[i] = o.fbp[i] - fb[i]
+	}
+	copy(o.fbp, fb)
+
+	// BFGS: skip pair if curvature condition is not satisfied
+	if o.bfgs {
+		yp := la.VecDot(y, p)
+		if yp <= 1e-14*la.VecNorm(y)*la.VecNorm(p) {
+			return
+		}
+		o.P = append(o.P, p)
+		o.Y = append(o.Y, y)
+		o.ρ = append(o.ρ, 1.0/yp)
+		return
+	}
+
+	// Broyden: u = (p - H_k.y) / (y.y)
+	yy := la.VecDot(y, y)
+	if yy == 0 {
+		return
+	}
+	u := make([]float64, len(fb))
+	err = o.Dir(u, y)
+	if err != nil {
+		return
+	}
+	for i := 0; i < len(u); i++ {
+		u[i] = (p[i] - u[i]) / yy
+	}
+	o.P = append(o.P, p)
+	o.Y = append(o.Y, y)
+	o.U = append(o.U, u)
+	return
+}
+
+// SetStep sets the increment of the current iteration (after line search, if any)
+func (o *QuasiNewton) SetStep(dy []float64) {
+	copy(o.dyp, dy)
+}
+
+// Dir computes the direction x = H . r
+func (o *QuasiNewton) Dir(x, r []float64) (err error) {
+
+	// Broyden: H_k.r = H0.r + Σ u_i (y_i.r)
+	if !o.bfgs {
+		err = o.solve(x, r)
+		if err != nil {
+			return
+		}
+		for i, u := range o.U {
+			la.VecAdd(x, la.VecDot(o.Y[i], r), u) // x += (y_i.r) * u_i
+		}
+		return
+	}
+
+	// BFGS: first loop
+	copy(o.q, r)
+	for i := len(o.P) - 1; i >= 0; i-- {
+		o.α[i] = o.ρ[i] * la.VecDot(o.P[i], o.q)
+		la.VecAdd(o.q, -o.α[i], o.Y[i]) // q -= α_i * y_i
+	}
+
+	// BFGS: initial inverse
+	err = o.solve(x, o.q)
+	if err != nil {
+		return
+	}
+
+	// BFGS: second loop
+	for i := 0; i < len(o.P); i++ {
+		β := o.ρ[i] * la.VecDot(o.Y[i], x)
+		la.VecAdd(x, o.α[i]-β, o.P[i]) // x += (α_i - β) * p_i
+	}
+	return
+}
@@ -78,6 +78,11 @@ func (o *RichardsonExtrap) Init() {
 
 func (o *RichardsonExtrap) Run(tf float64, dtFunc, dtoFunc fun.Func, verbose bool, dbgKb DebugKb_t) (err error) {
 
+	// check
+	if o.dc.BDF2 {
+		return chk.Err("Richardson's extrapolation cannot handle the BDF2 method yet")
+	}
+
 	// constants
 	dat := o.doms[0].Sim.Solver
 	atol := dat.REatol
@@ -139,7 +144,7 @@ func (o *RichardsonExtrap) Run(tf float64, dtFunc, dtoFunc fun.Func, verbose boo
 		// single step with Δt
 		d.Sol.T = t + o.Δt
 		d.Sol.Dt = o.Δt
-		o.diverging, err = run_iterations(t+o.Δt, o.Δt, d, o.dc, o.sum, dbgKb)
+		_, o.diverging, err = run_iterations(t+o.Δt, o.Δt, d, o.dc, o.sum, dbgKb)
 		if err != nil {
 			return chk.Err("single step with Δt: run_iterations failed:\n%v", err)
 		}
@@ -160,7 +165,7 @@ func (o *RichardsonExtrap) Run(tf float64, dtFunc, dtoFunc fun.Func, verbose boo
 		// 1st halved step
 		d.Sol.T = t + o.Δt/2.0
 		d.Sol.Dt = o.Δt / 2.0
-		o.diverging, err = run_iterations(t+o.Δt/2.0, o.Δt/2.0, d, o.dc, o.sum, dbgKb)
+		_, o.diverging, err = run_iterations(t+o.Δt/2.0, o.Δt/2.0, d, o.dc, o.sum, dbgKb)
 		if err != nil {
 			return chk.Err("1st halved step: run_iterations failed:\n%v", err)
 		}
@@ -173,7 +178,7 @@ func (o *RichardsonExtrap) Run(tf float64, dtFunc, dtoFunc fun.Func, verbose boo
 		// 2nd halved step
 		d.Sol.T = t + o.Δt
 		d.Sol.Dt = o.Δt
-		o.diverging, err = run_iterations(t+o.Δt, o.Δt/2.0, d, o.dc, o.sum, dbgKb)
+		_, o.diverging, err = run_iterations(t+o.Δt, o.Δt/2.0, d, o.dc, o.sum, dbgKb)
 		if err != nil {
 			return chk.Err("2nd halved step: run_iterations failed:\n%v", err)
 		}
@@ -15,6 +15,9 @@ import (
 )
 
 // Summary records summary of outputs
+//  Note: [1] the step lengths of line search are not mixed with the residuals in Resids; instead,
+//            LsSteps has the same layout as Resids: one row per time step and one value per
+//            iteration. Thus, LsSteps.Vals[i][j] is the step applied after Resids.Vals[i][j]
 type Summary struct {
 
 	// main data
@@ -23,6 +26,17 @@ type Summary struct {
 	Nproc    int          // number of processors
 	OutTimes []float64    // [nOutTimes] output times
 	Resids   utl.DblSlist // residuals (if Stat is on; includes all stages)
+	LsSteps  utl.DblSlist // step lengths of line search (if Stat is on; includes all stages) [1]
+
+	// arc-length solver
+	LoadFactors []float64 // load factors λ at all converged steps
+	CtrlVals    []float64 // values of control DOF at all converged steps
+
+	// modal solver
+	Freqs []float64 // natural frequencies f = ω/(2π) of all computed modes
+
+	// buckling solver
+	CritFactors []float64 // critical load factors of all computed buckling modes
 
 	// auxiliary
 	tidx int // time output index
@@ -30,13 +44,37 @@ type Summary struct {
 
 // SaveDomains save the results from all domains (nodes and elements)
 func (o *Summary) SaveDomains(time float64, doms []*Domain, verbose bool) (err error) {
+	return o.save_domains(time, doms, true, verbose)
+}
+
+// save_domains save the results from all domains (nodes and elements)
+//  Input:
+//   react -- compute and save reactions; otherwise, no reactions are saved (e.g. with mode shapes)
+func (o *Summary) save_domains(time float64, doms []*Domain, react, verbose bool) (err error) {
 
 	// output results from all domains
-	for _, d := range doms {
-		err = d.Save(o.tidx, verbose)
+	for i, d := range doms {
+		if react {
+			err = d.CalcReactions()
+			if err != nil {
+				return chk.Err("SaveResults failed:\n%v", err)
+			}
+			err = d.Save(o.tidx, verbose)
+		} else {
+			bkp := d.Sol.React
+			d.Sol.React = nil
+			err = d.Save(o.tidx, verbose)
+			d.Sol.React = bkp
+		}
 		if err != nil {
 			return chk.Err("SaveResults failed:\n%v", err)
 		}
+		if d.Sim.Data.Ckpout {
+			err = d.SaveCheckpoint(ckp_path(ckp_key_out(d.Sim.DirOut, d.Sim.Key, o.tidx), d.Proc, i), o, verbose)
+			if err != nil {
+				return chk.Err("SaveResults failed:\n%v", err)
+			}
+		}
 	}
 
 	// update internal structures
@@ -0,0 +1,108 @@
+// Copyright 2015 Dorival Pedroso and Raul Durand. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fem
+
+import (
+	"testing"
+
+	"github.com/cpmech/gosl/chk"
+	"github.com/cpmech/gosl/io"
+	"github.com/cpmech/gosl/utl"
+
+	"github.com/cpmech/gofem/inp"
+)
+
+func Test_bdf2_01(tst *testing.T) {
+
+	//verbose()
+	chk.PrintTitle("bdf2 01. coefficients")
+
+	// coefficients
+	var dat inp.SolverData
+	dat.SetDefault()
+	dat.BDF2 = true
+	dat.PostProcess()
+	var dc DynCoefs
+	dc.Init(&dat)
+
+	// function and its derivative
+	y := func(t float64) float64 { return 1.0 + 2.0*t + 3.0*t*t }
+	dydt := func(t float64) float64 { return 2.0 + 6.0*t }
+
+	// first step: backward Euler
+	h := 0.2
+	err := dc.CalcBetas(h)
+	if err != nil {
+		tst.Errorf("CalcBetas failed:\n%v", err)
+		return
+	}
+	ψ := dc.β1*y(0.5) + dc.β2*dydt(0.5)
+	chk.Scalar(tst, "dydt (backward Euler)", 1e-13, dc.β1*y(0.7)-ψ, (y(0.7)-y(0.5))/h)
+
+	// variable steps: BDF2 is exact for quadratic functions
+	for _, hp := range []float64{0.2, 0.05, 0.6} {
+		tn := 0.7
+		dc.SetPrevDt(hp)
+		err = dc.CalcBetas(h)
+		if err != nil {
+			tst.Errorf("CalcBetas failed:\n%v", err)
+			return
+		}
+		ψ = dc.β1*y(tn) + dc.β2*dydt(tn) + dc.β3*(y(tn)-y(tn-hp))
+		io.Pforan("hp=%g: β1=%g β3=%g\n", hp, dc.β1, dc.β3)
+		chk.Scalar(tst, io.Sf("dydt (hp=%g)", hp), 1e-13, dc.β1*y(tn+h)-ψ, dydt(tn+h))
+	}
+}
+
+func Test_bdf2_02(tst *testing.T) {
+
+	//verbose()
+	chk.PrintTitle("bdf2 02. flow along column with BDF2 and automatic time stepping")
+
+	// run simulation
+	analysis := NewFEM("data/p01bdf2.sim", "", true, true, false, false, chk.Verbose, 0)
+	err := analysis.Run()
+	if err != nil {
+		tst.Errorf("Run failed:\n%v", err)
+		return
+	}
+
+	// check output times
+	tout := utl.LinSpace(0, 1000, 11)
+	chk.Vector(tst, "output times", 1e-15, analysis.Summary.OutTimes, tout)
+
+	// decay of first mode of diffusion along column with DtMax, DtMax/2 and DtMax/4
+	var pltop []float64
+	for k, dtmax := range []float64{2.5e-4, 1.25e-4, 6.25e-5} {
+		io.Pf("\n")
+		analysis = NewFEM("data/p03bdf2.sim", "", true, true, false, false, chk.Verbose, 0)
+		analysis.Sim.Solver.DtMax = dtmax
+		plana, err := p_single_mode(analysis)
+		if err != nil {
+			tst.Errorf("p_single_mode failed:\n%v", err)
+			return
+		}
+		err = analysis.SolveOneStage(0, false)
+		if err != nil {
+			tst.Errorf("SolveOneStage failed:\n%v", err)
+			return
+		}
+		dom := analysis.Domains[0]
+		pltop = append(pltop, dom.Sol.Y[dom.Vid2node[8].GetEq("pl")]) // vertex 8 @ (0,10)
+		io.Pforan("DtMax = %g: pl @ top = %v\n", dtmax, pltop[k])
+
+		// compare pl at output times with analytical solution
+		if k == 2 {
+			p_check_single_mode(tst, analysis, plana, 1e-2)
+		}
+	}
+
+	// second order: the error is reduced 4 times when DtMax is halved
+	ratio := (pltop[0] - pltop[1]) / (pltop[1] - pltop[2])
+	io.Pforan("ratio = %v\n", ratio)
+	if ratio < 3 || ratio > 5 {
+		tst.Errorf("ratio of differences between solutions with DtMax, DtMax/2 and DtMax/4 should be approximately 4. %g is incorrect\n", ratio)
+	}
+}
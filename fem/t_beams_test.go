@@ -261,3 +261,396 @@ func Test_beam03(tst *testing.T) {
 		plt.SaveD("/tmp/gofem", "test_beam03_prob4.png")
 	}
 }
+
+func Test_beam04(tst *testing.T) {
+
+	/* beam with clamped supports and end releases (hinges) at the supports => simply supported beam
+	 *
+	 *   mid-span deflection: δ = 5.q.L⁴/(384.E.I)
+	 *   bending moment:      M = -q.x.(L-x)/2
+	 */
+
+	//verbose()
+	chk.PrintTitle("beam04. end releases")
+
+	// run simulation
+	analysis := NewFEM("data/beam04.sim", "", true, false, false, false, chk.Verbose, 0)
+	err := analysis.Run()
+	if err != nil {
+		tst.Errorf("Run failed:\n%v", err)
+		return
+	}
+
+	// check mid-span deflection
+	E, I, q, L := 100.0, 1e-4, -1.0, 1.0
+	dom := analysis.Domains[0]
+	uy := dom.Sol.Y[dom.Vid2node[1].GetEq("uy")]
+	io.Pforan("uy = %v\n", uy)
+	chk.Scalar(tst, "uy @ mid", 1e-12, uy, 5.0*q*L*L*L*L/(384.0*E*I))
+
+	// check bending moments
+	for i, x0 := range []float64{0, 0.5} {
+		ele := dom.Cid2elem[i].(*Beam)
+		for _, s := range []float64{0, 0.5, 1} {
+			x := x0 + s*L/2.0
+			_, M := ele.CalcVandM(dom.Sol, s, 1)
+			chk.Scalar(tst, io.Sf("M(x=%g)", x), 1e-12, M[0], -q*x*(L-x)/2.0)
+		}
+	}
+
+	nstations, withtext, numfmt, tol, coef := 11, true, "", 1e-10, 0.2
+	if chk.Verbose {
+		plt.SetForPng(1, 600, 150)
+		PlotAllBendingMoments(dom, nstations, withtext, numfmt, tol, coef)
+		plt.SaveD("/tmp/gofem", "test_beam04.png")
+	}
+}
+
+func Test_frame3d01(tst *testing.T) {
+
+	/* L-shaped 3D frame with out-of-plane tip load P; the first member is clamped at the origin
+	 *
+	 *   tip deflection: δ = P.L³ (2/(3.E.Iyy) + 1/(G.J))
+	 */
+
+	//verbose()
+	chk.PrintTitle("frame3d01. L-shaped 3D frame")
+
+	// run simulation
+	analysis := NewFEM("data/frame3d01.sim", "", true, false, false, false, chk.Verbose, 0)
+	err := analysis.Run()
+	if err != nil {
+		tst.Errorf("Run failed:\n%v", err)
+		return
+	}
+
+	// check tip deflection
+	E, G, Iyy, J, P, L := 1000.0, 400.0, 2e-4, 3e-4, -1.0, 1.0
+	dom := analysis.Domains[0]
+	uz := dom.Sol.Y[dom.Vid2node[2].GetEq("uz")]
+	io.Pforan("uz = %v\n", uz)
+	chk.Scalar(tst, "uz @ tip", 1e-12, uz, P*L*L*L*(2.0/(3.0*E*Iyy)+1.0/(G*J)))
+
+	// check stress resultants
+	check := func(beamId int, s float64, ref []float64) {
+		ele := dom.Cid2elem[beamId].(*Beam)
+		N, Vy, Vz, T, My, Mz := ele.CalcResultants(dom.Sol, s, 1)
+		io.Pforan("beam %d, s=%g: N=%g Vy=%g Vz=%g T=%g My=%g Mz=%g\n", beamId, s, N[0], Vy[0], Vz[0], T[0], My[0], Mz[0])
+		chk.Vector(tst, io.Sf("beam %d: N,Vy,Vz,T,My,Mz @ s=%g", beamId, s), 1e-12, []float64{N[0], Vy[0], Vz[0], T[0], My[0], Mz[0]}, ref)
+	}
+	check(0, 0, []float64{0, 0, 1, -1, 1, 0})
+	check(0, 1, []float64{0, 0, 1, -1, 0, 0})
+	check(1, 0, []float64{0, 0, 1, 0, 1, 0})
+	check(1, 0.5, []float64{0, 0, 1, 0, 0.5, 0})
+	check(1, 1, []float64{0, 0, 1, 0, 0, 0})
+
+	// 3D beams are not plotted
+	beams, _ := PlotAllBendingMoments(dom, 11, false, "", 1e-10, 0.2)
+	chk.IntAssert(len(beams), 0)
+	elems, _ := PlotAllBeamMoments(dom, 11, false, "", 1e-10, 0.2)
+	chk.IntAssert(len(elems), 0)
+
+	// diagram of local Mz of single 3D beam
+	if chk.Verbose {
+		plt.SetForPng(1, 600, 150)
+	}
+	ele := dom.Cid2elem[1].(*Beam)
+	_, M := ele.CalcVandM(dom.Sol, 0, 11)
+	ele.PlotDiagMoment(M, true, "", 1e-10, 0.2)
+	if chk.Verbose {
+		plt.SaveD("/tmp/gofem", "test_frame3d01.png")
+	}
+}
+
+func Test_frame3d02(tst *testing.T) {
+
+	/* 3D beam along Y with clamped supports and end releases (hinges) at the supports
+	 * under uniform loads along the local y (global Z) and local z (global X) axes
+	 *
+	 *   mid-span deflections: δy = 5.qn.L⁴/(384.E.Izz) and δz = 5.qz.L⁴/(384.E.Iyy)
+	 */
+
+	//verbose()
+	chk.PrintTitle("frame3d02. 3D beam with end releases")
+
+	// run simulation
+	analysis := NewFEM("data/frame3d02.sim", "", true, false, false, false, chk.Verbose, 0)
+	err := analysis.Run()
+	if err != nil {
+		tst.Errorf("Run failed:\n%v", err)
+		return
+	}
+
+	// check mid-span deflections
+	E, Iyy, Izz, qn, qz, L := 1000.0, 2e-4, 1e-4, -1.0, -1.0, 1.0
+	dom := analysis.Domains[0]
+	mid := dom.Vid2node[1]
+	ux := dom.Sol.Y[mid.GetEq("ux")]
+	uz := dom.Sol.Y[mid.GetEq("uz")]
+	io.Pforan("ux, uz = %v, %v\n", ux, uz)
+	chk.Scalar(tst, "ux @ mid", 1e-12, ux, 5.0*qz*L*L*L*L/(384.0*E*Iyy))
+	chk.Scalar(tst, "uz @ mid", 1e-12, uz, 5.0*qn*L*L*L*L/(384.0*E*Izz))
+
+	// check stress resultants
+	check := func(beamId int, s float64, ref []float64) {
+		ele := dom.Cid2elem[beamId].(*Beam)
+		N, Vy, Vz, T, My, Mz := ele.CalcResultants(dom.Sol, s, 1)
+		io.Pforan("beam %d, s=%g: N=%g Vy=%g Vz=%g T=%g My=%g Mz=%g\n", beamId, s, N[0], Vy[0], Vz[0], T[0], My[0], Mz[0])
+		chk.Vector(tst, io.Sf("beam %d: N,Vy,Vz,T,My,Mz @ s=%g", beamId, s), 1e-12, []float64{N[0], Vy[0], Vz[0], T[0], My[0], Mz[0]}, ref)
+	}
+	check(0, 0, []float64{0, 0.5, 0.5, 0, 0, 0})
+	check(0, 0.5, []float64{0, 0.25, 0.25, 0, -0.09375, 0.09375})
+	check(0, 1, []float64{0, 0, 0, 0, -0.125, 0.125})
+	check(1, 1, []float64{0, -0.5, -0.5, 0, 0, 0})
+}
+
+func Test_frame3d03(tst *testing.T) {
+
+	/* lumped masses of an inclined 3D beam along e1 = (1,2,2)/3
+	 *
+	 *   displacements: mt = ρ.A.L/2
+	 *   rotations:     mr + (mx - mr).e1[i]²  with  mr = ρ.A.L³/78  and  mx = ρ.(Iyy+Izz).L/2
+	 */
+
+	//verbose()
+	chk.PrintTitle("frame3d03. lumped masses of inclined 3D beam")
+
+	// set stage
+	analysis := NewFEM("data/frame3d03.sim", "", true, false, false, false, chk.Verbose, 0)
+	err := analysis.SetStage(0)
+	if err != nil {
+		tst.Errorf("SetStage failed:\n%v", err)
+		return
+	}
+
+	// lumped masses
+	dom := analysis.Domains[0]
+	ele := dom.Cid2elem[0].(*Beam)
+	mb := make([]float64, dom.Ny)
+	err = ele.AddToLumpedM(mb, dom.Sol)
+	if err != nil {
+		tst.Errorf("AddToLumpedM failed:\n%v", err)
+		return
+	}
+
+	// check
+	ρ, A, Iyy, Izz, L := 1.0, 0.01, 2e-4, 1e-4, 3.0
+	e1 := []float64{1.0 / 3.0, 2.0 / 3.0, 2.0 / 3.0}
+	mt := ρ * A * L / 2.0
+	mr := ρ * A * L * L * L / 78.0
+	mx := ρ * (Iyy + Izz) * L / 2.0
+	chk.Scalar(tst, "L", 1e-15, ele.L, L)
+	for _, nod := range dom.Nodes {
+		var trace float64
+		for i, key := range []string{"rx", "ry", "rz"} {
+			m := mb[nod.GetEq(key)]
+			trace += m
+			chk.Scalar(tst, io.Sf("node %d: m(%s)", nod.Vert.Id, key), 1e-14, m, mr+(mx-mr)*e1[i]*e1[i])
+		}
+		chk.Scalar(tst, io.Sf("node %d: trace of rotational masses", nod.Vert.Id), 1e-14, trace, mx+2.0*mr)
+		for _, key := range []string{"ux", "uy", "uz"} {
+			chk.Scalar(tst, io.Sf("node %d: m(%s)", nod.Vert.Id, key), 1e-15, mb[nod.GetEq(key)], mt)
+		}
+	}
+
+	// critical time step
+	Δtcr, err := ele.CritDt(dom.Sol)
+	if err != nil {
+		tst.Errorf("CritDt failed:\n%v", err)
+		return
+	}
+	io.Pforan("Δtcr = %v\n", Δtcr)
+	if Δtcr <= 0 || math.IsInf(Δtcr, 0) || math.IsNaN(Δtcr) {
+		tst.Errorf("Δtcr = %v is invalid", Δtcr)
+	}
+
+	// member aligned with the X axis: the torsional mass goes to rx only
+	analysis = NewFEM("data/frame3d01.sim", "", true, false, false, false, chk.Verbose, 0)
+	err = analysis.SetStage(0)
+	if err != nil {
+		tst.Errorf("SetStage failed:\n%v", err)
+		return
+	}
+	dom = analysis.Domains[0]
+	ele = dom.Cid2elem[0].(*Beam)
+	mb = make([]float64, dom.Ny)
+	err = ele.AddToLumpedM(mb, dom.Sol)
+	if err != nil {
+		tst.Errorf("AddToLumpedM failed:\n%v", err)
+		return
+	}
+	L = 1.0
+	mr = ρ * A * L * L * L / 78.0
+	mx = ρ * (Iyy + Izz) * L / 2.0
+	nod := dom.Vid2node[0]
+	chk.Vector(tst, "aligned: rx,ry,rz masses", 1e-14, []float64{mb[nod.GetEq("rx")], mb[nod.GetEq("ry")], mb[nod.GetEq("rz")]}, []float64{mx, mr, mr})
+}
+
+func Test_timobeam01(tst *testing.T) {
+
+	/* deep cantilever with Timoshenko beam elements (2 lin3 elements)
+	 *
+	 *   tip deflection: δ = P.L³/(3.E.I) + P.L/(G.As)
+	 *   tip rotation:   θ = P.L²/(2.E.I)
+	 */
+
+	//verbose()
+	chk.PrintTitle("timobeam01. deep cantilever")
+
+	// start simulation
+	analysis := NewFEM("data/timobeam01.sim", "", true, true, false, false, chk.Verbose, 0)
+
+	// set stage and run
+	set_and_run := func(stgidx int) {
+		err := analysis.SetStage(stgidx)
+		if err != nil {
+			tst.Errorf("SetStage failed:\n%v", err)
+			return
+		}
+		err = analysis.SolveOneStage(stgidx, true)
+		if err != nil {
+			tst.Errorf("SolveOneStage failed:\n%v", err)
+			return
+		}
+	}
+
+	// properties
+	E, G, As, I, P, L := 1000.0, 1000.0/2.6, 0.2*5.0/6.0, 0.2*0.2*0.2/12.0, -1.0, 1.0
+
+	// define function to check axial force, shear force and bending moment
+	dom := analysis.Domains[0]
+	check_NVM := func(beamId int, s, Nref, Vref, Mref, tol float64) {
+		ele := dom.Cid2elem[beamId].(*TimoBeam)
+		N, V, M := ele.CalcNVM(dom.Sol, s, 1)
+		chk.Scalar(tst, io.Sf("TimoBeam %d: N(s=%g) = %.6f", ele.Id(), s, N[0]), tol, N[0], Nref)
+		chk.Scalar(tst, io.Sf("TimoBeam %d: V(s=%g) = %.6f", ele.Id(), s, V[0]), tol, V[0], Vref)
+		chk.Scalar(tst, io.Sf("TimoBeam %d: M(s=%g) = %.6f", ele.Id(), s, M[0]), tol, M[0], Mref)
+	}
+
+	// problem # 1: tip load
+	set_and_run(0)
+	tip := dom.Vid2node[2]
+	uy := dom.Sol.Y[tip.GetEq("uy")]
+	rz := dom.Sol.Y[tip.GetEq("rz")]
+	io.Pforan("uy, rz = %v, %v\n", uy, rz)
+	chk.Scalar(tst, "uy @ tip", 1e-12, uy, P*L*L*L/(3.0*E*I)+P*L/(G*As))
+	chk.Scalar(tst, "rz @ tip", 1e-12, rz, P*L*L/(2.0*E*I))
+	check_NVM(0, 0, 0, 1, -1, 1e-11)
+	check_NVM(0, 1, 0, 1, -0.5, 1e-11)
+	check_NVM(1, 0, 0, 1, -0.5, 1e-11)
+	check_NVM(1, 1, 0, 1, 0, 1e-11)
+
+	// problem # 2: qn from 0 to -2 and qt = -1 on each element; values by statics
+	set_and_run(1)
+	check_NVM(0, 0, -1, 1, -7.0/12.0, 1e-11)
+	check_NVM(0, 1, -0.5, 0.5, -1.0/6.0, 1e-11)
+	check_NVM(1, 0, -0.5, 0.5, -1.0/6.0, 1e-11)
+	check_NVM(1, 0.5, -0.25, 0.375, -5.0/96.0, 1e-11)
+	check_NVM(1, 1, 0, 0, 0, 1e-11)
+}
+
+func Test_timobeam02(tst *testing.T) {
+
+	/* slender cantilever with Timoshenko beam elements (16 lin2 elements)
+	 *
+	 *   with reduced integration of the shear terms, the solution must approach the
+	 *   Euler-Bernoulli one: δ ≈ P.L³/(3.E.I) and θ = P.L²/(2.E.I); i.e. no shear locking
+	 */
+
+	//verbose()
+	chk.PrintTitle("timobeam02. slender cantilever. shear locking")
+
+	// run simulation
+	analysis := NewFEM("data/timobeam02.sim", "", true, false, false, false, chk.Verbose, 0)
+	err := analysis.Run()
+	if err != nil {
+		tst.Errorf("Run failed:\n%v", err)
+		return
+	}
+
+	// check tip displacement and rotation
+	E, G, As, I, P, L := 1000.0, 1000.0/2.6, 0.01*5.0/6.0, 0.01*0.01*0.01/12.0, -0.001, 1.0
+	dom := analysis.Domains[0]
+	tip := dom.Vid2node[16]
+	uy := dom.Sol.Y[tip.GetEq("uy")]
+	rz := dom.Sol.Y[tip.GetEq("rz")]
+	δ := P*L*L*L/(3.0*E*I) + P*L/(G*As)
+	io.Pforan("uy/δ = %v\n", uy/δ)
+	chk.Scalar(tst, "uy/δ @ tip", 2e-3, uy/δ, 1)
+	chk.Scalar(tst, "rz @ tip", 1e-10, rz, P*L*L/(2.0*E*I))
+}
+
+func Test_timobeam03(tst *testing.T) {
+
+	/* simply supported Timoshenko beam under uniform load (2 lin3 elements)
+	 *
+	 *   mid-span deflection: δ = 5.q.L⁴/(384.E.I) + q.L²/(8.G.As)
+	 *   mid-span moment:     M = -q.L²/8
+	 */
+
+	//verbose()
+	chk.PrintTitle("timobeam03. simply supported beam")
+
+	// run simulation
+	analysis := NewFEM("data/timobeam03.sim", "", true, false, false, false, chk.Verbose, 0)
+	err := analysis.Run()
+	if err != nil {
+		tst.Errorf("Run failed:\n%v", err)
+		return
+	}
+
+	// check mid-span deflection
+	E, G, As, I, q, L := 1000.0, 1000.0/2.6, 0.2*5.0/6.0, 0.2*0.2*0.2/12.0, -1.0, 1.0
+	dom := analysis.Domains[0]
+	uy := dom.Sol.Y[dom.Vid2node[1].GetEq("uy")]
+	io.Pforan("uy = %v\n", uy)
+	chk.Scalar(tst, "uy @ mid", 1e-12, uy, 5.0*q*L*L*L*L/(384.0*E*I)+q*L*L/(8.0*G*As))
+
+	// check bending moments
+	for _, s := range []float64{0, 0.5, 1} {
+		x := s * L / 2.0
+		_, M := dom.Cid2elem[0].(*TimoBeam).CalcVandM(dom.Sol, s, 1)
+		chk.Scalar(tst, io.Sf("M(x=%g)", x), 1e-11, M[0], -q*x*(L-x)/2.0)
+	}
+
+	// all beams
+	nstations, withtext, numfmt, tol, coef := 11, true, "", 1e-10, 0.2
+	if chk.Verbose {
+		plt.SetForPng(1, 600, 150)
+	}
+	beams, allM := PlotAllBeamMoments(dom, nstations, withtext, numfmt, tol, coef)
+	chk.IntAssert(len(beams), 2)
+	chk.IntAssert(len(allM[0]), nstations)
+	chk.Scalar(tst, "M(x=L/2)", 1e-11, allM[0][nstations-1], -q*L*L/8.0)
+	if chk.Verbose {
+		plt.SaveD("/tmp/gofem", "test_timobeam03.png")
+	}
+}
+
+func Test_timobeam04(tst *testing.T) {
+
+	/* simply supported Timoshenko beam with pinned ends (10 lin3 elements): natural frequencies
+	 *
+	 *   with k = π/L and S = G.As, ω1 is the smallest root of
+	 *     (ρ.A.ω² - S.k²).(ρ.I.ω² - E.I.k² - S) - S².k² = 0
+	 *
+	 *   with E=100, ν=0.3, A=0.01, As=5/6 A, I=1e-4, ρ=1 and L=1 => f1 = 1.3348894267168379
+	 */
+
+	//verbose()
+	chk.PrintTitle("timobeam04. simply supported beam. natural frequencies")
+
+	// run simulation
+	analysis := NewFEM("data/timobeam04.sim", "", true, true, false, false, chk.Verbose, 0)
+	err := analysis.Run()
+	if err != nil {
+		tst.Errorf("Run failed:\n%v", err)
+		return
+	}
+
+	// check first frequency; smaller than the Euler-Bernoulli one: π/2
+	f := analysis.Summary.Freqs
+	io.Pforan("f = %v\n", f)
+	chk.IntAssert(len(f), 5)
+	chk.Scalar(tst, "f1 (bending)", 1e-4, f[0], 1.3348894267168379)
+}
@@ -0,0 +1,167 @@
+// Copyright 2015 Dorival Pedroso and Raul Durand. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fem
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/cpmech/gosl/chk"
+	"github.com/cpmech/gosl/io"
+)
+
+// ckp_check_state compares the state of a domain with a reference one
+func ckp_check_state(tst *testing.T, dom, ref *Domain, tol float64) {
+	chk.Scalar(tst, "t", 1e-15, dom.Sol.T, ref.Sol.T)
+	chk.Vector(tst, "Y", tol, dom.Sol.Y, ref.Sol.Y)
+	for i, e := range dom.Elems {
+		eref := ref.Elems[i].(*ElemU)
+		for idx, s := range e.(*ElemU).States {
+			chk.Vector(tst, io.Sf("σ%d_%d", i, idx), tol, s.Sig, eref.States[idx].Sig)
+			chk.Vector(tst, io.Sf("α%d_%d", i, idx), tol, s.Alp, eref.States[idx].Alp)
+		}
+	}
+}
+
+func Test_ckp01(tst *testing.T) {
+
+	//verbose()
+	chk.PrintTitle("ckp01. checkpoints: save and restart from output time")
+
+	// reference simulation; saving checkpoints
+	ref := NewFEM("data/spo751ckp.sim", "", true, true, false, false, chk.Verbose, 0)
+	err := ref.Run()
+	if err != nil {
+		tst.Errorf("Run failed:\n%v", err)
+		return
+	}
+	io.Pforan("output times = %v\n", ref.Summary.OutTimes)
+	tidx := 2
+	if len(ref.Summary.OutTimes) <= tidx {
+		tst.Errorf("at least %d output times are required\n", tidx+1)
+		return
+	}
+
+	// restart from checkpoint at tidx
+	analysis := NewFEM("data/spo751ckp.sim", "", false, true, false, false, chk.Verbose, 0)
+	analysis.Sim.Stages[0].Load = ckp_key_out(analysis.Sim.DirOut, analysis.Sim.Key, tidx)
+	err = analysis.SetStage(0)
+	if err != nil {
+		tst.Errorf("SetStage failed:\n%v", err)
+		return
+	}
+	err = analysis.ZeroStage(0, true)
+	if err != nil {
+		tst.Errorf("ZeroStage failed:\n%v", err)
+		return
+	}
+	chk.Scalar(tst, "t @ checkpoint", 1e-15, analysis.Domains[0].Sol.T, ref.Summary.OutTimes[tidx])
+	chk.IntAssert(analysis.Summary.tidx, tidx)
+	chk.Vector(tst, "output times @ checkpoint", 1e-15, analysis.Summary.OutTimes, ref.Summary.OutTimes[:tidx])
+
+	// continue
+	err = analysis.Run()
+	if err != nil {
+		tst.Errorf("Run failed:\n%v", err)
+		return
+	}
+
+	// check
+	chk.Vector(tst, "output times", 1e-15, analysis.Summary.OutTimes, ref.Summary.OutTimes)
+	ckp_check_state(tst, analysis.Domains[0], ref.Domains[0], 1e-13)
+
+	// damped dynamic case
+	io.Pf("\n")
+	ref = NewFEM("data/qua4rayckp.sim", "", true, true, false, false, chk.Verbose, 0)
+	err = ref.Run()
+	if err != nil {
+		tst.Errorf("Run failed:\n%v", err)
+		return
+	}
+	analysis = NewFEM("data/qua4rayckp.sim", "", false, true, false, false, chk.Verbose, 0)
+	analysis.Sim.Stages[0].Load = ckp_key_out(analysis.Sim.DirOut, analysis.Sim.Key, tidx)
+	err = analysis.SetStage(0)
+	if err != nil {
+		tst.Errorf("SetStage failed:\n%v", err)
+		return
+	}
+	err = analysis.ZeroStage(0, true)
+	if err != nil {
+		tst.Errorf("ZeroStage failed:\n%v", err)
+		return
+	}
+
+	// damping matrices are restored; i.e. not computed again with the current states
+	dom, domref := analysis.Domains[0], ref.Domains[0]
+	for i, e := range dom.Elems {
+		Cray := e.(*ElemU).Cray
+		if Cray == nil {
+			tst.Errorf("damping matrix of element %d was not restored\n", i)
+			return
+		}
+		chk.Matrix(tst, io.Sf("Cray%d", i), 1e-15, Cray, domref.Elems[i].(*ElemU).Cray)
+	}
+
+	// continue
+	err = analysis.Run()
+	if err != nil {
+		tst.Errorf("Run failed:\n%v", err)
+		return
+	}
+	chk.Vector(tst, "output times", 1e-15, analysis.Summary.OutTimes, ref.Summary.OutTimes)
+	ckp_check_state(tst, dom, domref, 1e-13)
+	chk.Vector(tst, "dydt", 1e-13, dom.Sol.Dydt, domref.Sol.Dydt)
+	chk.Vector(tst, "d2ydt2", 1e-12, dom.Sol.D2ydt2, domref.Sol.D2ydt2)
+}
+
+func Test_ckp02(tst *testing.T) {
+
+	//verbose()
+	chk.PrintTitle("ckp02. checkpoints: load state at end of stage")
+
+	// reference simulation; saving checkpoint at end of stage
+	ref := NewFEM("data/spo751ckp.sim", "", true, false, false, false, chk.Verbose, 0)
+	err := ref.Run()
+	if err != nil {
+		tst.Errorf("Run failed:\n%v", err)
+		return
+	}
+
+	// load checkpoint
+	analysis := NewFEM("data/spo751ckp.sim", "", false, false, false, false, chk.Verbose, 0)
+	analysis.Sim.Stages[0].Load = ckp_key_stg(analysis.Sim.DirOut, analysis.Sim.Key, 0)
+	err = analysis.SetStage(0)
+	if err != nil {
+		tst.Errorf("SetStage failed:\n%v", err)
+		return
+	}
+	err = analysis.ZeroStage(0, true)
+	if err != nil {
+		tst.Errorf("ZeroStage failed:\n%v", err)
+		return
+	}
+
+	// check
+	ckp_check_state(tst, analysis.Domains[0], ref.Domains[0], 1e-15)
+
+	// missing checkpoint
+	analysis.Sim.Stages[0].Load = ckp_key_stg(analysis.Sim.DirOut, analysis.Sim.Key, 123)
+	if analysis.SetStage(0) == nil {
+		tst.Errorf("SetStage should have failed with missing checkpoint\n")
+	}
+
+	// truncated checkpoint
+	b, err := io.ReadFile(ckp_path(ckp_key_stg(analysis.Sim.DirOut, analysis.Sim.Key, 0), 0, 0))
+	if err != nil {
+		tst.Errorf("cannot read checkpoint:\n%v", err)
+		return
+	}
+	ckey := ckp_key_stg(analysis.Sim.DirOut, analysis.Sim.Key+"_trunc", 0)
+	io.WriteFile(ckp_path(ckey, 0, 0), bytes.NewBuffer(b[:len(b)/2]))
+	analysis.Sim.Stages[0].Load = ckey
+	if analysis.SetStage(0) == nil {
+		tst.Errorf("SetStage should have failed with truncated checkpoint\n")
+	}
+}
@@ -153,3 +153,38 @@ func Test_contact01b(tst *testing.T) {
 		}
 	}
 }
+
+func Test_contact01par(tst *testing.T) {
+
+	//verbose()
+	chk.PrintTitle("contact01par. concurrent element computations")
+
+	// reference solution: serial computations
+	ref := NewFEM("data/contact01.sim", "", true, false, false, false, chk.Verbose, 0)
+	err := ref.Run()
+	if err != nil {
+		tst.Errorf("Run failed:\n%v", err)
+		return
+	}
+
+	// two workers
+	analysis := NewFEM("data/contact01par.sim", "", true, false, false, false, chk.Verbose, 0)
+	err = analysis.Run()
+	if err != nil {
+		tst.Errorf("Run failed:\n%v", err)
+		return
+	}
+
+	// check solution
+	dom, domref := analysis.Domains[0], ref.Domains[0]
+	chk.Scalar(tst, "t", 1e-15, dom.Sol.T, domref.Sol.T)
+	chk.Vector(tst, "y", 1e-10, dom.Sol.Y, domref.Sol.Y)
+
+	// check stresses
+	for i, ele := range dom.Elems {
+		e, eref := ele.(*ElemU), domref.Elems[i].(*ElemU)
+		for idx, s := range e.States {
+			chk.Vector(tst, io.Sf("σ(e=%d,ip=%d)", e.Id(), idx), 1e-8, s.Sig, eref.States[idx].Sig)
+		}
+	}
+}
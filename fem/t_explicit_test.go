@@ -0,0 +1,56 @@
+// Copyright 2015 Dorival Pedroso and Raul Durand. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fem
+
+import (
+	"math"
+	"testing"
+
+	"github.com/cpmech/gosl/chk"
+	"github.com/cpmech/gosl/io"
+)
+
+func Test_explicit01(tst *testing.T) {
+
+	/* rod with one fixed end and suddenly applied axial load P at the other end
+	 *
+	 *    ▷0------------1 → P     E = 2.1e8, A = 0.003, ρ = 7.8
+	 *
+	 *    with lumped mass m = ρ.A.L/2 and k = E.A/L:
+	 *         u(t) = P/k . (1 - cos(ω.t)),  ω = sqrt(k/m)
+	 */
+
+	//verbose()
+	chk.PrintTitle("explicit01. rod under suddenly applied load")
+
+	// fem
+	analysis := NewFEM("data/rod01exp.sim", "", true, false, false, false, chk.Verbose, 0)
+
+	// run simulation
+	err := analysis.Run()
+	if err != nil {
+		tst.Errorf("Run failed:\n%v", err)
+		return
+	}
+
+	// analytical solution
+	E, A, ρ, L, P := 2.1e8, 0.003, 7.8, 1.0, 100.0
+	k := E * A / L
+	m := ρ * A * L / 2.0
+	ω := math.Sqrt(k / m)
+	t := 1e-3
+	uana := P * (1.0 - math.Cos(ω*t)) / k
+
+	// check
+	dom := analysis.Domains[0]
+	eq := dom.Vid2node[1].GetEq("ux")
+	io.Pforan("t=%g ux=%g (analytical=%g)\n", dom.Sol.T, dom.Sol.Y[eq], uana)
+	chk.Scalar(tst, "t", 1e-15, dom.Sol.T, t)
+	chk.Scalar(tst, "ux", 1e-8, dom.Sol.Y[eq], uana)
+
+	// velocity
+	vel := P * ω * math.Sin(ω*t) / k
+	chk.Scalar(tst, "vx", 1e-4, dom.Sol.Dydt[eq], vel)
+}
@@ -0,0 +1,88 @@
+// Copyright 2015 Dorival Pedroso and Raul Durand. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fem
+
+import (
+	"math"
+	"testing"
+
+	"github.com/cpmech/gosl/chk"
+	"github.com/cpmech/gosl/io"
+)
+
+func Test_genalpha01(tst *testing.T) {
+
+	/* damped rod with one fixed end and suddenly applied axial load P at the other end
+	 *
+	 *    ▷0------------1 → P     m = 1, k = 1 and c = 0.2 (see Test_rayleigh02)
+	 *
+	 *    generalized-α method with ρ∞ = 0.5; the results are compared with:
+	 *      (1) the same method applied to the single degree-of-freedom system
+	 *      (2) the analytical solution
+	 */
+
+	//verbose()
+	chk.PrintTitle("genalpha01. damped rod with generalized-α method")
+
+	// run simulation
+	analysis := NewFEM("data/rod01ga.sim", "", true, false, false, false, chk.Verbose, 0)
+	err := analysis.Run()
+	if err != nil {
+		tst.Errorf("Run failed:\n%v", err)
+		return
+	}
+
+	// domain for reading results
+	doms := NewDomains(analysis.Sim, analysis.DynCfs, analysis.HydSta, 0, 1, false)
+	if len(doms) == 0 {
+		tst.Errorf("NewDomains failed\n")
+		return
+	}
+	dom := doms[0]
+	err = dom.SetStage(0)
+	if err != nil {
+		tst.Errorf("SetStage failed\n%v", err)
+		return
+	}
+	eq := dom.Vid2node[1].GetEq("ux")
+
+	// single degree-of-freedom system
+	m, c, k, P, h, ρinf := 1.0, 0.2, 1.0, 1.0, 0.01, 0.5
+	αm := (2.0*ρinf - 1.0) / (ρinf + 1.0)
+	αf := ρinf / (ρinf + 1.0)
+	γ := 0.5 - αm + αf
+	β := math.Pow(1.0-αm+αf, 2.0) / 4.0
+	nsteps := 1000
+	U := make([]float64, nsteps+1)
+	var u, v, a float64
+	for n := 1; n <= nsteps; n++ {
+		up := u + h*v + h*h*(0.5-β)*a
+		vp := v + h*(1.0-γ)*a
+		anew := (P - m*αm*a - c*((1.0-αf)*vp+αf*v) - k*((1.0-αf)*up+αf*u)) / (m*(1.0-αm) + c*(1.0-αf)*γ*h + k*(1.0-αf)*β*h*h)
+		u, v, a = up+β*h*h*anew, vp+γ*h*anew, anew
+		U[n] = u
+	}
+
+	// analytical solution
+	ω := math.Sqrt(k / m)
+	ξ := c / (2.0 * m * ω)
+	ωd := ω * math.Sqrt(1.0-ξ*ξ)
+	uana := func(t float64) float64 {
+		return P * (1.0 - math.Exp(-ξ*ω*t)*(math.Cos(ωd*t)+ξ*math.Sin(ωd*t)/math.Sqrt(1.0-ξ*ξ))) / k
+	}
+
+	// check
+	for tidx, t := range analysis.Summary.OutTimes {
+		err = dom.ReadSol(analysis.Sim.DirOut, analysis.Sim.Key, analysis.Sim.EncType, tidx)
+		if err != nil {
+			tst.Errorf("ReadSol failed:\n%v", err)
+			return
+		}
+		n := int(math.Floor(t/h + 0.5))
+		io.Pforan("t=%5.2f ux=%23.15e (sdof=%23.15e, analytical=%23.15e)\n", t, dom.Sol.Y[eq], U[n], uana(t))
+		chk.Scalar(tst, io.Sf("ux(t=%g) (sdof)", t), 1e-10, dom.Sol.Y[eq], U[n])
+		chk.Scalar(tst, io.Sf("ux(t=%g) (analytical)", t), 3e-3, dom.Sol.Y[eq], uana(t))
+	}
+}
@@ -0,0 +1,190 @@
+// Copyright 2015 Dorival Pedroso and Raul Durand. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fem
+
+import (
+	"math"
+	"testing"
+
+	"github.com/cpmech/gofem/inp"
+	"github.com/cpmech/gosl/chk"
+	"github.com/cpmech/gosl/fun"
+	"github.com/cpmech/gosl/io"
+	"github.com/cpmech/gosl/la"
+)
+
+// incsup_frame returns the local frame of the rotated cube in hex8incsup.msh
+func incsup_frame() (e1, e2, e3 []float64) {
+	α, β := 30.0*math.Pi/180.0, 20.0*math.Pi/180.0
+	e1 = []float64{math.Cos(β) * math.Cos(α), math.Cos(β) * math.Sin(α), math.Sin(β)}
+	e2 = []float64{-math.Sin(α), math.Cos(α), 0}
+	e3 = []float64{-math.Sin(β) * math.Cos(α), -math.Sin(β) * math.Sin(α), math.Cos(β)}
+	return
+}
+
+// incsup_check_displ checks the displacements of the rotated cube under uniaxial compression
+func incsup_check_displ(tst *testing.T, dom *Domain, tol float64) {
+
+	// solution in local frame: E=1000, ν=0.25, σz'=-100
+	εx, εz := 0.025, -0.1
+	e1, e2, e3 := incsup_frame()
+	for _, nod := range dom.Nodes {
+		X := nod.Vert.C
+		x, y, z := la.VecDot(X, e1), la.VecDot(X, e2), la.VecDot(X, e3)
+		u := make([]float64, 3)
+		for i := 0; i < 3; i++ {
+			u[i] = εx*x*e1[i] + εx*y*e2[i] + εz*z*e3[i]
+		}
+		for i, key := range []string{"ux", "uy", "uz"} {
+			eq := nod.GetEq(key)
+			chk.Scalar(tst, io.Sf("%s @ nod %d", key, nod.Vert.Id), tol, dom.Sol.Y[eq], u[i])
+		}
+	}
+}
+
+func Test_incsup01(tst *testing.T) {
+
+	//verbose()
+	chk.PrintTitle("incsup01. rotated cube on inclined plane and line supports")
+
+	// fem
+	analysis := NewFEM("data/hex8incsup.sim", "", true, false, false, false, chk.Verbose, 0)
+
+	// run simulation
+	err := analysis.Run()
+	if err != nil {
+		tst.Errorf("Run failed\n%v", err)
+		return
+	}
+
+	// domain
+	dom := analysis.Domains[0]
+	io.Pf("%v\n", dom.EssenBcs.List(dom.Sol.T))
+
+	// constraints: 3 (corner) + 3×2 (line supports) + 3 (planes)
+	chk.IntAssert(dom.Nlam, 12)
+	e1, e2, e3 := incsup_frame()
+	dirs := map[int][]float64{1: e1, 3: e2, 4: e3}
+	for _, bc := range dom.EssenBcs.Bcs {
+		if bc.Key != "incsup" {
+			tst.Errorf("key %s is incorrect", bc.Key)
+			continue
+		}
+		chk.Scalar(tst, "|coefficients|", 1e-15, la.VecNorm(bc.ValsA), 1)
+		for vid, d := range dirs {
+			if bc.Eqs[0] == dom.Vid2node[vid].GetEq("ux") {
+				chk.Scalar(tst, io.Sf("line @ nod %d: coefficients ⋅ d", vid), 1e-15, la.VecDot(bc.ValsA, d), 0)
+			}
+		}
+	}
+
+	// check displacements
+	incsup_check_displ(tst, dom, 1e-13)
+
+	// plane supports only; edges and corner are constrained by intersecting planes
+	analysis = NewFEM("data/hex8incsup.sim", "", true, false, false, false, chk.Verbose, 0)
+	analysis.Sim.Stages[0].NodeBcs = nil
+	err = analysis.Run()
+	if err != nil {
+		tst.Errorf("Run failed\n%v", err)
+		return
+	}
+	dom = analysis.Domains[0]
+	chk.IntAssert(dom.Nlam, 12)
+	incsup_check_displ(tst, dom, 1e-13)
+}
+
+func Test_incsup02(tst *testing.T) {
+
+	//verbose()
+	chk.PrintTitle("incsup02. coefficients of inclined supports")
+
+	// 2D
+	rows, err := incsup_coefficients(2, "!alp:120")
+	if err != nil {
+		tst.Errorf("incsup_coefficients failed:\n%v", err)
+		return
+	}
+	chk.Matrix(tst, "2D", 1e-15, rows, [][]float64{{math.Cos(2.0 * math.Pi / 3.0), math.Sin(2.0 * math.Pi / 3.0)}})
+
+	// 3D plane
+	rows, err = incsup_coefficients(3, "!n:0,3,4")
+	if err != nil {
+		tst.Errorf("incsup_coefficients failed:\n%v", err)
+		return
+	}
+	chk.Matrix(tst, "3D plane", 1e-15, rows, [][]float64{{0, 0.6, 0.8}})
+
+	// 3D line
+	d := []float64{1, 2, 2}
+	rows, err = incsup_coefficients(3, "!type:line !d:1,2,2")
+	if err != nil {
+		tst.Errorf("incsup_coefficients failed:\n%v", err)
+		return
+	}
+	chk.IntAssert(len(rows), 2)
+	chk.Scalar(tst, "e1 ⋅ d", 1e-15, la.VecDot(rows[0], d), 0)
+	chk.Scalar(tst, "e2 ⋅ d", 1e-15, la.VecDot(rows[1], d), 0)
+	chk.Scalar(tst, "e1 ⋅ e2", 1e-15, la.VecDot(rows[0], rows[1]), 0)
+	chk.Scalar(tst, "|e1|", 1e-15, la.VecNorm(rows[0]), 1)
+	chk.Scalar(tst, "|e2|", 1e-15, la.VecNorm(rows[1]), 1)
+
+	// errors
+	for _, extra := range []string{"!type:point", "!n:1,0", "!n:0,0,0"} {
+		_, err = incsup_coefficients(3, extra)
+		if err == nil {
+			tst.Errorf("incsup_coefficients should have failed with extra = %q\n", extra)
+		}
+	}
+	_, err = incsup_coefficients(2, "!type:line")
+	if err == nil {
+		tst.Errorf("incsup_coefficients should have failed with line support in 2D\n")
+	}
+}
+
+func Test_incsup03(tst *testing.T) {
+
+	//verbose()
+	chk.PrintTitle("incsup03. legacy syntax of 2D inclined supports")
+
+	// coefficients
+	rows, err := incsup_coefficients(2, "!type:incsup2d !alp:30")
+	if err != nil {
+		tst.Errorf("incsup_coefficients failed:\n%v", err)
+		return
+	}
+	chk.Matrix(tst, "incsup2d", 1e-15, rows, [][]float64{{math.Cos(math.Pi / 6.0), math.Sin(math.Pi / 6.0)}})
+	_, err = incsup_coefficients(3, "!type:incsup2d !alp:30")
+	if err == nil {
+		tst.Errorf("incsup_coefficients should have failed with incsup2d in 3D\n")
+	}
+
+	// nodes
+	nodes := make([]*Node, 2)
+	eq := 0
+	for i := 0; i < 2; i++ {
+		nodes[i] = NewNode(&inp.Vert{Id: i, Tag: -1, C: []float64{float64(i), 0}})
+		eq = nodes[i].AddDofAndEq("ux", eq)
+		eq = nodes[i].AddDofAndEq("uy", eq)
+	}
+
+	// existing constraints at node 0 are replaced, except rigid ones
+	var ebcs EssentialBcs
+	ebcs.Init(nil, "", 0)
+	ebcs.Set("ux", nodes[:1], &fun.Zero, "")
+	ebcs.Set("rigid", nodes, &fun.Zero, "")
+	ebcs.SetMpc(nodes, []string{"uy", "uy"}, []float64{1, -1}, &fun.Zero)
+	err = ebcs.Set("incsup", nodes[:1], &fun.Zero, "!type:incsup2d !alp:30")
+	if err != nil {
+		tst.Errorf("Set failed:\n%v", err)
+		return
+	}
+	for _, pair := range ebcs.BcsTmp {
+		io.Pforan("%6s: inactive = %v\n", pair.bc.Key, pair.bc.Inact)
+		if pair.bc.Inact != (pair.bc.Key != "rigid" && pair.bc.Key != "incsup") {
+			tst.Errorf("%s constraint has incorrect inactive flag\n", pair.bc.Key)
+		}
+	}
+}
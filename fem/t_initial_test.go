@@ -0,0 +1,203 @@
+// Copyright 2015 Dorival Pedroso and Raul Durand. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fem
+
+import (
+	"math"
+	"testing"
+
+	"github.com/cpmech/gofem/inp"
+	"github.com/cpmech/gosl/chk"
+	"github.com/cpmech/gosl/io"
+)
+
+// initial_check_h checks the initial values of the level set function: distance to circle
+func initial_check_h(tst *testing.T, dom *Domain, tol float64, checkDydt bool) {
+	for _, nod := range dom.Nodes {
+		eq := nod.GetEq("h")
+		x, y := nod.Vert.C[0], nod.Vert.C[1]
+		h := math.Sqrt((x-0.5)*(x-0.5)+(y-0.5)*(y-0.5)) - 0.25
+		chk.Scalar(tst, io.Sf("h @ nod %d", nod.Vert.Id), tol, dom.Sol.Y[eq], h)
+		if checkDydt {
+			chk.Scalar(tst, io.Sf("dhdt @ nod %d", nod.Vert.Id), tol, dom.Sol.Dydt[eq], -h)
+		}
+	}
+}
+
+// initial_set_stage sets stage and initial values
+func initial_set_stage(tst *testing.T, analysis *FEM) (ok bool) {
+	err := analysis.SetStage(0)
+	if err != nil {
+		tst.Errorf("SetStage failed:\n%v", err)
+		return
+	}
+	err = analysis.ZeroStage(0, true)
+	if err != nil {
+		tst.Errorf("ZeroStage failed:\n%v", err)
+		return
+	}
+	return true
+}
+
+func Test_initial01(tst *testing.T) {
+
+	//verbose()
+	chk.PrintTitle("initial01. initial values from csv file")
+
+	// by vertex id
+	analysis := NewFEM("data/phi01csv.sim", "", true, false, false, false, chk.Verbose, 0)
+	if !initial_set_stage(tst, analysis) {
+		return
+	}
+	dom := analysis.Domains[0]
+	initial_check_h(tst, dom, 1e-15, true)
+
+	// by coordinates
+	for _, mapping := range []string{"nearest", "interp"} {
+		io.Pforan("mapping = %q\n", mapping)
+		analysis.Sim.Stages[0].Initial.Map = mapping
+		if !initial_set_stage(tst, analysis) {
+			return
+		}
+		initial_check_h(tst, analysis.Domains[0], 1e-15, true)
+	}
+
+	// inverse distance weighting of a linear field on a coarse grid; h = 1 + 2.x + 3.y
+	io.Pforan("mapping = \"interp\" with coarse grid\n")
+	analysis.Sim.Stages[0].Initial.File = "data/phi01idw.csv"
+	analysis.Sim.Stages[0].Initial.Map = "interp"
+	if !initial_set_stage(tst, analysis) {
+		return
+	}
+	dom = analysis.Domains[0]
+	h := func(vid int) float64 { return dom.Sol.Y[dom.Vid2node[vid].GetEq("h")] }
+
+	// @ (0.25,0.25): nearest points (0.1,0.2), (0.6,0.2), (0.1,0.6) and (0.6,0.6) with d² = 0.025, 0.125, 0.145 and 0.245
+	href := (1.8/0.025 + 2.8/0.125 + 3.0/0.145 + 4.0/0.245) / (1.0/0.025 + 1.0/0.125 + 1.0/0.145 + 1.0/0.245)
+	chk.Scalar(tst, "h @ nod 21", 1e-14, h(21), href)
+	chk.Scalar(tst, "h @ nod 21", 1e-14, h(21), 2.228216876670485)
+
+	// @ (0,0): nearest points (0.1,0.2), (0.1,0.6), (0.6,0.2) and (0.6,0.6) with d² = 0.05, 0.37, 0.4 and 0.72
+	href = (1.8/0.05 + 3.0/0.37 + 2.8/0.4 + 4.0/0.72) / (1.0/0.05 + 1.0/0.37 + 1.0/0.4 + 1.0/0.72)
+	chk.Scalar(tst, "h @ nod 0", 1e-14, h(0), href)
+	chk.Scalar(tst, "h @ nod 0", 1e-14, h(0), 2.130886504799548)
+
+	// weighted averages are bounded by the values in the table
+	for _, nod := range dom.Nodes {
+		if h(nod.Vert.Id) < 1.8 || h(nod.Vert.Id) > 6.2 {
+			tst.Errorf("h @ nod %d = %g is out of range [1.8, 6.2]\n", nod.Vert.Id, h(nod.Vert.Id))
+		}
+	}
+
+	// invalid mapping
+	analysis.Sim.Stages[0].Initial.Map = "closest"
+	err := analysis.SetStage(0)
+	if err != nil {
+		tst.Errorf("SetStage failed:\n%v", err)
+		return
+	}
+	if analysis.ZeroStage(0, true) == nil {
+		tst.Errorf("ZeroStage should have failed with invalid mapping\n")
+	}
+}
+
+func Test_initial02(tst *testing.T) {
+
+	//verbose()
+	chk.PrintTitle("initial02. initial values from results file")
+
+	// save results
+	analysis := NewFEM("data/phi01csv.sim", "", true, false, false, false, chk.Verbose, 0)
+	if !initial_set_stage(tst, analysis) {
+		return
+	}
+	err := analysis.Domains[0].SaveSol(0, chk.Verbose)
+	if err != nil {
+		tst.Errorf("SaveSol failed:\n%v", err)
+		return
+	}
+
+	// read results
+	sim := analysis.Sim
+	sim.Stages[0].Initial.File = out_nod_path(sim.DirOut, sim.Key, sim.EncType, 0, 0)
+	sim.Stages[0].Initial.Sim = "data/phi01csv.sim"
+	if !initial_set_stage(tst, analysis) {
+		return
+	}
+	initial_check_h(tst, analysis.Domains[0], 1e-15, true)
+
+	// equation numbers of results are the same as the ones of the domain
+	nodes, ny, err := results_nodes(inp.ReadSim("data/phi01csv.sim", "", false, 0), 0, 0)
+	if err != nil {
+		tst.Errorf("results_nodes failed:\n%v", err)
+		return
+	}
+	dom := analysis.Domains[0]
+	chk.IntAssert(ny, dom.Ny)
+	chk.IntAssert(len(nodes), len(dom.Nodes))
+	for _, nod := range nodes {
+		chk.IntAssert(nod.GetEq("h"), dom.Vid2node[nod.Vert.Id].GetEq("h"))
+	}
+
+	// by coordinates
+	for _, mapping := range []string{"nearest", "interp"} {
+		io.Pforan("mapping = %q\n", mapping)
+		sim.Stages[0].Initial.Map = mapping
+		if !initial_set_stage(tst, analysis) {
+			return
+		}
+		initial_check_h(tst, analysis.Domains[0], 1e-15, true)
+	}
+	sim.Stages[0].Initial.Map = ""
+
+	// missing or inexistent simulation file
+	for _, simfn := range []string{"", "data/inexistent.sim"} {
+		sim.Stages[0].Initial.Sim = simfn
+		err = analysis.SetStage(0)
+		if err != nil {
+			tst.Errorf("SetStage failed:\n%v", err)
+			return
+		}
+		if analysis.ZeroStage(0, true) == nil {
+			tst.Errorf("ZeroStage should have failed with simulation of results file = %q\n", simfn)
+		}
+	}
+}
+
+func Test_initial03(tst *testing.T) {
+
+	//verbose()
+	chk.PrintTitle("initial03. initial values from results file with different equation numbers")
+
+	// save results
+	ref := NewFEM("data/phi01csv.sim", "", true, false, false, false, chk.Verbose, 0)
+	if !initial_set_stage(tst, ref) {
+		return
+	}
+	err := ref.Domains[0].SaveSol(0, chk.Verbose)
+	if err != nil {
+		tst.Errorf("SaveSol failed:\n%v", err)
+		return
+	}
+
+	// read results; cells are given in reverse order
+	analysis := NewFEM("data/phi01res.sim", "", true, false, false, false, chk.Verbose, 0)
+	if !initial_set_stage(tst, analysis) {
+		return
+	}
+	dom, domref := analysis.Domains[0], ref.Domains[0]
+	chk.IntAssert(dom.Ny, domref.Ny)
+	samelayout := true
+	for _, nod := range dom.Nodes {
+		if nod.GetEq("h") != domref.Vid2node[nod.Vert.Id].GetEq("h") {
+			samelayout = false
+		}
+	}
+	if samelayout {
+		tst.Errorf("equation numbers should be different\n")
+		return
+	}
+	initial_check_h(tst, dom, 1e-15, true)
+}
@@ -0,0 +1,90 @@
+// Copyright 2015 Dorival Pedroso and Raul Durand. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fem
+
+import (
+	"testing"
+
+	"github.com/cpmech/gosl/chk"
+	"github.com/cpmech/gosl/io"
+)
+
+func Test_cook01(tst *testing.T) {
+
+	//verbose()
+	chk.PrintTitle("cook01. Cook's membrane with neo-Hookean material")
+
+	// fem
+	analysis := NewFEM("data/cook01.sim", "", true, false, false, false, chk.Verbose, 0)
+
+	// check Kb
+	u_DebugKb(analysis, &testKb{
+		tst: tst, eid: 63, tol: 1e-5, verb: chk.Verbose,
+		ni: -1, nj: -1, itmin: 1, itmax: 1, tmin: 0.45, tmax: 0.55,
+	})
+
+	// run simulation
+	err := analysis.Run()
+	if err != nil {
+		tst.Errorf("Run failed\n%v", err)
+		return
+	}
+
+	// displacements of top-right corner
+	//  Note: the geometry is the one of Cook's membrane; however, the material and load are not the
+	//        ones of published benchmarks. Thus, the reference values are computed by data/cook01.py
+	//        with an independent total Lagrangian implementation of the same discretisation (8x8 qua4
+	//        elements with 2x2 integration points and 10 load steps). The tolerance accounts for the
+	//        convergence tolerances of the nonlinear solver
+	dom := analysis.Domains[0]
+	nod := dom.Vid2node[80]
+	ux := dom.Sol.Y[nod.GetEq("ux")]
+	uy := dom.Sol.Y[nod.GetEq("uy")]
+	io.Pforan("ux, uy = %v, %v\n", ux, uy)
+	chk.Scalar(tst, "ux", 1e-5, ux, -6.06977932979606)
+	chk.Scalar(tst, "uy", 1e-5, uy, 7.1946663175694)
+
+	// deformation gradients and stresses
+	e := dom.Elems[63].(*ElemU)
+	for idx, _ := range e.IpsElem {
+		s := e.States[idx]
+		io.Pforan("F = %v\n", s.F)
+		chk.Scalar(tst, "Fzz", 1e-17, s.F[2][2], 1)
+		if s.F[0][0]*s.F[1][1]-s.F[0][1]*s.F[1][0] <= 0 {
+			tst.Errorf("J must be positive\n")
+		}
+	}
+}
+
+func Test_cook02(tst *testing.T) {
+
+	//verbose()
+	chk.PrintTitle("cook02. Cook's membrane. small load: neo-Hookean versus linear elastic")
+
+	// tip displacement
+	tip := func(simfile string) (uy float64, σ []float64) {
+		analysis := NewFEM(simfile, "", true, false, false, false, chk.Verbose, 0)
+		err := analysis.Run()
+		if err != nil {
+			tst.Errorf("Run failed\n%v", err)
+			return
+		}
+		dom := analysis.Domains[0]
+		uy = dom.Sol.Y[dom.Vid2node[80].GetEq("uy")]
+		σ = dom.Elems[0].(*ElemU).States[0].Sig
+		return
+	}
+
+	// run both simulations
+	uy_neo, σ_neo := tip("data/cook02.sim")
+	uy_lin, σ_lin := tip("data/cook03.sim")
+	if tst.Failed() {
+		return
+	}
+	io.Pforan("uy: neo = %v  lin = %v\n", uy_neo, uy_lin)
+	io.Pforan("σ:  neo = %v  lin = %v\n", σ_neo, σ_lin)
+	chk.Scalar(tst, "uy", 1e-5, uy_neo, uy_lin)
+	chk.Vector(tst, "σ", 1e-5, σ_neo, σ_lin)
+}
@@ -0,0 +1,249 @@
+// Copyright 2015 Dorival Pedroso and Raul Durand. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fem
+
+import (
+	"testing"
+
+	"github.com/cpmech/gofem/ana"
+	"github.com/cpmech/gofem/inp"
+	"github.com/cpmech/gosl/chk"
+	"github.com/cpmech/gosl/fun"
+	"github.com/cpmech/gosl/io"
+	"github.com/cpmech/gosl/la"
+)
+
+// linsol_check solves A.x = b with an iterative solver and compares x with the given solution
+//  Note: each entry of A is added to the triplet in two halves in order to check the sum of duplicates
+func linsol_check(tst *testing.T, method, precond string, A [][]float64, x []float64, blocks [][]int) {
+	n := len(x)
+	var t Triplet
+	t.Init(n, n, 2*n*n, false, true)
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			if A[i][j] != 0 {
+				t.Put(i, j, A[i][j]/2.0)
+				t.Put(i, j, A[i][j]/2.0)
+			}
+		}
+	}
+	b := make([]float64, n)
+	la.MatVecMul(b, 1, A, x)
+	var dat inp.LinSolData
+	dat.SetDefault()
+	dat.Name, dat.Precond = method, precond
+	ls := NewLinSolIter(&dat)
+	ls.SetBlocks(blocks)
+	ls.SetTriplet(&t)
+	err := ls.InitR(&t.Triplet, false, false, false)
+	if err != nil {
+		tst.Errorf("InitR failed:\n%v", err)
+		return
+	}
+	err = ls.Fact()
+	if err != nil {
+		tst.Errorf("Fact failed:\n%v", err)
+		return
+	}
+	xnum := make([]float64, n)
+	err = ls.SolveR(xnum, b, false)
+	if err != nil {
+		tst.Errorf("SolveR failed:\n%v", err)
+		return
+	}
+	io.Pforan("%8s + %-6s: nit = %2d, res = %g\n", method, precond, ls.NumIt, ls.Res)
+	chk.Vector(tst, io.Sf("x (%s+%s)", method, precond), 1e-9, xnum, x)
+}
+
+func Test_linsol01(tst *testing.T) {
+
+	//verbose()
+	chk.PrintTitle("linsol01. iterative solvers: symmetric positive-definite matrix")
+
+	A := [][]float64{
+		{4, -1, 0, 0, -1},
+		{-1, 4, -1, 0, 0},
+		{0, -1, 4, -1, 0},
+		{0, 0, -1, 4, -1},
+		{-1, 0, 0, -1, 4},
+	}
+	x := []float64{1, 2, 3, 4, 5}
+	blocks := [][]int{{0, 1}, {2, 3}, {4}}
+	for _, method := range []string{"cg", "gmres", "bicgstab"} {
+		for _, precond := range []string{"none", "jacobi", "ilu0", "block"} {
+			linsol_check(tst, method, precond, A, x, blocks)
+		}
+	}
+}
+
+func Test_linsol02(tst *testing.T) {
+
+	//verbose()
+	chk.PrintTitle("linsol02. iterative solvers: unsymmetric matrix with Lagrange multipliers")
+
+	// Kb = [[K, Aᵀ], [A, 0]] with constraints: x1 = 0.5 and x2 - x3 = -2
+	Kb := [][]float64{
+		{5, -2, 0, 1, 0, 0},
+		{-1, 6, -2, 0, 1, 0},
+		{0, -3, 7, -1, 0, 1},
+		{2, 0, -1, 4, 0, -1},
+		{0, 1, 0, 0, 0, 0},
+		{0, 0, 1, -1, 0, 0},
+	}
+	x := []float64{1, 0.5, -1, 1, 2, -3}
+	blocks := [][]int{{0, 1}, {2, 3}}
+	for _, method := range []string{"gmres", "bicgstab"} {
+		for _, precond := range []string{"none", "jacobi", "ilu0", "block"} {
+			linsol_check(tst, method, precond, Kb, x, blocks)
+		}
+	}
+
+	// CG cannot be used with Lagrange multipliers
+	var t Triplet
+	t.Init(2, 2, 3, false, true)
+	t.Put(0, 0, 1)
+	t.Put(0, 1, 1)
+	t.Put(1, 0, 1)
+	var dat inp.LinSolData
+	dat.SetDefault()
+	dat.Name = "cg"
+	ls := NewLinSolIter(&dat)
+	ls.SetTriplet(&t)
+	err := ls.InitR(&t.Triplet, true, false, false)
+	if err != nil {
+		tst.Errorf("InitR failed:\n%v", err)
+		return
+	}
+	err = ls.Fact()
+	if err == nil {
+		tst.Errorf("Fact should have failed with CG and Lagrange multipliers\n")
+	}
+}
+
+func Test_linsol03(tst *testing.T) {
+
+	//verbose()
+	chk.PrintTitle("linsol03. ini stress free square with GMRES and ILU(0)")
+
+	// fem
+	analysis := NewFEM("data/square01it.sim", "", true, false, false, false, chk.Verbose, 0)
+
+	// run simulation
+	err := analysis.Run()
+	if err != nil {
+		tst.Errorf("Run failed\n%v", err)
+		return
+	}
+
+	// solution
+	dom := analysis.Domains[0]
+	var sol ana.CteStressPstrain
+	sol.Init(fun.Prms{
+		&fun.Prm{N: "qnH", V: -50},
+		&fun.Prm{N: "qnV", V: -100},
+	})
+
+	// check displacements
+	t := dom.Sol.T
+	tolu := 1e-9
+	for _, n := range dom.Nodes {
+		eqx := n.GetEq("ux")
+		eqy := n.GetEq("uy")
+		u := []float64{dom.Sol.Y[eqx], dom.Sol.Y[eqy]}
+		io.Pfyel("u = %v\n", u)
+		sol.CheckDispl(tst, t, u, n.Vert.C, tolu)
+	}
+}
+
+func Test_linsol04(tst *testing.T) {
+
+	//verbose()
+	chk.PrintTitle("linsol04. triplet with readable entries")
+
+	// K and A
+	var K, A Triplet
+	K.Init(3, 3, 20, true, true)
+	K.Put(0, 0, 4)
+	K.Put(0, 1, 1)
+	K.Put(1, 0, 1)
+	K.Put(1, 1, 3)
+	A.Init(1, 2, 2, true, true)
+	A.Put(0, 1, 1)
+
+	// Kb = [[K, tr(A)], [A, 0]]
+	K.PutMatAndMatT(&A)
+	chk.IntAssert(K.Len(), 6)
+	linsol_check_triplet(tst, "Kb", &K, [][]float64{
+		{4, 1, 0},
+		{1, 3, 1},
+		{0, 1, 0},
+	})
+
+	// eliminate row and column 0
+	K.Filter(func(i, j int) bool { return i != 0 && j != 0 })
+	K.Put(0, 0, 1)
+	chk.IntAssert(K.Len(), 4)
+	linsol_check_triplet(tst, "Kb(eliminated)", &K, [][]float64{
+		{1, 0, 0},
+		{0, 3, 1},
+		{0, 1, 0},
+	})
+
+	// restart
+	K.Start()
+	chk.IntAssert(len(K.I), 0)
+	chk.IntAssert(K.Len(), 0)
+
+	// direct only: entries are not kept
+	var D Triplet
+	D.Init(2, 2, 4, true, false)
+	D.Put(0, 0, 1)
+	D.Put(1, 1, 2)
+	chk.IntAssert(D.Len(), 2)
+	if D.I != nil || D.Readable() {
+		tst.Errorf("direct-only triplet must not keep entries\n")
+		return
+	}
+
+	// readable only: la.Triplet is not used
+	var R Triplet
+	R.Init(2, 2, 4, false, true)
+	R.Put(0, 0, 1)
+	R.Put(1, 1, 2)
+	chk.IntAssert(len(R.I), 2)
+	chk.IntAssert(R.Len(), 0)
+
+	// iterative linear solver requires readable triplet
+	var dat inp.LinSolData
+	dat.SetDefault()
+	dat.Name = "gmres"
+	ls := NewLinSolIter(&dat)
+	ls.SetTriplet(&D)
+	err := ls.InitR(&D.Triplet, false, false, false)
+	if err == nil {
+		tst.Errorf("InitR should have failed with direct-only triplet\n")
+		return
+	}
+	io.Pforan("err = %v\n", err)
+}
+
+// linsol_check_triplet compares the entries of a triplet and its la.Triplet with a dense matrix
+func linsol_check_triplet(tst *testing.T, msg string, t *Triplet, a [][]float64) {
+	m, n := t.Size()
+	b := la.MatAlloc(m, n)
+	for k := 0; k < len(t.I); k++ {
+		b[t.I[k]][t.J[k]] += t.X[k]
+	}
+	chk.Matrix(tst, msg, 1e-15, b, a)
+	x := make([]float64, n)
+	for i := 0; i < n; i++ {
+		x[i] = float64(i + 1)
+	}
+	y := make([]float64, m)
+	ycor := make([]float64, m)
+	la.SpMatVecMul(y, 1, t.ToMatrix(nil), x)
+	la.MatVecMul(ycor, 1, a, x)
+	chk.Vector(tst, msg+".x", 1e-15, y, ycor)
+}
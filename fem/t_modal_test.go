@@ -0,0 +1,94 @@
+// Copyright 2015 Dorival Pedroso and Raul Durand. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fem
+
+import (
+	"math"
+	"testing"
+
+	"github.com/cpmech/gosl/chk"
+	"github.com/cpmech/gosl/io"
+)
+
+func Test_modal01(tst *testing.T) {
+
+	/* simply supported beam with pinned ends (10 elements)
+	 *
+	 *   bending: ωn = (n.π)² sqrt(E.I/(ρ.A.L⁴))
+	 *   axial:   ωn = n.π/L sqrt(E/ρ)
+	 *
+	 *   with E=100, A=0.01, I=1e-4, ρ=1 and L=1 => ω1(bending) = π², ω1(axial) = 10π
+	 */
+
+	//verbose()
+	chk.PrintTitle("modal01. simply supported beam")
+
+	// run simulation
+	analysis := NewFEM("data/beam10emodal.sim", "", true, true, false, false, chk.Verbose, 0)
+	err := analysis.Run()
+	if err != nil {
+		tst.Errorf("Run failed:\n%v", err)
+		return
+	}
+
+	// check frequencies
+	f := analysis.Summary.Freqs
+	io.Pforan("f = %v\n", f)
+	chk.IntAssert(len(f), 5)
+	chk.Scalar(tst, "f1 (bending)", 1e-4, f[0], math.Pi/2.0)
+	chk.Scalar(tst, "f1 (axial)  ", 5e-2, f[1], 5.0)
+	chk.Scalar(tst, "f2 (bending)", 2e-3, f[2], 2.0*math.Pi)
+
+	// check output times
+	chk.Vector(tst, "mode numbers", 1e-15, analysis.Summary.OutTimes, []float64{1, 2, 3, 4, 5})
+
+	// check first mode shape: uy = sin(π.x/L)
+	dom := analysis.Domains[0]
+	sol := analysis.Solver.(*SolverModal)
+	φ := sol.Φ[0]
+	φmid := φ[dom.Vid2node[5].GetEq("uy")]
+	for _, v := range dom.Msh.Verts {
+		eq := dom.Vid2node[v.Id].GetEq("uy")
+		chk.Scalar(tst, io.Sf("φ(x=%g)", v.C[0]), 1e-10, φ[eq]/φmid, math.Sin(math.Pi*v.C[0]))
+	}
+}
+
+func Test_buckling01(tst *testing.T) {
+
+	/* Euler column with pinned ends (10 elements) under unit axial compressive load
+	 *
+	 *   Pcr = (n.π)² E.I / L²
+	 *
+	 *   with E=100, I=1e-4 and L=1 => Pcr = 0.01 π²
+	 */
+
+	//verbose()
+	chk.PrintTitle("buckling01. Euler column")
+
+	// run simulation
+	analysis := NewFEM("data/beam10ebuck.sim", "", true, true, false, false, chk.Verbose, 0)
+	err := analysis.Run()
+	if err != nil {
+		tst.Errorf("Run failed:\n%v", err)
+		return
+	}
+
+	// check critical load factors
+	λ := analysis.Summary.CritFactors
+	Pcr := 0.01 * math.Pi * math.Pi
+	io.Pforan("λcr = %v (Pcr = %v)\n", λ, Pcr)
+	chk.IntAssert(len(λ), 2)
+	chk.Scalar(tst, "λcr1", 1e-5, λ[0], Pcr)
+	chk.Scalar(tst, "λcr2", 2e-4, λ[1], 4.0*Pcr)
+
+	// check first buckling mode: uy = sin(π.x/L)
+	dom := analysis.Domains[0]
+	φ := analysis.Solver.(*SolverBuckling).Φ[0]
+	φmid := φ[dom.Vid2node[5].GetEq("uy")]
+	for _, v := range dom.Msh.Verts {
+		eq := dom.Vid2node[v.Id].GetEq("uy")
+		chk.Scalar(tst, io.Sf("φ(x=%g)", v.C[0]), 1e-8, φ[eq]/φmid, math.Sin(math.Pi*v.C[0]))
+	}
+}
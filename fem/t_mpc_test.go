@@ -0,0 +1,105 @@
+// Copyright 2015 Dorival Pedroso and Raul Durand. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fem
+
+import (
+	"testing"
+
+	"github.com/cpmech/gofem/ana"
+	"github.com/cpmech/gosl/chk"
+	"github.com/cpmech/gosl/fun"
+	"github.com/cpmech/gosl/io"
+)
+
+func Test_mpc01(tst *testing.T) {
+
+	//verbose()
+	chk.PrintTitle("mpc01. two disconnected squares tied by multi-point constraints")
+
+	// fem
+	analysis := NewFEM("data/twoqua4mpc.sim", "", true, false, false, false, chk.Verbose, 0)
+
+	// run simulation
+	err := analysis.Run()
+	if err != nil {
+		tst.Errorf("Run failed\n%v", err)
+		return
+	}
+
+	// domain
+	dom := analysis.Domains[0]
+	io.Pf("%v\n", dom.EssenBcs.List(dom.Sol.T))
+
+	// constraints: 4 (uy @ bottom) + 2 (ux @ left) + 3 mpcs
+	chk.IntAssert(dom.Nlam, 9)
+	nmpc := 0
+	for _, bc := range dom.EssenBcs.Bcs {
+		if bc.Key == "mpc" {
+			chk.Vector(tst, "mpc coefficients", 1e-17, bc.ValsA, []float64{1, -1})
+			nmpc++
+		}
+	}
+	chk.IntAssert(nmpc, 3)
+
+	// solution
+	var sol ana.CteStressPstrain
+	sol.Init(fun.Prms{
+		&fun.Prm{N: "qnH", V: -50},
+		&fun.Prm{N: "qnV", V: -100},
+	})
+
+	// check displacements
+	t := dom.Sol.T
+	tolu := 1e-15
+	for _, n := range dom.Nodes {
+		eqx := n.GetEq("ux")
+		eqy := n.GetEq("uy")
+		u := []float64{dom.Sol.Y[eqx], dom.Sol.Y[eqy]}
+		io.Pfyel("u = %v\n", u)
+		sol.CheckDispl(tst, t, u, n.Vert.C, tolu)
+	}
+
+	// check stresses
+	tols := 1e-13
+	for _, ele := range dom.Elems {
+		e := ele.(*ElemU)
+		for idx, ip := range e.IpsElem {
+			x := e.Cell.Shp.IpRealCoords(e.X, ip)
+			σ := e.States[idx].Sig
+			io.Pforan("σ = %v\n", σ)
+			sol.CheckStress(tst, t, σ, x, tols)
+		}
+	}
+}
+
+func Test_mpc02(tst *testing.T) {
+
+	//verbose()
+	chk.PrintTitle("mpc02. invalid multi-point constraints")
+
+	// fem
+	analysis := NewFEM("data/twoqua4mpc.sim", "", true, false, false, false, chk.Verbose, 0)
+	mpc := analysis.Sim.Stages[0].Mpcs[0]
+
+	// invalid key
+	mpc.Keys[1] = "pl"
+	if analysis.SetStage(0) == nil {
+		tst.Errorf("SetStage should have failed with invalid dof key\n")
+	}
+	mpc.Keys[1] = "ux"
+
+	// inconsistent number of coefficients
+	mpc.Coefs = []float64{1}
+	if analysis.SetStage(0) == nil {
+		tst.Errorf("SetStage should have failed with inconsistent number of coefficients\n")
+	}
+	mpc.Coefs = []float64{1, -1}
+
+	// tag without vertices
+	mpc.Tags[0] = -999
+	if analysis.SetStage(0) == nil {
+		tst.Errorf("SetStage should have failed with invalid tag\n")
+	}
+}
@@ -5,12 +5,15 @@
 package fem
 
 import (
+	"math"
 	"sort"
 	"testing"
 
 	"github.com/cpmech/gosl/chk"
 	"github.com/cpmech/gosl/io"
 	"github.com/cpmech/gosl/utl"
+
+	"github.com/cpmech/gofem/mporous"
 )
 
 func Test_p01a(tst *testing.T) {
@@ -164,6 +167,85 @@ func Test_p01b(tst *testing.T) {
 	// TODO: add check here
 }
 
+func Test_p01ad(tst *testing.T) {
+
+	//verbose()
+	chk.PrintTitle("p01ad. automatic time stepping")
+
+	// reference solution: fixed Δt
+	ref := NewFEM("data/p01ad.sim", "", true, false, false, false, chk.Verbose, 0)
+	ref.Sim.Solver.Adapt = false
+	_, refΔt := p_record_steps(ref)
+	err := ref.Run()
+	if err != nil {
+		tst.Errorf("Run failed:\n%v", err)
+		return
+	}
+
+	// run simulation
+	analysis := NewFEM("data/p01ad.sim", "", true, true, false, false, chk.Verbose, 0)
+	T, Δt := p_record_steps(analysis)
+	err = analysis.Run()
+	if err != nil {
+		tst.Errorf("Run failed:\n%v", err)
+		return
+	}
+
+	// check output times
+	tout := utl.LinSpace(0, 1000, 11)
+	chk.Vector(tst, "output times", 1e-15, analysis.Summary.OutTimes, tout)
+
+	// accepted steps: the next step starts at the end of an accepted step
+	var nacc, nrej int
+	var acc []float64
+	for k := 0; k < len(*T); k++ {
+		if k == len(*T)-1 || math.Abs((*T)[k+1]-(*Δt)[k+1]-(*T)[k]) < 1e-10 {
+			nacc++
+			acc = append(acc, (*Δt)[k])
+		} else {
+			nrej++
+		}
+	}
+	io.Pforan("number of steps: fixed = %d, adaptive = %d (rejected = %d)\n", len(*refΔt), nacc, nrej)
+	if nacc == len(*refΔt) {
+		tst.Errorf("number of accepted steps must differ from the one with fixed Δt")
+		return
+	}
+	chk.Scalar(tst, "final time", 1e-10, (*T)[len(*T)-1], 1000)
+
+	// Δt must grow and shrink
+	var grows, shrinks bool
+	for k := 1; k < len(acc); k++ {
+		if acc[k] > acc[k-1]+1e-10 {
+			grows = true
+		}
+		if acc[k] < acc[k-1]-1e-10 {
+			shrinks = true
+		}
+	}
+	io.Pforan("accepted Δt = %v\n", acc)
+	if !grows || !shrinks {
+		tst.Errorf("Δt must grow and shrink during automatic time stepping: grows=%v, shrinks=%v", grows, shrinks)
+		return
+	}
+
+	// final solution
+	chk.Vector(tst, "y @ tf", 1e-1, analysis.Domains[0].Sol.Y, ref.Domains[0].Sol.Y)
+}
+
+// p_record_steps records the time and time step size at the first iteration of every (accepted or
+// rejected) step through the DebugKb callback
+func p_record_steps(analysis *FEM) (T, Δt *[]float64) {
+	T, Δt = new([]float64), new([]float64)
+	analysis.DebugKb = func(d *Domain, it int) {
+		if it == 0 {
+			*T = append(*T, d.Sol.T)
+			*Δt = append(*Δt, d.Sol.Dt)
+		}
+	}
+	return
+}
+
 func Test_p02(tst *testing.T) {
 
 	//verbose()
@@ -187,3 +269,89 @@ func Test_p02(tst *testing.T) {
 		return
 	}
 }
+
+func Test_p03(tst *testing.T) {
+
+	//verbose()
+	chk.PrintTitle("p03. diffusion along column with linear implicit solver")
+
+	// set stage and initial values
+	analysis := NewFEM("data/p03.sim", "", true, true, false, false, chk.Verbose, 0)
+	plana, err := p_single_mode(analysis)
+	if err != nil {
+		tst.Errorf("p_single_mode failed:\n%v", err)
+		return
+	}
+
+	// run simulation
+	err = analysis.SolveOneStage(0, false)
+	if err != nil {
+		tst.Errorf("SolveOneStage failed:\n%v", err)
+		return
+	}
+
+	// check pl at output times
+	p_check_single_mode(tst, analysis, plana, 1e-2)
+}
+
+// p_single_mode sets the first mode of diffusion along the column as initial values and returns
+// the analytical solution pl(z,t) = A⋅sin(π⋅z/(2⋅H))⋅exp(-λ⋅t)
+//  Note: without gravity and with saturated conditions, the p-element solves Cpl⋅∂pl/∂t =
+//        klr⋅klsat⋅∂²pl/∂z² with pl = 0 at the bottom (z = 0) and no flux at the top (z = H)
+func p_single_mode(analysis *FEM) (plana func(z, t float64) float64, err error) {
+
+	// set stage and states with zero pressure
+	err = analysis.SetStage(0)
+	if err != nil {
+		return
+	}
+	err = analysis.ZeroStage(0, true)
+	if err != nil {
+		return
+	}
+
+	// coefficient of diffusion
+	dom := analysis.Domains[0]
+	e := dom.Elems[0].(*ElemP)
+	var res mporous.LsVars
+	err = e.Mdl.CalcLs(&res, e.States[0], 0, 0, false)
+	if err != nil {
+		return
+	}
+	cv := e.Mdl.Cnd.Klr(1) * e.Mdl.Klsat[1][1] / res.Cpl
+
+	// analytical solution
+	H, A := 10.0, 100.0
+	λ := cv * math.Pow(math.Pi/(2.0*H), 2.0)
+	io.Pforan("cv = %g, λ = %g\n", cv, λ)
+	plana = func(z, t float64) float64 {
+		return A * math.Sin(math.Pi*z/(2.0*H)) * math.Exp(-λ*t)
+	}
+
+	// initial values and rates
+	for _, nod := range dom.Nodes {
+		eq := nod.GetEq("pl")
+		dom.Sol.Y[eq] = plana(nod.Vert.C[1], 0)
+		dom.Sol.Dydt[eq] = -λ * dom.Sol.Y[eq]
+	}
+
+	// states corresponding to initial values
+	err = analysis.ZeroStage(0, false)
+	return
+}
+
+// p_check_single_mode compares pl at all output times with the analytical solution
+func p_check_single_mode(tst *testing.T, analysis *FEM, plana func(z, t float64) float64, tol float64) {
+	dom := analysis.Domains[0]
+	for tidx, t := range analysis.Summary.OutTimes {
+		err := dom.ReadSol(analysis.Sim.DirOut, analysis.Sim.Key, analysis.Sim.EncType, tidx)
+		if err != nil {
+			tst.Errorf("ReadSol failed:\n%v", err)
+			return
+		}
+		for _, nod := range dom.Nodes {
+			z := nod.Vert.C[1]
+			chk.Scalar(tst, io.Sf("pl(z=%g,t=%g)", z, t), tol, dom.Sol.Y[nod.GetEq("pl")], plana(z, t))
+		}
+	}
+}
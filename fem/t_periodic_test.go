@@ -0,0 +1,160 @@
+// Copyright 2015 Dorival Pedroso and Raul Durand. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fem
+
+import (
+	"math"
+	"testing"
+
+	"github.com/cpmech/gosl/chk"
+	"github.com/cpmech/gosl/io"
+)
+
+// periodic_laminate holds the analytical solution of a plane-strain laminate with layers normal to y
+type periodic_laminate struct {
+	y0 []float64 // [nlay] bottom of layers
+	h  []float64 // [nlay] thicknesses
+	f  []float64 // [nlay] volume fractions
+	λ  []float64 // [nlay] Lamé's first parameter
+	μ  []float64 // [nlay] shear modulus
+}
+
+// init initialises laminate from thicknesses and elastic parameters
+func (o *periodic_laminate) init(h, E, ν []float64) {
+	var ytop, htot float64
+	for _, hk := range h {
+		htot += hk
+	}
+	for k, hk := range h {
+		o.y0 = append(o.y0, ytop)
+		o.h = append(o.h, hk)
+		o.f = append(o.f, hk/htot)
+		o.λ = append(o.λ, E[k]*ν[k]/((1.0+ν[k])*(1.0-2.0*ν[k])))
+		o.μ = append(o.μ, E[k]/(2.0*(1.0+ν[k])))
+		ytop += hk
+	}
+}
+
+// solve computes layer strains εyy and the average stress (Mandel components)
+// due to the macroscopic strain (tensor components) εxx, εyy, εxy
+func (o *periodic_laminate) solve(εxx, εyy, εxy float64) (eyy []float64, σ []float64) {
+	var sumfM, sumfλM, sumf2μ float64
+	for k, f := range o.f {
+		M := o.λ[k] + 2.0*o.μ[k]
+		sumfM += f / M
+		sumfλM += f * o.λ[k] / M
+		sumf2μ += f / (2.0 * o.μ[k])
+	}
+	S := (εyy + εxx*sumfλM) / sumfM // σyy: the same in all layers
+	τ := εxy / sumf2μ               // σxy: the same in all layers
+	eyy = make([]float64, len(o.f))
+	σ = make([]float64, 4)
+	for k, f := range o.f {
+		λ, M := o.λ[k], o.λ[k]+2.0*o.μ[k]
+		eyy[k] = (S - λ*εxx) / M
+		σ[0] += f * (M*εxx + λ*eyy[k])
+		σ[1] += f * S
+		σ[2] += f * λ * (εxx + eyy[k])
+		σ[3] += f * τ * math.Sqrt2
+	}
+	return
+}
+
+func Test_periodic01(tst *testing.T) {
+
+	//verbose()
+	chk.PrintTitle("periodic01. two-layer RVE with periodic boundary conditions")
+
+	// fem
+	analysis := NewFEM("data/rve2lay.sim", "", true, false, false, false, chk.Verbose, 0)
+
+	// run simulation
+	err := analysis.Run()
+	if err != nil {
+		tst.Errorf("Run failed\n%v", err)
+		return
+	}
+
+	// domain
+	dom := analysis.Domains[0]
+	io.Pf("%v\n", dom.EssenBcs.List(dom.Sol.T))
+
+	// constraints: 2 (fixed corner) + 5 periodic vertices × 2
+	chk.IntAssert(dom.Nlam, 12)
+
+	// analytical solution
+	var sol periodic_laminate
+	sol.init([]float64{0.3, 0.7}, []float64{1000, 20000}, []float64{0.25, 0.2})
+	εxx := 0.01
+	eyy, _ := sol.solve(εxx, 0, 0)
+
+	// check displacements
+	for _, nod := range dom.Nodes {
+		x, y := nod.Vert.C[0], nod.Vert.C[1]
+		var uy float64
+		for k, y0 := range sol.y0 {
+			if y > y0 {
+				uy += eyy[k] * (math.Min(y, y0+sol.h[k]) - y0)
+			}
+		}
+		chk.Scalar(tst, io.Sf("ux @ nod %d", nod.Vert.Id), 1e-14, dom.Sol.Y[nod.GetEq("ux")], εxx*x)
+		chk.Scalar(tst, io.Sf("uy @ nod %d", nod.Vert.Id), 1e-14, dom.Sol.Y[nod.GetEq("uy")], uy)
+	}
+
+	// invalid data
+	stg := analysis.Sim.Stages[0]
+	stg.Periodic.Eps = []float64{0.01, 0}
+	if analysis.SetStage(0) == nil {
+		tst.Errorf("SetStage should have failed with invalid macroscopic strain\n")
+	}
+	stg.Periodic.Eps = nil
+	stg.Periodic.Pairs[0][1] = -999
+	if analysis.SetStage(0) == nil {
+		tst.Errorf("SetStage should have failed with invalid face tag\n")
+	}
+	stg.Periodic.Pairs[0][1] = -10
+	if analysis.SetStage(0) == nil {
+		tst.Errorf("SetStage should have failed with non-matching faces\n")
+	}
+}
+
+func Test_homog01(tst *testing.T) {
+
+	//verbose()
+	chk.PrintTitle("homog01. effective stiffness of two-layer RVE")
+
+	// fem
+	analysis := NewFEM("data/rve2lay.sim", "", true, false, false, false, chk.Verbose, 0)
+
+	// homogenisation
+	δ := 1e-3
+	C, err := analysis.Homogenize(0, δ)
+	if err != nil {
+		tst.Errorf("Homogenize failed\n%v", err)
+		return
+	}
+
+	// analytical solution
+	var sol periodic_laminate
+	sol.init([]float64{0.3, 0.7}, []float64{1000, 20000}, []float64{0.25, 0.2})
+	Cana := make([][]float64, 4)
+	for i := 0; i < 4; i++ {
+		Cana[i] = make([]float64, 4)
+	}
+	for j, ε := range [][]float64{{δ, 0, 0}, {0, δ, 0}, nil, {0, 0, δ / math.Sqrt2}} {
+		if ε == nil {
+			continue
+		}
+		_, σ := sol.solve(ε[0], ε[1], ε[2])
+		for i := 0; i < 4; i++ {
+			Cana[i][j] = σ[i] / δ
+		}
+	}
+	io.Pforan("Cana = %v\n", Cana)
+	chk.Matrix(tst, "C", 1e-9, C, Cana)
+
+	// macroscopic strain is restored
+	chk.Vector(tst, "eps", 1e-15, analysis.Sim.Stages[0].Periodic.Eps, []float64{0.01, 0, 0, 0})
+}
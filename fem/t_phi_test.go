@@ -118,6 +118,13 @@ func Test_phi02(tst *testing.T) {
 		// chk.PrintTitle(io.Sf("%25.10e%25.10e", dom.Sol.Y[v], d+1.0))
 		chk.Scalar(tst, "h @ nod "+string(i), 2e-2, dom.Sol.Y[v], d+1.0)
 	}
+
+	// default velocity: no velocity is given in phi02.sim
+	for _, dat := range dom.Elems[0].OutIpsData() {
+		vals := dat.Calc(dom.Sol)
+		chk.Scalar(tst, "vx", 1e-15, vals["vx"], 1)
+		chk.Scalar(tst, "vy", 1e-15, vals["vy"], 0)
+	}
 }
 
 func Test_phi03(tst *testing.T) {
@@ -149,3 +156,154 @@ func Test_phi03(tst *testing.T) {
 		chk.Scalar(tst, "h @ nod "+string(i), 5e-2, dom.Sol.Y[v], dom.Msh.Verts[node[i]].C[0])
 	}
 }
+
+func Test_phi04(tst *testing.T) {
+
+	//verbose()
+	chk.PrintTitle("phi04 - Transport with time-dependent speed and source")
+
+	// run simulation
+	analysis := NewFEM("data/phi04.sim", "", true, false, false, false, chk.Verbose, 0)
+	err := analysis.Run()
+	if err != nil {
+		tst.Errorf("Run failed:\n%v", err)
+		return
+	}
+
+	// domain
+	dom := analysis.Domains[0]
+
+	// check results: v = [t, 0] => displacement = 0.5 @ t = 1; s = 0.2 => φ += 0.2
+	eq := []int{
+		30, 50, 70, 80, 90,
+	}
+	node := []int{
+		15, 25, 35, 40, 45,
+	}
+	for i, v := range eq {
+		x := []float64{dom.Msh.Verts[node[i]].C[0] - 0.5, dom.Msh.Verts[node[i]].C[1]}
+		xc := []float64{0.0, 0.025}
+		r := 1.0
+		d := (x[0]-xc[0])*(x[0]-xc[0]) + (x[1]-xc[1])*(x[1]-xc[1])
+		d = math.Sqrt(d) - r
+		chk.Scalar(tst, io.Sf("h @ nod %d", node[i]), 1e-2, dom.Sol.Y[v], d+0.2)
+	}
+}
+
+func Test_phi05(tst *testing.T) {
+
+	//verbose()
+	chk.PrintTitle("phi05 - Transport with seepage velocity")
+
+	// run simulation
+	analysis := NewFEM("data/phi05.sim", "", true, false, false, false, chk.Verbose, 0)
+	err := analysis.Run()
+	if err != nil {
+		tst.Errorf("Run failed:\n%v", err)
+		return
+	}
+
+	// domain
+	dom := analysis.Domains[0]
+
+	// phi-elements (cells 4 to 7) get velocity from p-elements with the same vertices (cells 0 to 3)
+	v := make([]float64, 2)
+	for cid := 4; cid < 8; cid++ {
+		phi := dom.Cid2elem[cid].(*ElemPhi)
+		pel := dom.Cid2elem[cid-4].(*ElemP)
+		if phi.Vsrc != pel {
+			tst.Errorf("velocity of phi-element %d must be computed by p-element %d", cid, cid-4)
+			return
+		}
+		for idx, dat := range phi.OutIpsData() {
+			err = pel.IpVelocity(v, idx, dom.Sol)
+			if err != nil {
+				tst.Errorf("IpVelocity failed:\n%v", err)
+				return
+			}
+			vals := dat.Calc(dom.Sol)
+			io.Pforan("cell %d, ip %d: v = %v\n", cid, idx, v)
+			chk.Scalar(tst, "vx", 1e-15, vals["vx"], v[0])
+			chk.Scalar(tst, "vy", 1e-15, vals["vy"], v[1])
+			if v[1] >= 0 {
+				tst.Errorf("liquid must flow downwards. vy = %g", v[1])
+				return
+			}
+		}
+	}
+}
+
+func Test_phi06(tst *testing.T) {
+
+	//verbose()
+	chk.PrintTitle("phi06 - Transport constant Speed with SUPG and implicit solver")
+
+	// run simulation
+	analysis := NewFEM("data/phi06.sim", "", true, false, false, false, chk.Verbose, 0)
+	err := analysis.Run()
+	if err != nil {
+		tst.Errorf("Run failed:\n%v", err)
+		return
+	}
+
+	// domain
+	dom := analysis.Domains[0]
+	if !dom.Elems[0].(*ElemPhi).Supg {
+		tst.Errorf("SUPG must be used by default")
+		return
+	}
+
+	// check results
+	eq := []int{
+		30, 50, 70, 80, 90,
+	}
+	node := []int{
+		15, 25, 35, 40, 45,
+	}
+	for i, v := range eq {
+		x := []float64{dom.Msh.Verts[node[i]].C[0], dom.Msh.Verts[node[i]].C[1]}
+		xc := []float64{0.0, 0.025}
+		r := 1.0
+		d := (x[0]-xc[0])*(x[0]-xc[0]) + (x[1]-xc[1])*(x[1]-xc[1])
+		d = math.Sqrt(d) - r
+		chk.Scalar(tst, io.Sf("h @ nod %d", node[i]), 2e-2, dom.Sol.Y[v], d+1.0)
+	}
+}
+
+func Test_phi07(tst *testing.T) {
+
+	//verbose()
+	chk.PrintTitle("phi07 - Reinitialisation with implicit solver")
+
+	// run simulation
+	analysis := NewFEM("data/phi07.sim", "", true, false, false, false, chk.Verbose, 0)
+	err := analysis.Run()
+	if err != nil {
+		tst.Errorf("Run failed:\n%v", err)
+		return
+	}
+
+	// domain
+	dom := analysis.Domains[0]
+
+	// check results: signed distance
+	eq := []int{
+		0, 20, 54,
+	}
+	node := []int{
+		0, 10, 27,
+	}
+	for i, v := range eq {
+		chk.Scalar(tst, io.Sf("h @ nod %d", node[i]), 5e-2, dom.Sol.Y[v], dom.Msh.Verts[node[i]].C[0])
+	}
+
+	// reinitialisation cannot be solved with the linear implicit solver
+	phi := dom.Elems[0].(*ElemPhi)
+	phi.linimp = true
+	err = phi.SetEleConds("reinit", nil, "")
+	if err == nil {
+		tst.Errorf("SetEleConds should have failed with lin-imp\n")
+		return
+	}
+	io.Pforan("err = %v\n", err)
+}
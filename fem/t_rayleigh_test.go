@@ -0,0 +1,200 @@
+// Copyright 2015 Dorival Pedroso and Raul Durand. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fem
+
+import (
+	"math"
+	"testing"
+
+	"github.com/cpmech/gosl/chk"
+	"github.com/cpmech/gosl/fun"
+	"github.com/cpmech/gosl/io"
+)
+
+func Test_rayleigh01(tst *testing.T) {
+
+	//verbose()
+	chk.PrintTitle("rayleigh01. coefficients")
+
+	// directly given
+	prms := fun.Prms{&fun.Prm{N: "RayA", V: 0.5}, &fun.Prm{N: "RayB", V: 0.01}}
+	ray, err := GetRayleigh(prms, "")
+	if err != nil {
+		tst.Errorf("GetRayleigh failed:\n%v", err)
+		return
+	}
+	chk.Scalar(tst, "α", 1e-15, ray.A, 0.5)
+	chk.Scalar(tst, "β", 1e-15, ray.B, 0.01)
+
+	// element data overrides material data
+	ray, err = GetRayleigh(prms, "!rayB:0.02")
+	if err != nil {
+		tst.Errorf("GetRayleigh failed:\n%v", err)
+		return
+	}
+	chk.Scalar(tst, "α", 1e-15, ray.A, 0.5)
+	chk.Scalar(tst, "β", 1e-15, ray.B, 0.02)
+
+	// from damping ratio and target frequencies: ξ(ω) = α/(2ω) + β.ω/2 must equal ξ at ω1 and ω2
+	ray, err = GetRayleigh(nil, "!rayXi:0.05 !rayF1:1 !rayF2:10")
+	if err != nil {
+		tst.Errorf("GetRayleigh failed:\n%v", err)
+		return
+	}
+	io.Pforan("α=%v β=%v\n", ray.A, ray.B)
+	for _, f := range []float64{1, 10} {
+		ω := 2.0 * math.Pi * f
+		chk.Scalar(tst, io.Sf("ξ(f=%g)", f), 1e-15, ray.A/(2.0*ω)+ray.B*ω/2.0, 0.05)
+	}
+
+	// invalid data
+	_, err = GetRayleigh(nil, "!rayXi:0.05 !rayF1:1")
+	if err == nil {
+		tst.Errorf("GetRayleigh should have failed with missing f2\n")
+	}
+}
+
+func Test_rayleigh02(tst *testing.T) {
+
+	/* damped rod with one fixed end and suddenly applied axial load P at the other end
+	 *
+	 *    ▷0------------1 → P     E = 1, A = 1, L = 1, ρ = 3
+	 *
+	 *    with consistent mass m = ρ.A.L/3 = 1, k = E.A/L = 1 and Rayleigh damping
+	 *    α = 0.1 (element data) and β = 0.1 (material data) => c = α.m + β.k = 0.2; ξ = 0.1
+	 *
+	 *         u(t) = P/k . (1 - exp(-ξ.ω.t) (cos(ωd.t) + ξ/sqrt(1-ξ²) sin(ωd.t)))
+	 */
+
+	//verbose()
+	chk.PrintTitle("rayleigh02. damped rod under suddenly applied load")
+
+	// analytical solution
+	P, k, ω, ξ := 1.0, 1.0, 1.0, 0.1
+	rayleigh_check_history(tst, "data/rod01ray.sim", 1, "ux", 5e-3, rayleigh_sdof_step(P/k, ω, ξ))
+}
+
+func Test_rayleigh03(tst *testing.T) {
+
+	/* damped unit square with bottom fixed and suddenly applied vertical loads P at the top nodes
+	 *
+	 *    3 ↑P   2 ↑P     E = 1, ν = 0, ρ = 3; ux = 0 everywhere
+	 *     +-----+
+	 *     |     |        with uy = u.y: m = ρ.(4+2)/36 = 0.5 and k = E/2 = 0.5 per top node
+	 *     |     |        and Rayleigh damping α = β = 0.1 => c = α.m + β.k = 0.1; ξ = 0.1
+	 *     +-----+
+	 *    0 ▲    1 ▲
+	 */
+
+	//verbose()
+	chk.PrintTitle("rayleigh03. damped solid element")
+
+	// analytical solution
+	P, k, ω, ξ := 0.5, 0.5, 1.0, 0.1
+	rayleigh_check_history(tst, "data/qua4ray.sim", 2, "uy", 5e-3, rayleigh_sdof_step(P/k, ω, ξ))
+}
+
+func Test_rayleigh04(tst *testing.T) {
+
+	/* same as rayleigh03 with a porous element and prescribed liquid pressure (pl = 0). The
+	 * mixture density is ρ = nf.ρL + (1-nf).ρS = 3 and the load is small; thus the change of
+	 * porosity with the volumetric strain can be neglected
+	 */
+
+	//verbose()
+	chk.PrintTitle("rayleigh04. damped porous element")
+
+	// analytical solution
+	P, k, ω, ξ := 5e-7, 0.5, 1.0, 0.1
+	rayleigh_check_history(tst, "data/qua4upray.sim", 2, "uy", 5e-3*P/k, rayleigh_sdof_step(P/k, ω, ξ))
+}
+
+func Test_rayleigh05(tst *testing.T) {
+
+	/* damped beam with clamped end and suddenly applied axial load P at the other end
+	 *
+	 *    |0============1 → P     E = 1, A = 1, L = 1, ρ = 3
+	 *
+	 *    the axial and bending dofs are uncoupled; thus m = ρ.A.L/3 = 1, k = E.A/L = 1 and
+	 *    α = β = 0.1 => c = α.m + β.k = 0.2; ξ = 0.1
+	 */
+
+	//verbose()
+	chk.PrintTitle("rayleigh05. damped beam")
+
+	// analytical solution
+	P, k, ω, ξ := 1.0, 1.0, 1.0, 0.1
+	rayleigh_check_history(tst, "data/beam01ray.sim", 1, "ux", 5e-3, rayleigh_sdof_step(P/k, ω, ξ))
+}
+
+func Test_rayleigh06(tst *testing.T) {
+
+	/* damped rod without inertia; i.e. the rod element does not consider the mass matrix in
+	 * transient simulations and with C = β.K0, the solution is
+	 *
+	 *         u(t) = P/k . (1 - exp(-t/β))
+	 */
+
+	//verbose()
+	chk.PrintTitle("rayleigh06. damped rod without inertia")
+
+	// analytical solution
+	P, k, β := 1.0, 1.0, 0.1
+	uana := func(t float64) float64 {
+		return P * (1.0 - math.Exp(-t/β)) / k
+	}
+	rayleigh_check_history(tst, "data/rod02ray.sim", 1, "ux", 5e-3, uana)
+}
+
+// rayleigh_sdof_step returns the response of a damped single-degree-of-freedom system to a
+// suddenly applied load
+//  Input:
+//   ustat -- static displacement P/k
+//   ω     -- natural frequency
+//   ξ     -- damping ratio
+func rayleigh_sdof_step(ustat, ω, ξ float64) func(t float64) float64 {
+	ωd := ω * math.Sqrt(1.0-ξ*ξ)
+	return func(t float64) float64 {
+		return ustat * (1.0 - math.Exp(-ξ*ω*t)*(math.Cos(ωd*t)+ξ*math.Sin(ωd*t)/math.Sqrt(1.0-ξ*ξ)))
+	}
+}
+
+// rayleigh_check_history runs a simulation and compares the displacement at a vertex at all
+// output times with the analytical solution
+//  Note: the tolerance accounts for the initial acceleration being set to zero
+func rayleigh_check_history(tst *testing.T, simfn string, vid int, key string, tol float64, uana func(t float64) float64) {
+
+	// run simulation
+	analysis := NewFEM(simfn, "", true, false, false, false, chk.Verbose, 0)
+	err := analysis.Run()
+	if err != nil {
+		tst.Errorf("Run failed:\n%v", err)
+		return
+	}
+
+	// domain for reading results
+	doms := NewDomains(analysis.Sim, analysis.DynCfs, analysis.HydSta, 0, 1, false)
+	if len(doms) == 0 {
+		tst.Errorf("NewDomains failed\n")
+		return
+	}
+	dom := doms[0]
+	err = dom.SetStage(0)
+	if err != nil {
+		tst.Errorf("SetStage failed\n%v", err)
+		return
+	}
+	eq := dom.Vid2node[vid].GetEq(key)
+
+	// check
+	for tidx, t := range analysis.Summary.OutTimes {
+		err = dom.ReadSol(analysis.Sim.DirOut, analysis.Sim.Key, analysis.Sim.EncType, tidx)
+		if err != nil {
+			tst.Errorf("ReadSol failed:\n%v", err)
+			return
+		}
+		chk.Scalar(tst, io.Sf("%s(t=%g)", key, t), tol, dom.Sol.Y[eq], uana(t))
+	}
+}
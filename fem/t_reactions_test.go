@@ -0,0 +1,158 @@
+// Copyright 2015 Dorival Pedroso and Raul Durand. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fem
+
+import (
+	"math"
+	"testing"
+
+	"github.com/cpmech/gosl/chk"
+	"github.com/cpmech/gosl/io"
+)
+
+func Test_react01(tst *testing.T) {
+
+	//verbose()
+	chk.PrintTitle("react01. reactions and support resultants")
+
+	// methods for single-point constraints
+	for _, method := range []string{"lagrange", "elim", "penalty"} {
+		io.Pforan("method = %q\n", method)
+
+		// fem
+		analysis := NewFEM("data/tie2reg.sim", "", true, false, false, false, chk.Verbose, 0)
+		analysis.Sim.Solver.Spcs = method
+
+		// run simulation
+		err := analysis.Run()
+		if err != nil {
+			tst.Errorf("Run failed\n%v", err)
+			return
+		}
+
+		// domain
+		dom := analysis.Domains[0]
+		chk.IntAssert(len(dom.Sol.React), dom.Ny)
+
+		// resultants: qnV=-100 on top (length=2) and qnH=-50 on right side (length=1)
+		tol := 1e-12
+		if method == "penalty" {
+			tol = 1e-6
+		}
+		bottom := dom.Msh.FaceTag2verts[-10]
+		left := dom.Msh.FaceTag2verts[-13]
+		chk.Scalar(tst, "Σ Ruy @ bottom", tol, dom.SumReactions(dom.Sol.React, "uy", bottom), 200)
+		chk.Scalar(tst, "Σ Rux @ left", tol, dom.SumReactions(dom.Sol.React, "ux", left), 50)
+		chk.Scalar(tst, "Σ Rux @ bottom", tol, dom.SumReactions(dom.Sol.React, "ux", bottom), 0)
+
+		// reactions are zero at free equations
+		constrained := make(map[int]bool)
+		for _, bc := range dom.EssenBcs.Bcs {
+			for _, eq := range bc.Eqs {
+				constrained[eq] = true
+			}
+		}
+		for _, bc := range dom.EssenBcs.Spcs {
+			constrained[bc.Eqs[0]] = true
+		}
+		for eq, r := range dom.Sol.React {
+			if !constrained[eq] && r != 0 {
+				tst.Errorf("reaction at free equation %d must be zero. %g is incorrect", eq, r)
+			}
+		}
+
+		// Lagrange multipliers: R = -λ for single-point constraints (without ties)
+		if method == "lagrange" {
+			tied := make(map[int]bool)
+			for _, bc := range dom.EssenBcs.Bcs {
+				if bc.Key == "mpc" {
+					for _, eq := range bc.Eqs {
+						tied[eq] = true
+					}
+				}
+			}
+			for i, bc := range dom.EssenBcs.Bcs {
+				if bc.single() && !tied[bc.Eqs[0]] {
+					chk.Scalar(tst, io.Sf("R @ eq %d", bc.Eqs[0]), 1e-12, dom.Sol.React[bc.Eqs[0]], -dom.Sol.L[i])
+				}
+			}
+		}
+	}
+}
+
+func Test_react02(tst *testing.T) {
+
+	/* damped rod with generalized-α method (see Test_genalpha01)
+	 *
+	 *    ▷0------------1 → P     M = [[1, ½], [½, 1]], K = [[1, -1], [-1, 1]] and C = 0.1 M + 0.1 K
+	 *
+	 *    the reaction at vertex 0 corresponds to the equilibrium at t(n+1-α):
+	 *      R = M01 a(n+1-αm) + C01 v(n+1-αf) + K01 u(n+1-αf)
+	 */
+
+	//verbose()
+	chk.PrintTitle("react02. reactions with generalized-α method")
+
+	// run simulation
+	analysis := NewFEM("data/rod01ga.sim", "", true, false, false, false, chk.Verbose, 0)
+	err := analysis.Run()
+	if err != nil {
+		tst.Errorf("Run failed:\n%v", err)
+		return
+	}
+
+	// domain for reading results
+	doms := NewDomains(analysis.Sim, analysis.DynCfs, analysis.HydSta, 0, 1, false)
+	if len(doms) == 0 {
+		tst.Errorf("NewDomains failed\n")
+		return
+	}
+	dom := doms[0]
+	err = dom.SetStage(0)
+	if err != nil {
+		tst.Errorf("SetStage failed\n%v", err)
+		return
+	}
+	eq := dom.Vid2node[0].GetEq("ux")
+
+	// single degree-of-freedom system
+	m, c, k, P, h, ρinf := 1.0, 0.2, 1.0, 1.0, 0.01, 0.5
+	M01, C01, K01 := 0.5, -0.05, -1.0
+	αm := (2.0*ρinf - 1.0) / (ρinf + 1.0)
+	αf := ρinf / (ρinf + 1.0)
+	γ := 0.5 - αm + αf
+	β := math.Pow(1.0-αm+αf, 2.0) / 4.0
+	nsteps := 1000
+	R := make([]float64, nsteps+1)
+	var u, v, a float64
+	for n := 1; n <= nsteps; n++ {
+		up := u + h*v + h*h*(0.5-β)*a
+		vp := v + h*(1.0-γ)*a
+		anew := (P - m*αm*a - c*((1.0-αf)*vp+αf*v) - k*((1.0-αf)*up+αf*u)) / (m*(1.0-αm) + c*(1.0-αf)*γ*h + k*(1.0-αf)*β*h*h)
+		unew, vnew := up+β*h*h*anew, vp+γ*h*anew
+		R[n] = M01*((1.0-αm)*anew+αm*a) + C01*((1.0-αf)*vnew+αf*v) + K01*((1.0-αf)*unew+αf*u)
+		u, v, a = unew, vnew, anew
+	}
+
+	// check
+	for tidx, t := range analysis.Summary.OutTimes {
+		err = dom.ReadSol(analysis.Sim.DirOut, analysis.Sim.Key, analysis.Sim.EncType, tidx)
+		if err != nil {
+			tst.Errorf("ReadSol failed:\n%v", err)
+			return
+		}
+		n := int(math.Floor(t/h + 0.5))
+		io.Pforan("t=%5.2f Rx=%23.15e (sdof=%23.15e)\n", t, dom.Sol.React[eq], R[n])
+		chk.Scalar(tst, io.Sf("Rx(t=%g)", t), 1e-9, dom.Sol.React[eq], R[n])
+	}
+
+	// Lagrange multipliers: R = -(1-αf) λ
+	d := analysis.Domains[0]
+	for i, bc := range d.EssenBcs.Bcs {
+		if bc.single() {
+			chk.Scalar(tst, io.Sf("R @ eq %d", bc.Eqs[0]), 1e-10, d.Sol.React[bc.Eqs[0]], -(1.0-αf)*d.Sol.L[i])
+		}
+	}
+}
@@ -0,0 +1,99 @@
+// Copyright 2015 Dorival Pedroso and Raul Durand. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fem
+
+import (
+	"testing"
+
+	"github.com/cpmech/gofem/ana"
+	"github.com/cpmech/gosl/chk"
+	"github.com/cpmech/gosl/fun"
+	"github.com/cpmech/gosl/io"
+)
+
+func Test_spcs01(tst *testing.T) {
+
+	//verbose()
+	chk.PrintTitle("spcs01. single-point constraints by elimination and penalty")
+
+	// solution
+	var sol ana.CteStressPstrain
+	sol.Init(fun.Prms{
+		&fun.Prm{N: "qnH", V: -50},
+		&fun.Prm{N: "qnV", V: -100},
+	})
+
+	// methods
+	for _, method := range []string{"elim", "penalty"} {
+		io.Pforan("method = %q\n", method)
+
+		// fem
+		analysis := NewFEM("data/twoqua4mpc.sim", "", true, false, false, false, chk.Verbose, 0)
+		analysis.Sim.Solver.Spcs = method
+
+		// run simulation
+		err := analysis.Run()
+		if err != nil {
+			tst.Errorf("Run failed\n%v", err)
+			return
+		}
+
+		// domain
+		dom := analysis.Domains[0]
+		io.Pf("%v\n", dom.EssenBcs.List(dom.Sol.T))
+
+		// constraints: Lagrange multipliers for 3 mpcs only
+		chk.IntAssert(dom.Nlam, 3)
+		chk.IntAssert(len(dom.EssenBcs.Spcs), 6)
+		for _, bc := range dom.EssenBcs.Bcs {
+			if bc.Key != "mpc" {
+				tst.Errorf("key %s is incorrect", bc.Key)
+			}
+		}
+
+		// check displacements
+		tolu := 1e-13
+		if method == "penalty" {
+			tolu = 1e-8
+		}
+		t := dom.Sol.T
+		for _, n := range dom.Nodes {
+			eqx := n.GetEq("ux")
+			eqy := n.GetEq("uy")
+			u := []float64{dom.Sol.Y[eqx], dom.Sol.Y[eqy]}
+			sol.CheckDispl(tst, t, u, n.Vert.C, tolu)
+		}
+	}
+}
+
+func Test_spcs02(tst *testing.T) {
+
+	//verbose()
+	chk.PrintTitle("spcs02. elimination of single-point constraints in plasticity problem")
+
+	// reference solution: Lagrange multipliers
+	ref := NewFEM("data/spo751.sim", "", true, false, false, false, chk.Verbose, 0)
+	err := ref.Run()
+	if err != nil {
+		tst.Errorf("Run failed:\n%v", err)
+		return
+	}
+
+	// elimination; inclined supports still use Lagrange multipliers
+	analysis := NewFEM("data/spo751.sim", "", true, false, false, false, chk.Verbose, 0)
+	analysis.Sim.Solver.Spcs = "elim"
+	err = analysis.Run()
+	if err != nil {
+		tst.Errorf("Run failed:\n%v", err)
+		return
+	}
+
+	// check
+	dom, domref := analysis.Domains[0], ref.Domains[0]
+	chk.IntAssert(dom.Nlam, 9)
+	chk.IntAssert(len(dom.EssenBcs.Spcs), 9)
+	chk.Scalar(tst, "t", 1e-15, dom.Sol.T, domref.Sol.T)
+	chk.Vector(tst, "y", 1e-7, dom.Sol.Y, domref.Sol.Y)
+}
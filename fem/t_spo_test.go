@@ -188,3 +188,123 @@ func Test_spo751re(tst *testing.T) {
 
 	// TODO: add check here
 }
+
+func Test_spo751arc(tst *testing.T) {
+
+	//verbose()
+	chk.PrintTitle("spo751arc. arc-length method")
+
+	// run simulation
+	analysis := NewFEM("data/spo751arc.sim", "", true, true, false, false, chk.Verbose, 0)
+
+	// run simulation
+	err := analysis.Run()
+	if err != nil {
+		tst.Errorf("Run failed:\n%v", err)
+		return
+	}
+
+	// check equilibrium path
+	sum := analysis.Summary
+	chk.IntAssert(len(sum.CtrlVals), len(sum.LoadFactors))
+	λmax := 0.0
+	for _, λ := range sum.LoadFactors {
+		λmax = utl.Max(λmax, λ)
+	}
+
+	// limit pressure: 2/√3 . σy . ln(b/a)
+	a, b, σy := 100.0, 200.0, 0.24
+	plim := 2.0 * σy * math.Log(b/a) / math.Sqrt(3.0)
+	io.Pforan("pmax = %v (analytical = %v)\n", λmax*0.2, plim)
+	chk.Scalar(tst, "pmax", 3e-3, λmax*0.2, plim)
+}
+
+func Test_spo751arcmax(tst *testing.T) {
+
+	//verbose()
+	chk.PrintTitle("spo751arcmax. arc-length method: stop at max number of steps")
+
+	// allow a few steps only
+	analysis := NewFEM("data/spo751arc.sim", "", true, false, false, false, chk.Verbose, 0)
+	nsmax := 3
+	analysis.Sim.Solver.ALnsmax = nsmax
+
+	// run simulation
+	err := analysis.Run()
+	if err != nil {
+		tst.Errorf("Run failed:\n%v", err)
+		return
+	}
+
+	// check equilibrium path: initial state + nsmax steps at most
+	sum := analysis.Summary
+	io.Pforan("λ = %v\n", sum.LoadFactors)
+	chk.IntAssert(len(sum.CtrlVals), len(sum.LoadFactors))
+	if len(sum.LoadFactors) < 2 || len(sum.LoadFactors) > nsmax+1 {
+		tst.Errorf("number of recorded load factors is incorrect: %d", len(sum.LoadFactors))
+	}
+}
+
+func Test_spo751ls(tst *testing.T) {
+
+	//verbose()
+	chk.PrintTitle("spo751ls. line search and BFGS")
+
+	// reference solution: full Newton-Raphson
+	ref := NewFEM("data/spo751.sim", "", true, false, false, false, chk.Verbose, 0)
+	err := ref.Run()
+	if err != nil {
+		tst.Errorf("Run failed:\n%v", err)
+		return
+	}
+
+	// energy line search and BFGS updates
+	analysis := NewFEM("data/spo751ls.sim", "", true, true, false, false, chk.Verbose, 0)
+	err = analysis.Run()
+	if err != nil {
+		tst.Errorf("Run failed:\n%v", err)
+		return
+	}
+
+	// check
+	chk.Scalar(tst, "t", 1e-15, analysis.Domains[0].Sol.T, ref.Domains[0].Sol.T)
+	chk.Vector(tst, "y", 1e-6, analysis.Domains[0].Sol.Y, ref.Domains[0].Sol.Y)
+	if len(analysis.Summary.LsSteps.Vals) == 0 {
+		tst.Errorf("step lengths of line search should have been recorded\n")
+	}
+}
+
+func Test_spo751par(tst *testing.T) {
+
+	//verbose()
+	chk.PrintTitle("spo751par. concurrent element computations")
+
+	// reference solution: serial computations
+	ref := NewFEM("data/spo751.sim", "", true, false, false, false, chk.Verbose, 0)
+	err := ref.Run()
+	if err != nil {
+		tst.Errorf("Run failed:\n%v", err)
+		return
+	}
+
+	// four workers
+	analysis := NewFEM("data/spo751par.sim", "", true, false, false, false, chk.Verbose, 0)
+	err = analysis.Run()
+	if err != nil {
+		tst.Errorf("Run failed:\n%v", err)
+		return
+	}
+
+	// check solution
+	dom, domref := analysis.Domains[0], ref.Domains[0]
+	chk.Scalar(tst, "t", 1e-15, dom.Sol.T, domref.Sol.T)
+	chk.Vector(tst, "y", 1e-10, dom.Sol.Y, domref.Sol.Y)
+
+	// check stresses
+	for i, ele := range dom.Elems {
+		e, eref := ele.(*ElemU), domref.Elems[i].(*ElemU)
+		for idx, s := range e.States {
+			chk.Vector(tst, io.Sf("σ(e=%d,ip=%d)", e.Id(), idx), 1e-8, s.Sig, eref.States[idx].Sig)
+		}
+	}
+}
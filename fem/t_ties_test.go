@@ -0,0 +1,84 @@
+// Copyright 2015 Dorival Pedroso and Raul Durand. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fem
+
+import (
+	"testing"
+
+	"github.com/cpmech/gofem/ana"
+	"github.com/cpmech/gosl/chk"
+	"github.com/cpmech/gosl/fun"
+	"github.com/cpmech/gosl/io"
+)
+
+func Test_ties01(tst *testing.T) {
+
+	//verbose()
+	chk.PrintTitle("ties01. two non-conforming regions tied along interface")
+
+	// solution
+	var sol ana.CteStressPstrain
+	sol.Init(fun.Prms{
+		&fun.Prm{N: "qnH", V: -50},
+		&fun.Prm{N: "qnV", V: -100},
+	})
+
+	// methods for single-point constraints
+	for _, method := range []string{"lagrange", "elim"} {
+		io.Pforan("method = %q\n", method)
+
+		// fem
+		analysis := NewFEM("data/tie2reg.sim", "", true, false, false, false, chk.Verbose, 0)
+		analysis.Sim.Solver.Spcs = method
+
+		// regions are merged
+		chk.IntAssert(len(analysis.Sim.Regions), 1)
+		chk.IntAssert(len(analysis.Domains), 1)
+		tie := analysis.Sim.Ties[0]
+		chk.Ints(tst, "slave vertices", tie.SlaveVerts, []int{9, 11, 13, 15})
+		chk.IntAssert(len(tie.MasterFaces), 2)
+
+		// run simulation
+		err := analysis.Run()
+		if err != nil {
+			tst.Errorf("Run failed\n%v", err)
+			return
+		}
+
+		// domain
+		dom := analysis.Domains[0]
+		io.Pf("%v\n", dom.EssenBcs.List(dom.Sol.T))
+		chk.IntAssert(len(dom.Msh.Verts), 17)
+		chk.IntAssert(len(dom.Msh.Cells), 7)
+
+		// constraints: 4 ties of ux and 3 ties of uy (bottom slave vertex has uy fixed)
+		nmpc := 0
+		for _, bc := range dom.EssenBcs.Bcs {
+			if bc.Key == "mpc" {
+				nmpc++
+			}
+		}
+		chk.IntAssert(nmpc, 7)
+		if method == "lagrange" {
+			chk.IntAssert(dom.Nlam, 15)
+		} else {
+			chk.IntAssert(dom.Nlam, 7)
+		}
+
+		// check displacements
+		t := dom.Sol.T
+		for _, n := range dom.Nodes {
+			eqx := n.GetEq("ux")
+			eqy := n.GetEq("uy")
+			u := []float64{dom.Sol.Y[eqx], dom.Sol.Y[eqy]}
+			sol.CheckDispl(tst, t, u, n.Vert.C, 1e-13)
+		}
+
+		// interpolation of slave vertex at y=1/3
+		verts, N := tie_interpolation(dom.Msh, tie.MasterFaces, dom.Msh.Verts[11].C, 1e-8)
+		chk.Ints(tst, "master vertices", verts, []int{2, 5})
+		chk.Vector(tst, "N", 1e-14, N, []float64{1.0 / 3.0, 2.0 / 3.0})
+	}
+}
@@ -0,0 +1,229 @@
+// Copyright 2015 Dorival Pedroso and Raul Durand. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fem
+
+import (
+	"math"
+	"testing"
+
+	"github.com/cpmech/gofem/inp"
+	"github.com/cpmech/gofem/shp"
+	"github.com/cpmech/gosl/chk"
+	"github.com/cpmech/gosl/io"
+	"github.com/cpmech/gosl/la"
+	"github.com/cpmech/gosl/num"
+)
+
+func Test_xfem01(tst *testing.T) {
+
+	//verbose()
+	chk.PrintTitle("xfem01. enrichment functions and auxiliary near-tip fields")
+
+	// inclined crack
+	crk, err := new_xcrack("!xcrk:1 !crka:0,0 !crkt:1,0.5")
+	if err != nil {
+		tst.Errorf("new_xcrack failed:\n%v", err)
+		return
+	}
+	chk.Vector(tst, "t", 1e-15, crk.t, []float64{2.0 / math.Sqrt(5.0), 1.0 / math.Sqrt(5.0)})
+	chk.Vector(tst, "n", 1e-15, crk.n, []float64{-1.0 / math.Sqrt(5.0), 2.0 / math.Sqrt(5.0)})
+
+	// derivatives of enrichment functions
+	F := make([]float64, 5)
+	dF := la.MatAlloc(5, 2)
+	Ftmp := make([]float64, 5)
+	dFtmp := la.MatAlloc(5, 2)
+	for _, x := range [][]float64{{1.3, 0.6}, {0.7, 0.8}, {0.5, 0.1}, {1.1, 0.2}} {
+		crk.funcs(F, dF, x)
+		io.Pforan("x = %v  F = %v\n", x, F)
+		for k := 0; k < 5; k++ {
+			for i := 0; i < 2; i++ {
+				dnum := num.DerivCen(func(t float64, args ...interface{}) float64 {
+					xtmp := []float64{x[0], x[1]}
+					xtmp[i] = t
+					crk.funcs(Ftmp, dFtmp, xtmp)
+					return Ftmp[k]
+				}, x[i])
+				chk.AnaNum(tst, io.Sf("dF%d/dx%d", k, i), 1e-8, dF[k][i], dnum, chk.Verbose)
+			}
+		}
+	}
+
+	// Heaviside function
+	crk.funcs(F, dF, []float64{0.5, 0.3})
+	chk.Scalar(tst, "H(above)", 1e-15, F[0], 1)
+	crk.funcs(F, dF, []float64{0.5, 0.2})
+	chk.Scalar(tst, "H(below)", 1e-15, F[0], -1)
+
+	// auxiliary fields satisfy Hooke's law (plane-strain)
+	E, ν := 1000.0, 0.3
+	μ := E / (2.0 * (1.0 + ν))
+	λ := E * ν / ((1.0 + ν) * (1.0 - 2.0*ν))
+	κ := 3.0 - 4.0*ν
+	σ := la.MatAlloc(2, 2)
+	gu := la.MatAlloc(2, 2)
+	for mode := 0; mode < 2; mode++ {
+		for _, r := range []float64{0.01, 0.5, 2.0} {
+			for _, θ := range []float64{-3.0, -1.2, 0, 0.4, 2.5} {
+				xcrk_auxfields(σ, gu, r, θ, κ, μ, mode)
+				tr := gu[0][0] + gu[1][1]
+				for i := 0; i < 2; i++ {
+					for j := 0; j < 2; j++ {
+						σh := μ * (gu[i][j] + gu[j][i])
+						if i == j {
+							σh += λ * tr
+						}
+						chk.Scalar(tst, io.Sf("σ%d%d(mode=%d,r=%g,θ=%g)", i, j, mode, r, θ), 1e-12, σ[i][j], σh)
+					}
+				}
+			}
+		}
+	}
+}
+
+func Test_xfem02(tst *testing.T) {
+
+	//verbose()
+	chk.PrintTitle("xfem02. sub-cell integration points")
+
+	// unit square
+	sh := shp.Get("qua4", 0)
+	x := [][]float64{
+		{0, 1, 1, 0},
+		{0, 0, 1, 1},
+	}
+
+	// integrals of 1, x and y
+	integrals := func(ips []shp.Ipoint) (res []float64) {
+		res = make([]float64, 3)
+		for _, ip := range ips {
+			y := sh.IpRealCoords(x, ip)
+			sh.CalcAtIp(x, ip, true)
+			res[0] += sh.J * ip[3]
+			res[1] += sh.J * ip[3] * y[0]
+			res[2] += sh.J * ip[3] * y[1]
+		}
+		return
+	}
+
+	// cut cell
+	crk, _ := new_xcrack("!xcrk:1 !crka:-1,0.3 !crkt:2,0.6")
+	cut, tip, _ := crk.classify(x, sh)
+	if !cut || tip {
+		tst.Errorf("cell should be cut by crack. cut=%v, tip=%v\n", cut, tip)
+		return
+	}
+	ips, err := crk.subcell_ips(x, sh, false, nil)
+	if err != nil {
+		tst.Errorf("subcell_ips failed:\n%v", err)
+		return
+	}
+	chk.IntAssert(len(ips), 4*12)
+	chk.Vector(tst, "∫ cut", 1e-14, integrals(ips), []float64{1, 0.5, 0.5})
+
+	// tip cell
+	crk, _ = new_xcrack("!xcrk:1 !crka:-1,0.4 !crkt:0.6,0.4")
+	cut, tip, rt := crk.classify(x, sh)
+	if cut || !tip {
+		tst.Errorf("cell should contain tip. cut=%v, tip=%v\n", cut, tip)
+		return
+	}
+	chk.Vector(tst, "rt", 1e-12, rt[:2], []float64{0.2, -0.2})
+	ips, err = crk.subcell_ips(x, sh, true, rt)
+	if err != nil {
+		tst.Errorf("subcell_ips failed:\n%v", err)
+		return
+	}
+	chk.IntAssert(len(ips), 6*25)
+	chk.Vector(tst, "∫ tip", 1e-14, integrals(ips), []float64{1, 0.5, 0.5})
+
+	// neither cut nor tip: crack ahead of tip
+	crk, _ = new_xcrack("!xcrk:1 !crka:-1,0.4 !crkt:-0.5,0.4")
+	cut, tip, _ = crk.classify(x, sh)
+	if cut || tip {
+		tst.Errorf("cell should not be cut. cut=%v, tip=%v\n", cut, tip)
+	}
+}
+
+func Test_xfem03(tst *testing.T) {
+
+	//verbose()
+	chk.PrintTitle("xfem03. single edge notched plate in tension")
+
+	// fem
+	analysis := NewFEM("data/xsent.sim", "", true, false, false, false, chk.Verbose, 0)
+
+	// run simulation
+	err := analysis.Run()
+	if err != nil {
+		tst.Errorf("Run failed\n%v", err)
+		return
+	}
+
+	// enriched vertices: 14 within rtip = 0.1 of tip and 10 around crack faces
+	dom := analysis.Domains[0]
+	var ntip, nhvs int
+	for _, nod := range dom.Nodes {
+		if nod.GetEq("a1x") >= 0 {
+			ntip++
+		}
+		if nod.GetEq("ahx") >= 0 {
+			nhvs++
+		}
+	}
+	chk.IntAssert(ntip, 14)
+	chk.IntAssert(nhvs, 10)
+
+	// reference solution: a/W = 0.3
+	σ, a, r := 1.0, 0.3, 0.3
+	KIana := σ * math.Sqrt(math.Pi*a) * (1.12 - 0.231*r + 10.55*r*r - 21.72*r*r*r + 30.39*r*r*r*r)
+
+	// stress intensity factors with two integration domains
+	var KIs []float64
+	for _, rd := range []float64{0.15, 0.25} {
+		KI, KII, err := dom.XfemSIF(rd)
+		if err != nil {
+			tst.Errorf("XfemSIF failed\n%v", err)
+			return
+		}
+		io.Pforan("rd = %g: KI = %v (ana = %v)  KII = %v\n", rd, KI, KIana, KII)
+		chk.Scalar(tst, "KI", 0.06, KI, KIana)
+		chk.Scalar(tst, "KII", 0.01, KII, 0)
+		KIs = append(KIs, KI)
+	}
+	chk.Scalar(tst, "KI: domain independence", 0.03, KIs[0], KIs[1])
+}
+
+func Test_xfem04(tst *testing.T) {
+
+	//verbose()
+	chk.PrintTitle("xfem04. xmat and xcrk cannot be used together")
+
+	// flags
+	xmat, xcrk, xfem, err := xfem_flags("!xmat:1")
+	if err != nil {
+		tst.Errorf("xfem_flags failed:\n%v", err)
+		return
+	}
+	if !xmat || xcrk || !xfem {
+		tst.Errorf("flags are incorrect: xmat=%v xcrk=%v xfem=%v", xmat, xcrk, xfem)
+		return
+	}
+
+	// both flags
+	edat := &inp.ElemData{Type: "u", Extra: "!xmat:1 !xcrk:1 !crka:0,0 !crkt:1,0"}
+	err = xfem_set_info(new(Info), nil, nil, edat)
+	if err == nil {
+		tst.Errorf("xfem_set_info should have failed with xmat and xcrk\n")
+		return
+	}
+	io.Pforan("err = %v\n", err)
+	err = new(ElemU).xfem_init(nil, edat)
+	if err == nil {
+		tst.Errorf("xfem_init should have failed with xmat and xcrk\n")
+		return
+	}
+	io.Pforan("err = %v\n", err)
+}
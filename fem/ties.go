@@ -0,0 +1,161 @@
+// Copyright 2015 Dorival Pedroso and Raul Durand. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fem
+
+import (
+	"math"
+	"strings"
+
+	"github.com/cpmech/gofem/inp"
+	"github.com/cpmech/gosl/chk"
+	"github.com/cpmech/gosl/fun"
+	"github.com/cpmech/gosl/la"
+)
+
+// set_ties sets node-to-surface tie constraints between slave vertices and master faces
+//  Note: (1) each slave vertex is located on one master face by inverse mapping of the master
+//            cell; the constraint coefficients are the shape functions of the face vertices
+//        (2) conforming meshes are handled as well; the slave vertex is then tied to the
+//            coincident master vertex only
+//        (3) dofs of slave vertices with single-point constraints are not tied to avoid
+//            redundant constraints; e.g. at the ends of an interface on a supported boundary
+//        (4) the constraints are multi-point constraints ("mpc") and must be set after
+//            single-point constraints; see EssentialBcs.SetMpc
+func (o *Domain) set_ties(ties []*inp.TieData) (err error) {
+
+	// keys
+	ndim := o.Msh.Ndim
+	ukeys := []string{"ux", "uy", "uz"}
+
+	// for each tie
+	for k, tie := range ties {
+		keys := tie.Keys
+		if len(keys) == 0 {
+			keys = ukeys[:ndim]
+		}
+		tol := tie.Tol
+		if tol <= 0 {
+			tol = 1e-8
+		}
+
+		// for each slave vertex
+		for _, vid := range tie.SlaveVerts {
+			snod := o.Vid2node[vid]
+			if snod == nil { // inactive vertex
+				continue
+			}
+
+			// master vertices and coefficients
+			verts, N := tie_interpolation(o.Msh, tie.MasterFaces, o.Msh.Verts[vid].C, tol)
+			if len(verts) == 0 {
+				return chk.Err("tie %d: cannot find master face containing slave vertex %d", k, vid)
+			}
+			nodes := []*Node{snod}
+			coefs := []float64{1}
+			for i, v := range verts {
+				if o.Vid2node[v] == nil {
+					return chk.Err("tie %d: master vertex %d of slave vertex %d is not active", k, v, vid)
+				}
+				nodes = append(nodes, o.Vid2node[v])
+				coefs = append(coefs, -N[i])
+			}
+
+			// constraints: y(slave) - Σ N(master) ⋅ y(master) = 0
+			for _, key := range keys {
+				eq := snod.GetEq(key)
+				if eq < 0 {
+					return chk.Err("tie %d: dof=%q cannot be found in slave vertex %d", k, key, vid)
+				}
+				if o.EssenBcs.has_single(eq) {
+					continue
+				}
+				mkeys := make([]string, len(nodes))
+				for i := 0; i < len(nodes); i++ {
+					mkeys[i] = key
+				}
+				err = o.EssenBcs.SetMpc(nodes, mkeys, coefs, &fun.Zero)
+				if err != nil {
+					return chk.Err("tie %d: cannot set constraint of slave vertex %d:\n%v", k, vid, err)
+				}
+			}
+		}
+	}
+	return
+}
+
+// tie_interpolation finds the master face containing point y and computes the interpolation
+// coefficients of the face vertices
+//  Output:
+//   verts -- ids of face vertices with non-zero coefficients; empty if y is not on any face
+//   N     -- coefficients (shape functions) corresponding to verts; Σ N = 1
+func tie_interpolation(msh *inp.Mesh, faces []inp.CellFaceId, y []float64, tol float64) (verts []int, N []float64) {
+	r := make([]float64, 3)
+	ndim := msh.Ndim
+	for _, cf := range faces {
+
+		// natural coordinates of y in master cell
+		sh := cf.C.Shp
+		x := la.MatAlloc(ndim, sh.Nverts)
+		for j, v := range cf.C.Verts {
+			for i := 0; i < ndim; i++ {
+				x[i][j] = msh.Verts[v].C[i]
+			}
+		}
+		if sh.InvMap(r, y, x) != nil {
+			continue
+		}
+		if !inside_refcell(sh.BasicType, r[:sh.Gndim], tol) {
+			continue
+		}
+
+		// shape functions; non-face vertices must have zero values
+		sh.Func(sh.S, sh.DSdR, r, false, -1)
+		onface := make(map[int]bool)
+		for _, l := range sh.FaceLocalVerts[cf.Fid] {
+			onface[l] = true
+		}
+		var sumface, sumother float64
+		for l := 0; l < sh.Nverts; l++ {
+			if onface[l] {
+				sumface += sh.S[l]
+			} else {
+				sumother += math.Abs(sh.S[l])
+			}
+		}
+		if sumother > tol {
+			continue
+		}
+
+		// coefficients
+		for _, l := range sh.FaceLocalVerts[cf.Fid] {
+			if math.Abs(sh.S[l]) > 1e-12 {
+				verts = append(verts, cf.C.Verts[l])
+				N = append(N, sh.S[l]/sumface)
+			}
+		}
+		return
+	}
+	return
+}
+
+// inside_refcell checks whether natural coordinates r are inside the reference cell
+func inside_refcell(basictype string, r []float64, tol float64) bool {
+	if strings.HasPrefix(basictype, "tri") || strings.HasPrefix(basictype, "tet") {
+		var sum float64
+		for _, ri := range r {
+			if ri < -tol {
+				return false
+			}
+			sum += ri
+		}
+		return sum <= 1.0+tol
+	}
+	for _, ri := range r {
+		if math.Abs(ri) > 1.0+tol {
+			return false
+		}
+	}
+	return true
+}
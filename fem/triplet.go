@@ -0,0 +1,126 @@
+// Copyright 2015 Dorival Pedroso and Raul Durand. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fem
+
+import (
+	"github.com/cpmech/gosl/chk"
+	"github.com/cpmech/gosl/la"
+)
+
+// Triplet holds a sparse matrix in triplet format (e.g. the global Jacobian Kb)
+//  Note: (1) the entries are put into la.Triplet, which is given to the direct linear solvers,
+//            and/or kept in I, J and X because la.Triplet does not expose them. Init selects the
+//            storage: I, J and X are allocated only if fem needs to read the assembled matrix;
+//            e.g. to join the matrices of element workers, to eliminate constrained equations or
+//            to build the matrix of the iterative linear solvers, which do not need la.Triplet
+//        (2) the embedded la.Triplet must not be modified directly; otherwise I, J and X would
+//            not correspond to the matrix given to the linear solvers anymore
+type Triplet struct {
+	la.Triplet           // matrix given to direct linear solvers; if direct
+	I          []int     // row indices of all entries; if readable
+	J          []int     // column indices of all entries; if readable
+	X          []float64 // values of all entries; if readable
+	m, n       int       // dimensions
+	direct     bool      // entries are put into la.Triplet
+	readable   bool      // entries are kept in I, J and X
+}
+
+// Init allocates the triplet
+//  Input:
+//   m, n     -- dimensions
+//   max      -- max number of entries
+//   direct   -- put entries into la.Triplet; e.g. to be given to direct linear solvers
+//   readable -- keep entries in I, J and X
+func (o *Triplet) Init(m, n, max int, direct, readable bool) {
+	if !direct && !readable {
+		chk.Panic("triplet must be either direct or readable (or both)")
+	}
+	o.m, o.n = m, n
+	o.direct, o.readable = direct, readable
+	o.I, o.J, o.X = nil, nil, nil
+	if direct {
+		o.Triplet.Init(m, n, max)
+	}
+	if readable {
+		o.I = make([]int, 0, max)
+		o.J = make([]int, 0, max)
+		o.X = make([]float64, 0, max)
+	}
+}
+
+// Start removes all entries in order to assemble the matrix again
+func (o *Triplet) Start() {
+	if o.direct {
+		o.Triplet.Start()
+	}
+	if o.readable {
+		o.I, o.J, o.X = o.I[:0], o.J[:0], o.X[:0]
+	}
+}
+
+// Put adds an entry; duplicates are summed up by the linear solvers
+func (o *Triplet) Put(i, j int, x float64) {
+	if o.direct {
+		o.Triplet.Put(i, j, x)
+	}
+	if o.readable {
+		o.I = append(o.I, i)
+		o.J = append(o.J, j)
+		o.X = append(o.X, x)
+	}
+}
+
+// Size returns the dimensions of the matrix
+func (o *Triplet) Size() (m, n int) {
+	return o.m, o.n
+}
+
+// Readable returns whether the entries are kept in I, J and X
+func (o *Triplet) Readable() bool {
+	return o.readable
+}
+
+// PutTriplet adds all entries of another (readable) triplet
+func (o *Triplet) PutTriplet(a *Triplet) {
+	if !a.readable {
+		chk.Panic("cannot put entries of triplet that is not readable")
+	}
+	for k := 0; k < len(a.I); k++ {
+		o.Put(a.I[k], a.J[k], a.X[k])
+	}
+}
+
+// PutMatAndMatT adds the entries of a [m][n] (readable) matrix 'a' below the [n][n] matrix and of
+// tr(a) on its right; i.e. it builds [[K, tr(a)], [a, 0]] as la.Triplet.PutMatAndMatT does
+func (o *Triplet) PutMatAndMatT(a *Triplet) {
+	if !a.readable {
+		chk.Panic("cannot put entries of triplet that is not readable")
+	}
+	for k := 0; k < len(a.I); k++ {
+		o.Put(a.n+a.I[k], a.J[k], a.X[k]) // a
+		o.Put(a.J[k], a.n+a.I[k], a.X[k]) // tr(a)
+	}
+}
+
+// Filter removes the entries for which keep(i, j) returns false; the triplet must be readable
+func (o *Triplet) Filter(keep func(i, j int) bool) {
+	if !o.readable {
+		chk.Panic("cannot filter entries of triplet that is not readable")
+	}
+	if o.direct {
+		o.Triplet.Start()
+	}
+	n := 0
+	for k := 0; k < len(o.I); k++ {
+		if keep(o.I[k], o.J[k]) {
+			o.I[n], o.J[n], o.X[n] = o.I[k], o.J[k], o.X[k]
+			if o.direct {
+				o.Triplet.Put(o.I[n], o.J[n], o.X[n])
+			}
+			n++
+		}
+	}
+	o.I, o.J, o.X = o.I[:n], o.J[:n], o.X[:n]
+}
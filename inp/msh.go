@@ -95,6 +95,7 @@ type Mesh struct {
 	SeamTag2cells map[int][]CellSeamId // seam tag => set of cells
 	Ctype2cells   map[string][]*Cell   // cell type => set of cells
 	Part2cells    map[int][]*Cell      // partition number => set of cells
+	Vert2cells    [][]*Cell            // [nverts] vertex id => cells sharing this vertex
 
 	// NURBS
 	Nurbss   []gm.NurbsD   // all NURBS data (read from file)
@@ -122,6 +123,14 @@ func ReadMsh(dir, fn string, goroutineId int) (o *Mesh, err error) {
 		return
 	}
 
+	// derived data
+	err = o.init(goroutineId)
+	return
+}
+
+// init checks vertices and cells and computes derived data
+func (o *Mesh) init(goroutineId int) (err error) {
+
 	// check
 	if len(o.Verts) < 2 {
 		err = chk.Err("at least 2 vertices are required in mesh\n")
@@ -213,6 +222,7 @@ func ReadMsh(dir, fn string, goroutineId int) (o *Mesh, err error) {
 	o.SeamTag2cells = make(map[int][]CellSeamId)
 	o.Ctype2cells = make(map[string][]*Cell)
 	o.Part2cells = make(map[int][]*Cell)
+	o.Vert2cells = make([][]*Cell, len(o.Verts))
 	for i, c := range o.Cells {
 
 		// check id and tag
@@ -274,6 +284,11 @@ func ReadMsh(dir, fn string, goroutineId int) (o *Mesh, err error) {
 		// partition => cells
 		cells = o.Part2cells[c.Part]
 		o.Part2cells[c.Part] = append(cells, c)
+
+		// vertex => cells
+		for _, v := range c.Verts {
+			o.Vert2cells[v] = append(o.Vert2cells[v], c)
+		}
 	}
 
 	// remove duplicates
@@ -300,7 +315,7 @@ func (o *Vert) String() string {
 
 // String returns a JSON representation of *Cell
 func (o *Cell) String() string {
-	l := io.Sf("{\"id\":%d, \"tag\":%d, \"type\":%q, \"part\":%d, \"verts\":[", o.Id, o.Tag, o.Type, o.Part)
+	l := io.Sf("{\"id\":%d, \"tag\":%d, \"geo\":%d, \"type\":%q, \"part\":%d, \"verts\":[", o.Id, o.Tag, o.Geo, o.Type, o.Part)
 	for i, x := range o.Verts {
 		if i > 0 {
 			l += ", "
@@ -314,7 +329,31 @@ func (o *Cell) String() string {
 		}
 		l += io.Sf("%d", x)
 	}
-	l += "] }"
+	l += "]"
+	if len(o.STags) > 0 {
+		l += ", \"stags\":["
+		for i, x := range o.STags {
+			if i > 0 {
+				l += ", "
+			}
+			l += io.Sf("%d", x)
+		}
+		l += "]"
+	}
+	if len(o.Neighs) > 0 {
+		l += ", \"neighs\":["
+		for i, x := range o.Neighs {
+			if i > 0 {
+				l += ", "
+			}
+			l += io.Sf("%d", x)
+		}
+		l += "]"
+	}
+	if o.IsJoint {
+		l += io.Sf(", \"jlinId\":%d, \"jsldId\":%d", o.JlinId, o.JsldId)
+	}
+	l += " }"
 	return l
 }
 
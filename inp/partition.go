@@ -0,0 +1,324 @@
+// Copyright 2015 Dorival Pedroso and Raul Durand. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package inp
+
+import (
+	"math"
+	"sort"
+
+	"github.com/cpmech/gosl/chk"
+	"github.com/cpmech/gosl/io"
+	"github.com/cpmech/gosl/utl"
+)
+
+// PartitionsOk checks whether the cells are already split into nparts (non-empty) partitions
+// numbered from 0 to nparts-1
+func (o *Mesh) PartitionsOk(nparts int) bool {
+	if len(o.Part2cells) != nparts {
+		return false
+	}
+	for p := 0; p < nparts; p++ {
+		if len(o.Part2cells[p]) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Partition splits the cells into nparts partitions by recursive bisection of the dual graph
+//  Note: (1) Cell.Part and Part2cells are overwritten
+//        (2) joints are kept in the same partition as their line and solid cells
+//        (3) the results are deterministic; thus all processors compute the same partitions
+func (o *Mesh) Partition(nparts int) (err error) {
+
+	// check
+	nc := len(o.Cells)
+	if nparts < 1 {
+		return chk.Err("number of partitions must be greater than zero. %d is invalid\n", nparts)
+	}
+
+	// groups of cells that cannot be split
+	root := utl.IntRange(nc)
+	var find func(i int) int
+	find = func(i int) int {
+		if root[i] != i {
+			root[i] = find(root[i])
+		}
+		return root[i]
+	}
+	for _, c := range o.Cells {
+		if c.IsJoint {
+			if c.JlinId < 0 || c.JlinId >= nc || c.JsldId < 0 || c.JsldId >= nc {
+				return chk.Err("joint cell %d has invalid line (%d) or solid (%d) cell ids\n", c.Id, c.JlinId, c.JsldId)
+			}
+			for _, cid := range []int{c.JlinId, c.JsldId} {
+				a, b := find(c.Id), find(cid)
+				if a < b {
+					root[b] = a
+				} else {
+					root[a] = b
+				}
+			}
+		}
+	}
+	cid2grp := make([]int, nc)
+	var weights []int
+	root2grp := make(map[int]int)
+	for i := 0; i < nc; i++ {
+		r := find(i)
+		g, ok := root2grp[r]
+		if !ok {
+			g = len(weights)
+			root2grp[r] = g
+			weights = append(weights, 0)
+		}
+		cid2grp[i] = g
+		weights[g]++
+	}
+	ng := len(weights)
+	if nparts > ng {
+		return chk.Err("number of partitions (%d) must not be greater than the number of (groups of) cells (%d)\n", nparts, ng)
+	}
+
+	// graph of groups
+	sets := make([]map[int]bool, ng)
+	for g := 0; g < ng; g++ {
+		sets[g] = make(map[int]bool)
+	}
+	for i, neighs := range o.DualGraph() {
+		for _, j := range neighs {
+			a, b := cid2grp[i], cid2grp[j]
+			if a != b {
+				sets[a][b] = true
+			}
+		}
+	}
+	adj := make([][]int, ng)
+	for g, set := range sets {
+		for h := range set {
+			adj[g] = append(adj[g], h)
+		}
+		sort.Ints(adj[g])
+	}
+
+	// recursive bisection
+	parts := make([]int, ng)
+	insub := make([]bool, ng)
+	bisect_graph(parts, insub, adj, weights, utl.IntRange(ng), nparts, 0)
+
+	// set cells
+	o.Part2cells = make(map[int][]*Cell)
+	for i, c := range o.Cells {
+		c.Part = parts[cid2grp[i]]
+		o.Part2cells[c.Part] = append(o.Part2cells[c.Part], c)
+	}
+	return
+}
+
+// DualGraph returns the dual graph of the mesh; i.e. the cells (nodes) and the connections
+// between neighbouring cells (edges)
+//  Output:
+//   adj -- [ncells][nneighbours] sorted ids of neighbouring cells
+//  Note: (1) Cell.Neighs is used if available; otherwise, neighbours are found by matching faces
+//        (2) cells without faces; e.g. lines, joints and NURBS, are connected to all cells sharing
+//            at least one vertex with them
+func (o *Mesh) DualGraph() (adj [][]int) {
+
+	// auxiliary
+	nc := len(o.Cells)
+	sets := make([]map[int]bool, nc)
+	vert2cells := make(map[int][]int)
+	for i, c := range o.Cells {
+		sets[i] = make(map[int]bool)
+		for _, v := range c.Verts {
+			vert2cells[v] = append(vert2cells[v], i)
+		}
+	}
+	link := func(a, b int) {
+		if a != b && a >= 0 && b >= 0 && a < nc && b < nc {
+			sets[a][b] = true
+			sets[b][a] = true
+		}
+	}
+
+	// connections
+	face2cell := make(map[string]int)
+	for i, c := range o.Cells {
+
+		// given neighbours
+		if len(c.Neighs) > 0 {
+			for _, j := range c.Neighs {
+				link(i, j)
+			}
+			continue
+		}
+
+		// cells without faces
+		if c.Shp == nil || c.Shp.Nurbs != nil || c.Shp.Gndim != o.Ndim || len(c.Shp.FaceLocalVerts) == 0 {
+			for _, v := range c.Verts {
+				for _, j := range vert2cells[v] {
+					link(i, j)
+				}
+			}
+			continue
+		}
+
+		// matching faces
+		for _, lverts := range c.Shp.FaceLocalVerts {
+			verts := make([]int, len(lverts))
+			for k, l := range lverts {
+				verts[k] = c.Verts[l]
+			}
+			sort.Ints(verts)
+			key := io.Sf("%v", verts)
+			if j, ok := face2cell[key]; ok {
+				link(i, j)
+			} else {
+				face2cell[key] = i
+			}
+		}
+	}
+
+	// results
+	adj = make([][]int, nc)
+	for i, set := range sets {
+		for j := range set {
+			adj[i] = append(adj[i], j)
+		}
+		sort.Ints(adj[i])
+	}
+	return
+}
+
+// bisect_graph recursively bisects a subgraph until nparts partitions are obtained
+//  Input:
+//   insub   -- [nnodes] scratchpad; must be all false on input
+//   adj     -- [nnodes][nneighbours] adjacency lists
+//   weights -- [nnodes] weights of nodes
+//   nodes   -- nodes in subgraph
+//   nparts  -- number of partitions of subgraph
+//   first   -- index of first partition of subgraph
+//  Output:
+//   parts -- [nnodes] partition of each node in subgraph
+func bisect_graph(parts []int, insub []bool, adj [][]int, weights, nodes []int, nparts, first int) {
+
+	// end of recursion
+	if nparts == 1 {
+		for _, n := range nodes {
+			parts[n] = first
+		}
+		return
+	}
+
+	// target weight of first half
+	n1 := nparts / 2
+	wtot := 0
+	for _, n := range nodes {
+		wtot += weights[n]
+	}
+	wtarget := float64(wtot) * float64(n1) / float64(nparts)
+
+	// nodes sorted by the difference of distances to two pseudo-peripheral nodes a and b
+	for _, n := range nodes {
+		insub[n] = true
+	}
+	dist := bfs_distances(adj, insub, nodes, nodes[0])
+	a := farthest_node(dist, nodes)
+	dista := bfs_distances(adj, insub, nodes, a)
+	b := farthest_node(dista, nodes)
+	distb := bfs_distances(adj, insub, nodes, b)
+	for _, n := range nodes {
+		insub[n] = false
+	}
+	order := make([]int, len(nodes))
+	copy(order, nodes)
+	sort.Stable(&nodesByDist{order, dista, distb})
+
+	// split such that the weight of the first half is as close as possible to the target;
+	// keeping at least one node per partition
+	kmin, kmax := n1, len(order)-(nparts-n1)
+	k, w := kmin, 0
+	for i := 0; i < kmin; i++ {
+		w += weights[order[i]]
+	}
+	for k < kmax {
+		wnext := w + weights[order[k]]
+		if math.Abs(float64(wnext)-wtarget) >= math.Abs(float64(w)-wtarget) {
+			break
+		}
+		w = wnext
+		k++
+	}
+
+	// recursion
+	left := make([]int, k)
+	right := make([]int, len(order)-k)
+	copy(left, order[:k])
+	copy(right, order[k:])
+	bisect_graph(parts, insub, adj, weights, left, n1, first)
+	bisect_graph(parts, insub, adj, weights, right, nparts-n1, first+n1)
+}
+
+// bfs_distances returns the distances (number of edges) of all nodes in a subgraph to start
+//  Note: disconnected components are visited next, in the order given by nodes, with distances
+//        continuing from the largest distance found so far
+func bfs_distances(adj [][]int, insub []bool, nodes []int, start int) (dist map[int]int) {
+	dist = make(map[int]int)
+	dmax := 0
+	for _, seed := range append([]int{start}, nodes...) {
+		if _, ok := dist[seed]; ok {
+			continue
+		}
+		if seed != start {
+			dmax++
+		}
+		dist[seed] = dmax
+		queue := []int{seed}
+		for len(queue) > 0 {
+			n := queue[0]
+			queue = queue[1:]
+			if dist[n] > dmax {
+				dmax = dist[n]
+			}
+			for _, m := range adj[n] {
+				if _, ok := dist[m]; insub[m] && !ok {
+					dist[m] = dist[n] + 1
+					queue = append(queue, m)
+				}
+			}
+		}
+	}
+	return
+}
+
+// farthest_node returns the first node with the largest distance
+func farthest_node(dist map[int]int, nodes []int) (far int) {
+	far = nodes[0]
+	for _, n := range nodes {
+		if dist[n] > dist[far] {
+			far = n
+		}
+	}
+	return
+}
+
+// nodesByDist sorts nodes by the difference of distances to nodes a and b; then by the distance to a
+type nodesByDist struct {
+	nodes []int       // nodes
+	dista map[int]int // distances to a
+	distb map[int]int // distances to b
+}
+
+func (o *nodesByDist) Len() int      { return len(o.nodes) }
+func (o *nodesByDist) Swap(i, j int) { o.nodes[i], o.nodes[j] = o.nodes[j], o.nodes[i] }
+func (o *nodesByDist) Less(i, j int) bool {
+	ni, nj := o.nodes[i], o.nodes[j]
+	di := o.dista[ni] - o.distb[ni]
+	dj := o.dista[nj] - o.distb[nj]
+	if di == dj {
+		return o.dista[ni] < o.dista[nj]
+	}
+	return di < dj
+}
@@ -36,16 +36,28 @@ type Data struct {
 	Stat    bool    `json:"stat"`    // activate statistics
 	Wlevel  float64 `json:"wlevel"`  // water level; 0 means use max elevation
 	Surch   float64 `json:"surch"`   // surcharge load at surface == qn0
+
+	// checkpoints
+	Ckpout bool `json:"ckpout"` // save checkpoints at all output times; e.g. dirout/fnkey_out0000000003_p0_d0.ckp
+
+	// shared-memory parallelism
+	Nworkers int `json:"nworkers"` // number of goroutines for element assembly and updates; 0 or 1 => serial
 }
 
 // LinSolData holds data for linear solvers
 type LinSolData struct {
-	Name      string `json:"name"`      // "mumps" or "umfpack"
+	Name      string `json:"name"`      // "mumps" or "umfpack" (direct); "cg", "gmres" or "bicgstab" (iterative)
 	Symmetric bool   `json:"symmetric"` // use symmetric solver
 	Verbose   bool   `json:"verbose"`   // verbose?
 	Timing    bool   `json:"timing"`    // show timing statistics
 	Ordering  string `json:"ordering"`  // ordering scheme
 	Scaling   string `json:"scaling"`   // scaling scheme
+
+	// iterative solvers
+	Precond   string  `json:"precond"`   // preconditioner: "none", "jacobi", "ilu0" or "block"
+	ItTol     float64 `json:"ittol"`     // relative tolerance: |b - A.x| ≤ ItTol.|b|
+	ItMaxIt   int     `json:"itmaxit"`   // max number of iterations
+	ItRestart int     `json:"itrestart"` // GMRES: number of iterations before restart
 }
 
 // SolverData holds FEM solver data
@@ -63,6 +75,16 @@ type SolverData struct {
 	CteTg   bool    `json:"ctetg"`   // use constant tangent (modified Newton) during iterations
 	ShowR   bool    `json:"showr"`   // show residual
 
+	// line search and quasi-Newton methods
+	LSearch  string  `json:"lsearch"`  // line search method: "" => none, "energy" or "back" (backtracking)
+	LSnmaxIt int     `json:"lsnmaxit"` // line search: max number of iterations
+	LStol    float64 `json:"lstol"`    // line search: tolerance on ratio |G(s)/G(0)| with G(s) = δybᵀ.fb(yb + s.δyb) (energy)
+	LSc1     float64 `json:"lsc1"`     // line search: coefficient of sufficient decrease of fbᵀ.fb (backtracking)
+	LSsmin   float64 `json:"lssmin"`   // line search: min step length
+	LSsmax   float64 `json:"lssmax"`   // line search: max step length
+	QNmethod string  `json:"qnmethod"` // quasi-Newton update: "" => none (Newton or CteTg), "bfgs" or "broyden"
+	QNnmax   int     `json:"qnnmax"`   // quasi-Newton: max number of updates before Kb is assembled again
+
 	// Richardson's extrapolation
 	REnogus  bool    `json:"renogus"`  // Richardson extrapolation: no Gustafsson's step control
 	REnssmax int     `json:"renssmax"` // Richardson extrapolation: max number of substeps
@@ -72,17 +94,56 @@ type SolverData struct {
 	REmmin   float64 `json:"remmin"`   // Richardson extrapolation: min multiplier
 	REmmax   float64 `json:"remmax"`   // Richardson extrapolation: max multiplier
 
+	// automatic time stepping (implicit solver)
+	//  Note: Δt is adapted using the number of Newton iterations only; i.e. there is no estimate
+	//        of the time discretisation error. Use Richardson's extrapolation for error control
+	Adapt  bool    `json:"adapt"`  // automatic time stepping: Δt changes according to the number of iterations
+	ADnopt int     `json:"adnopt"` // automatic time stepping: desired number of iterations
+	ADmmin float64 `json:"admmin"` // automatic time stepping: min multiplier of Δt
+	ADmmax float64 `json:"admmax"` // automatic time stepping: max multiplier of Δt
+
+	// explicit solver
+	EXfcrit  float64 `json:"exfcrit"`  // explicit solver: factor multiplying the critical time step; i.e. Δt ≤ EXfcrit * Δtcr
+	EXnocrit bool    `json:"exnocrit"` // explicit solver: do not limit Δt by the estimated critical time step
+
+	// arc-length method
+	ALcyl     bool    `json:"alcyl"`     // arc-length: use cylindrical constraint; otherwise spherical
+	ALpsi     float64 `json:"alpsi"`     // arc-length: scaling factor ψ of load term in spherical constraint
+	ALdl      float64 `json:"aldl"`      // arc-length: initial arc-length; 0 => computed from first increment Δt of load factor
+	ALnopt    int     `json:"alnopt"`    // arc-length: desired number of iterations (to adapt arc-length)
+	ALmmin    float64 `json:"almmin"`    // arc-length: min multiplier of initial arc-length
+	ALmmax    float64 `json:"almmax"`    // arc-length: max multiplier of initial arc-length
+	ALnsmax   int     `json:"alnsmax"`   // arc-length: max number of steps
+	ALctrlVid int     `json:"alctrlvid"` // arc-length: vertex id of control DOF
+	ALctrlKey string  `json:"alctrlkey"` // arc-length: key of control DOF; e.g. "uy". "" => no control DOF
+	ALctrlMax float64 `json:"alctrlmax"` // arc-length: stop when |control DOF| reaches this value (if > 0)
+
+	// modal and buckling analyses
+	MDnmodes int     `json:"mdnmodes"` // modal/buckling analyses: number of modes
+	MDnsub   int     `json:"mdnsub"`   // modal/buckling analyses: number of vectors in subspace; 0 => min(2*nmodes, nmodes+8)
+	MDtol    float64 `json:"mdtol"`    // modal/buckling analyses: relative tolerance for convergence of eigenvalues
+	MDnmaxIt int     `json:"mdnmaxit"` // modal/buckling analyses: max number of subspace iterations
+	BKprev   bool    `json:"bkprev"`   // buckling analysis: use the state of previous stage as reference state; otherwise solve static problem
+
+	// essential boundary conditions
+	Spcs    string  `json:"spcs"`    // single-point constraints: "" or "lagrange" => Lagrange multipliers, "elim" => elimination or "penalty"
+	Penalty float64 `json:"penalty"` // penalty method: factor multiplying the largest diagonal entry of Kb to obtain the penalty coefficient
+
 	// transient analyses
 	DtMin      float64 `json:"dtmin"`      // minium value of Dt for transient (θ and Newmark / Dyn coefficients)
+	DtMax      float64 `json:"dtmax"`      // maximum value of Dt with automatic time stepping; 0 => no limit
 	Theta      float64 `json:"theta"`      // θ-method
 	ThGalerkin bool    `json:"thgalerkin"` // use θ = 2/3
 	ThLiniger  bool    `json:"thliniger"`  // use θ = 0.878
+	BDF2       bool    `json:"bdf2"`       // use variable-step second-order backward differentiation formula (BDF2) for t1 variables instead of θ-method
 
 	// dynamics
-	Theta1 float64 `json:"theta1"` // Newmark's method parameter
-	Theta2 float64 `json:"theta2"` // Newmark's method parameter
-	HHT    bool    `json:"hht"`    // use Hilber-Hughes-Taylor method
-	HHTalp float64 `json:"hhtalp"` // HHT α parameter
+	Theta1   float64 `json:"theta1"`   // Newmark's method parameter
+	Theta2   float64 `json:"theta2"`   // Newmark's method parameter
+	HHT      bool    `json:"hht"`      // use Hilber-Hughes-Taylor method
+	HHTalp   float64 `json:"hhtalp"`   // HHT α parameter
+	GenAlpha bool    `json:"genalpha"` // use generalized-α method (Chung-Hulbert)
+	GArhoInf float64 `json:"garhoinf"` // generalized-α: spectral radius at infinite frequency ρ∞ [0 ≤ ρ∞ ≤ 1]
 
 	// combination of coefficients
 	ThCombo1 bool `json:"thcombo1"` // use θ=2/3, θ1=5/6 and θ2=8/9 to avoid oscillations
@@ -148,6 +209,43 @@ type NodeBc struct {
 	Extra string   `json:"extra"` // extra information. ex: '!λl:10'
 }
 
+// Mpc holds data for one linear multi-point constraint: Σ coefs[i] * y(tags[i], keys[i]) = f(t)
+type Mpc struct {
+	Tags  []int     `json:"tags"`  // tags of nodes; each tag must correspond to one vertex only
+	Keys  []string  `json:"keys"`  // dof keys; one for each tag. ex: ux, uy, pl
+	Coefs []float64 `json:"coefs"` // coefficients; one for each tag
+	Func  string    `json:"func"`  // name of function f(t) on the right-hand side. ex: zero
+}
+
+// PeriodicData holds data for periodic boundary conditions between pairs of opposite faces:
+//   y(x⁺) - y(x⁻) = f(t) ⋅ ε̄ ⋅ (x⁺ - x⁻)    (the right-hand side is zero for non-displacement keys)
+//  Note: vertices on "plus" faces are tied to vertices on "minus" faces; thus, one vertex that is
+//        never on a "plus" face must be fixed to remove rigid body translations
+type PeriodicData struct {
+	Pairs [][]int   `json:"pairs"` // pairs of face tags: [[plus, minus], ...]; e.g. [[-11,-13], [-12,-10]]
+	Keys  []string  `json:"keys"`  // dof keys to be constrained; default: ux, uy (, uz)
+	Eps   []float64 `json:"eps"`   // macroscopic strain ε̄: [εxx, εyy, εzz, εxy (, εyz, εzx)]; tensor (not engineering) components
+	Func  string    `json:"func"`  // name of function f(t) multiplying ε̄; default: constant equal to 1
+	Tol   float64   `json:"tol"`   // tolerance to match vertices on opposite faces; default: 1e-8
+}
+
+// TieData holds data for tying a slave surface of one region to a master surface of another
+// region. Each vertex on the slave surface is constrained to follow the master surface:
+//   y(xˢ) - Σ Nᵐ(xˢ) ⋅ yᵐ = 0    (node-to-surface tie)
+//  Note: meshes do not need to be conforming along the interface
+type TieData struct {
+	Mreg int      `json:"mreg"` // index of region with master surface
+	Mtag int      `json:"mtag"` // face tag of master surface
+	Sreg int      `json:"sreg"` // index of region with slave surface
+	Stag int      `json:"stag"` // face tag of slave surface; default: the same as mtag
+	Keys []string `json:"keys"` // dof keys to be tied; default: ux, uy (, uz)
+	Tol  float64  `json:"tol"`  // tolerance (natural coordinates) to locate slave vertices on master faces; default: 1e-8
+
+	// derived
+	SlaveVerts  []int        `json:"-"` // ids of vertices on slave surface (merged mesh)
+	MasterFaces []CellFaceId `json:"-"` // cells and local face ids of master surface (merged mesh)
+}
+
 // EleCond holds element condition
 type EleCond struct {
 	Tag   int      `json:"tag"`   // tag of cell/element
@@ -191,6 +289,10 @@ type IniStressData struct {
 // InitialData holds data for setting initial solution values such as Y, dYdt and d2Ydt2
 type InitialData struct {
 	File string   `json:"file"` // file with values at each node is given; filename with path is provided
+	Fmt  string   `json:"fmt"`  // format of file: "csv" or "gob"/"json" (nodes file from results); default: from extension
+	Map  string   `json:"map"`  // csv: mapping of values: "id", "nearest" or "interp"; default: "id" if column "id" is given; "nearest" otherwise
+	Sim  string   `json:"sim"`  // gob/json: simulation (.sim) that wrote the results file
+	Stg  int      `json:"stg"`  // gob/json: index of stage that wrote the results file
 	Fcns []string `json:"fcns"` // functions F(t, x) are given; from functions database
 	Dofs []string `json:"dofs"` // degrees of freedom corresponding to "fcns"
 }
@@ -209,8 +311,8 @@ type Stage struct {
 	Desc       string `json:"desc"`       // description of simulation stage. ex: activation of top layer
 	Activate   []int  `json:"activate"`   // array of tags of elements to be activated
 	Deactivate []int  `json:"deactivate"` // array of tags of elements to be deactivated
-	Save       bool   `json:"save"`       // save stage data to binary file
-	Load       string `json:"load"`       // load stage data (filename) from binary file
+	Save       bool   `json:"save"`       // save stage data to binary file; e.g. dirout/fnkey_stg0_p0_d0.ckp
+	Load       string `json:"load"`       // load stage data (filename) from binary file; key with path; e.g. dirout/fnkey_stg0
 	Skip       bool   `json:"skip"`       // do not run stage
 
 	// specific problems data
@@ -222,10 +324,12 @@ type Stage struct {
 	Initial   *InitialData   `json:"initial"`   // set initial solution values such as Y, dYdt and d2Ydt2
 
 	// conditions
-	EleConds []*EleCond `json:"eleconds"` // element conditions. ex: gravity or beam distributed loads
-	FaceBcs  []*FaceBc  `json:"facebcs"`  // face boundary conditions
-	SeamBcs  []*SeamBc  `json:"seambcs"`  // seam (3D) boundary conditions
-	NodeBcs  []*NodeBc  `json:"nodebcs"`  // node boundary conditions
+	EleConds []*EleCond    `json:"eleconds"` // element conditions. ex: gravity or beam distributed loads
+	FaceBcs  []*FaceBc     `json:"facebcs"`  // face boundary conditions
+	SeamBcs  []*SeamBc     `json:"seambcs"`  // seam (3D) boundary conditions
+	NodeBcs  []*NodeBc     `json:"nodebcs"`  // node boundary conditions
+	Mpcs     []*Mpc        `json:"mpcs"`     // multi-point constraints
+	Periodic *PeriodicData `json:"periodic"` // periodic boundary conditions
 
 	// timecontrol
 	Control TimeControl `json:"control"` // time control
@@ -239,6 +343,7 @@ type Simulation struct {
 	Functions FuncsData  `json:"functions"` // stores all boundary condition functions
 	PlotF     *PlotFdata `json:"plotf"`     // plot functions
 	Regions   []*Region  `json:"regions"`   // stores all regions
+	Ties      []*TieData `json:"ties"`      // ties between regions; regions are then merged into one
 	LinSol    LinSolData `json:"linsol"`    // linear solver data
 	Solver    SolverData `json:"solver"`    // FEM solver data
 	Stages    []*Stage   `json:"stages"`    // stores all stages
@@ -381,6 +486,14 @@ func ReadSim(simfilepath, alias string, erasefiles bool, goroutineId int) *Simul
 		}
 	}
 
+	// tied regions
+	if len(o.Ties) > 0 {
+		err = o.merge_regions()
+		if err != nil {
+			chk.Panic("ReadSim: cannot merge tied regions:\n%v", err)
+		}
+	}
+
 	// water level
 	o.WaterLevel = utl.Max(o.Data.Wlevel, o.MaxElev)
 
@@ -518,6 +631,10 @@ func (o *LinSolData) SetDefault() {
 	o.Name = "umfpack"
 	o.Ordering = "amf"
 	o.Scaling = "rcit"
+	o.Precond = "jacobi"
+	o.ItTol = 1e-10
+	o.ItMaxIt = 5000
+	o.ItRestart = 50
 }
 
 // SetDefault set defaults values
@@ -532,6 +649,14 @@ func (o *SolverData) SetDefault() {
 	o.FbMin = 1e-14
 	o.NdvgMax = 20
 
+	// line search and quasi-Newton methods
+	o.LSnmaxIt = 10
+	o.LStol = 0.8
+	o.LSc1 = 1e-4
+	o.LSsmin = 0.1
+	o.LSsmax = 2.0
+	o.QNnmax = 20
+
 	// Richardson's extrapolation
 	o.REnssmax = 10000
 	o.REatol = 1e-6
@@ -540,6 +665,29 @@ func (o *SolverData) SetDefault() {
 	o.REmmin = 0.1
 	o.REmmax = 2.0
 
+	// automatic time stepping
+	o.ADnopt = 5
+	o.ADmmin = 0.5
+	o.ADmmax = 2.0
+
+	// explicit solver
+	o.EXfcrit = 0.9
+
+	// arc-length method
+	o.ALpsi = 1.0
+	o.ALnopt = 5
+	o.ALmmin = 0.001
+	o.ALmmax = 10.0
+	o.ALnsmax = 1000
+
+	// modal and buckling analyses
+	o.MDnmodes = 5
+	o.MDtol = 1e-10
+	o.MDnmaxIt = 100
+
+	// essential boundary conditions
+	o.Penalty = 1e8
+
 	// transient analyses
 	o.DtMin = 1e-8
 	o.Theta = 0.5
@@ -548,6 +696,7 @@ func (o *SolverData) SetDefault() {
 	o.Theta1 = 0.5
 	o.Theta2 = 0.5
 	o.HHTalp = 0.5
+	o.GArhoInf = 0.8
 
 	// constants
 	o.Eps = 1e-16
@@ -569,6 +718,29 @@ func (o *SolverData) PostProcess() {
 		o.Theta2 = 8.0 / 9.0
 	}
 
+	// line search and quasi-Newton methods
+	switch o.LSearch {
+	case "", "energy", "back":
+	default:
+		chk.Panic("line search method %q is not available. options: \"energy\" or \"back\"", o.LSearch)
+	}
+	switch o.QNmethod {
+	case "", "bfgs", "broyden":
+	default:
+		chk.Panic("quasi-Newton method %q is not available. options: \"bfgs\" or \"broyden\"", o.QNmethod)
+	}
+
+	// essential boundary conditions
+	switch o.Spcs {
+	case "", "lagrange":
+	case "elim", "penalty":
+		if o.Type != "imp" && o.Type != "lin-imp" && o.Type != "rex" {
+			chk.Panic("single-point constraints by %q are available with the \"imp\", \"lin-imp\" and \"rex\" solvers only", o.Spcs)
+		}
+	default:
+		chk.Panic("method %q for single-point constraints is not available. options: \"lagrange\", \"elim\" or \"penalty\"", o.Spcs)
+	}
+
 	// iterations tolerance
 	o.Itol = utl.Max(10.0*o.Eps/o.Rtol, utl.Min(0.01, math.Sqrt(o.Rtol)))
 }
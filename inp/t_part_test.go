@@ -0,0 +1,111 @@
+// Copyright 2015 Dorival Pedroso and Raul Durand. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package inp
+
+import (
+	"testing"
+
+	"github.com/cpmech/gosl/chk"
+	"github.com/cpmech/gosl/io"
+	"github.com/cpmech/gosl/utl"
+)
+
+// check_partitions checks whether all partitions are non-empty, balanced and connected
+func check_partitions(tst *testing.T, msh *Mesh, nparts int) {
+	if !msh.PartitionsOk(nparts) {
+		tst.Errorf("partitions are not ok. nparts = %d, len(Part2cells) = %d\n", nparts, len(msh.Part2cells))
+		return
+	}
+	adj := msh.DualGraph()
+	nmin, nmax := len(msh.Cells), 0
+	for p := 0; p < nparts; p++ {
+		cells := msh.Part2cells[p]
+		nmin = utl.Imin(nmin, len(cells))
+		nmax = utl.Imax(nmax, len(cells))
+		visited := map[int]bool{cells[0].Id: true}
+		queue := []int{cells[0].Id}
+		for len(queue) > 0 {
+			i := queue[0]
+			queue = queue[1:]
+			for _, j := range adj[i] {
+				if msh.Cells[j].Part == p && !visited[j] {
+					visited[j] = true
+					queue = append(queue, j)
+				}
+			}
+		}
+		if len(visited) != len(cells) {
+			tst.Errorf("partition %d is not connected\n", p)
+		}
+	}
+	io.Pforan("nparts = %d: min/max number of cells = %d/%d\n", nparts, nmin, nmax)
+	if nmax-nmin > 1 {
+		tst.Errorf("partitions are not balanced: min/max number of cells = %d/%d\n", nmin, nmax)
+	}
+}
+
+func Test_part01(tst *testing.T) {
+
+	//verbose()
+	chk.PrintTitle("part01. dual graph")
+
+	msh, err := ReadMsh("data", "frees01.msh", 0)
+	if err != nil {
+		tst.Errorf("test failed:\n%v", err)
+		return
+	}
+
+	// 3 x 5 grid of qua8 cells
+	adj := msh.DualGraph()
+	chk.Ints(tst, "neighs of  0", adj[0], []int{1, 3})
+	chk.Ints(tst, "neighs of  4", adj[4], []int{1, 3, 5, 7})
+	chk.Ints(tst, "neighs of 14", adj[14], []int{11, 13})
+}
+
+func Test_part02(tst *testing.T) {
+
+	//verbose()
+	chk.PrintTitle("part02. recursive bisection")
+
+	msh, err := ReadMsh("data", "frees01.msh", 0)
+	if err != nil {
+		tst.Errorf("test failed:\n%v", err)
+		return
+	}
+	chk.IntAssert(len(msh.Part2cells), 2)
+
+	// partitions
+	for nparts := 1; nparts <= 5; nparts++ {
+		err = msh.Partition(nparts)
+		if err != nil {
+			tst.Errorf("Partition failed:\n%v", err)
+			return
+		}
+		check_partitions(tst, msh, nparts)
+	}
+	if msh.Partition(16) == nil {
+		tst.Errorf("Partition should have failed with more partitions than cells\n")
+	}
+
+	// write and read back
+	err = msh.Partition(3)
+	if err != nil {
+		tst.Errorf("Partition failed:\n%v", err)
+		return
+	}
+	io.WriteFileSD("/tmp/gofem/inp", "test_part02.msh", msh.String())
+	res, err := ReadMsh("/tmp/gofem/inp", "test_part02.msh", 0)
+	if err != nil {
+		tst.Errorf("cannot read partitioned mesh:\n%v", err)
+		return
+	}
+	chk.IntAssert(len(res.Cells), len(msh.Cells))
+	for i, c := range res.Cells {
+		chk.IntAssert(c.Part, msh.Cells[i].Part)
+		chk.Ints(tst, io.Sf("verts of %d", i), c.Verts, msh.Cells[i].Verts)
+		chk.Ints(tst, io.Sf("ftags of %d", i), c.FTags, msh.Cells[i].FTags)
+	}
+	check_partitions(tst, res, 3)
+}
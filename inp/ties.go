@@ -0,0 +1,119 @@
+// Copyright 2015 Dorival Pedroso and Raul Durand. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package inp
+
+import (
+	"strings"
+
+	"github.com/cpmech/gosl/chk"
+)
+
+// merge_regions merges all regions into a single one and resolves the surfaces of ties
+//  Note: (1) ids of vertices and cells of region i are shifted by the total number of vertices
+//            and cells in regions 0, 1, ..., i-1, respectively
+//        (2) vertex, cell, face and seam tags are kept; thus, boundary conditions given by tags
+//            apply to all regions where these tags are found
+//        (3) regions may share element tags only if the corresponding element data are the same
+//        (4) meshes with NURBS cannot be merged
+func (o *Simulation) merge_regions() (err error) {
+
+	// offsets
+	nreg := len(o.Regions)
+	voffset := make([]int, nreg)
+	coffset := make([]int, nreg)
+	for i, reg := range o.Regions {
+		if len(reg.Msh.Nurbss) > 0 {
+			return chk.Err("mesh of region %d has NURBS and cannot be merged", i)
+		}
+		if i > 0 {
+			voffset[i] = voffset[i-1] + len(o.Regions[i-1].Msh.Verts)
+			coffset[i] = coffset[i-1] + len(o.Regions[i-1].Msh.Cells)
+		}
+	}
+
+	// surfaces of ties; cells are kept by the merged mesh and thus CellFaceId remains valid
+	for k, tie := range o.Ties {
+		if tie.Mreg < 0 || tie.Mreg >= nreg || tie.Sreg < 0 || tie.Sreg >= nreg {
+			return chk.Err("tie %d: indices of regions are invalid. mreg=%d, sreg=%d, nregions=%d", k, tie.Mreg, tie.Sreg, nreg)
+		}
+		if tie.Mreg == tie.Sreg {
+			return chk.Err("tie %d: master and slave surfaces must belong to different regions. mreg=sreg=%d", k, tie.Mreg)
+		}
+		if tie.Stag == 0 {
+			tie.Stag = tie.Mtag
+		}
+		faces, okm := o.Regions[tie.Mreg].Msh.FaceTag2cells[tie.Mtag]
+		verts, oks := o.Regions[tie.Sreg].Msh.FaceTag2verts[tie.Stag]
+		if !okm || !oks {
+			return chk.Err("tie %d: cannot find master faces with tag = %d in region %d or slave faces with tag = %d in region %d", k, tie.Mtag, tie.Mreg, tie.Stag, tie.Sreg)
+		}
+		tie.MasterFaces = make([]CellFaceId, len(faces))
+		copy(tie.MasterFaces, faces)
+		tie.SlaveVerts = make([]int, len(verts))
+		for j, v := range verts {
+			tie.SlaveVerts[j] = v + voffset[tie.Sreg]
+		}
+	}
+
+	// merged region
+	var descs, files []string
+	merged := &Region{etag2idx: make(map[int]int)}
+	msh := new(Mesh)
+	for i, reg := range o.Regions {
+
+		// vertices
+		for _, v := range reg.Msh.Verts {
+			v.Id += voffset[i]
+			msh.Verts = append(msh.Verts, v)
+		}
+
+		// cells
+		for _, c := range reg.Msh.Cells {
+			c.Id += coffset[i]
+			for j := 0; j < len(c.Verts); j++ {
+				c.Verts[j] += voffset[i]
+			}
+			for j := 0; j < len(c.Neighs); j++ {
+				if c.Neighs[j] >= 0 {
+					c.Neighs[j] += coffset[i]
+				}
+			}
+			if c.IsJoint {
+				c.JlinId += coffset[i]
+				c.JsldId += coffset[i]
+			}
+			msh.Cells = append(msh.Cells, c)
+		}
+
+		// elements data
+		for _, ed := range reg.ElemsData {
+			if idx, ok := merged.etag2idx[ed.Tag]; ok {
+				prev := merged.ElemsData[idx]
+				if prev.Mat != ed.Mat || prev.Type != ed.Type || prev.Nip != ed.Nip || prev.Nipf != ed.Nipf || prev.Extra != ed.Extra || prev.Inact != ed.Inact {
+					return chk.Err("element tag = %d is used in more than one region with different element data", ed.Tag)
+				}
+				continue
+			}
+			merged.etag2idx[ed.Tag] = len(merged.ElemsData)
+			merged.ElemsData = append(merged.ElemsData, ed)
+		}
+		descs = append(descs, reg.Desc)
+		files = append(files, reg.Mshfile)
+	}
+
+	// derived data of merged mesh
+	msh.FnamePath = o.Regions[0].Msh.FnamePath
+	err = msh.init(o.GoroutineId)
+	if err != nil {
+		return
+	}
+
+	// set merged region
+	merged.Desc = strings.Join(descs, " + ")
+	merged.Mshfile = strings.Join(files, " + ")
+	merged.Msh = msh
+	o.Regions = []*Region{merged}
+	return
+}
@@ -0,0 +1,120 @@
+// Copyright 2015 Dorival Pedroso and Raul Durand. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package msolid
+
+import (
+	"math"
+
+	"github.com/cpmech/gosl/chk"
+	"github.com/cpmech/gosl/fun"
+	"github.com/cpmech/gosl/la"
+	"github.com/cpmech/gosl/tsr"
+)
+
+// NeoHooke implements a compressible neo-Hookean hyperelastic model for large deformations
+//  Note: (1) the Kirchhoff stress is τ = G (b - I) + l ln(J) I where b = F⋅Fᵀ and J = det(F)
+//        (2) the small strain limit corresponds to linear elasticity with the same l and G
+//        (3) parameters are given as in SmallElasticity; e.g. {E,nu}, {l,G}, {K,G} or {K,nu}
+type NeoHooke struct {
+	SmallElasticity
+
+	// auxiliary
+	Fi [][]float64 // inverse of F [3][3]
+	b  [][]float64 // left Cauchy-Green deformation [3][3]
+	σ  [][]float64 // Cauchy stress [3][3]
+}
+
+// add model to factory
+func init() {
+	allocators["neo-hooke"] = func() Model { return new(NeoHooke) }
+}
+
+// Clean clean resources
+func (o *NeoHooke) Clean() {
+}
+
+// Init initialises model
+func (o *NeoHooke) Init(ndim int, pstress bool, prms fun.Prms) (err error) {
+	if pstress {
+		return chk.Err("neo-hooke model does not work with plane-stress analyses\n")
+	}
+	err = o.SmallElasticity.Init(ndim, pstress, prms)
+	if err != nil {
+		return
+	}
+	if o.Kgc != nil {
+		return chk.Err("neo-hooke model does not work with nonlinear K and G\n")
+	}
+	o.Fi = tsr.Alloc2()
+	o.b = tsr.Alloc2()
+	o.σ = tsr.Alloc2()
+	return
+}
+
+// GetPrms gets (an example) of parameters
+func (o NeoHooke) GetPrms() fun.Prms {
+	return o.SmallElasticity.GetPrms()
+}
+
+// InitIntVars initialises internal (secondary) variables
+//  Note: initial stresses are not allowed because the reference configuration is stress free
+func (o NeoHooke) InitIntVars(σ []float64) (s *State, err error) {
+	for _, v := range σ {
+		if math.Abs(v) > 0 {
+			return nil, chk.Err("neo-hooke model does not accept initial stresses. σ=%v is invalid\n", σ)
+		}
+	}
+	s = NewState(o.Nsig, 0, true, false)
+	for i := 0; i < 3; i++ {
+		s.F[i][i] = 1
+	}
+	return
+}
+
+// Update updates stresses for new deformation F and FΔ
+//  Note: the model is hyperelastic; thus the increment of deformation FΔ is not used
+func (o *NeoHooke) Update(s *State, F, FΔ [][]float64) (err error) {
+	J, err := tsr.Inv(o.Fi, F)
+	if err != nil {
+		return
+	}
+	if J <= 0 {
+		return chk.Err("neo-hooke: determinant of deformation gradient must be positive. J=%g is invalid\n", J)
+	}
+	tsr.LeftCauchyGreenDef(o.b, F)
+	lnJ := math.Log(J)
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			o.σ[i][j] = o.G * o.b[i][j] / J
+		}
+		o.σ[i][i] += (o.L*lnJ - o.G) / J
+	}
+	tsr.Ten2Man(s.Sig, o.σ)
+	la.MatCopy(s.F, 1, F)
+	return
+}
+
+// CalcA computes the spatial tangent modulus A_ijkl = F_jJ F_lL ∂P_iJ/∂F_kL / J
+//  Note: A = c/J + I ⊠ σ where c is the spatial elasticity tensor of the Kirchhoff stress:
+//          A_ijkl = (l/J) δij δkl + ((G - l ln(J))/J) (δik δjl + δil δjk) + δik σjl
+func (o *NeoHooke) CalcA(A [][][][]float64, s *State, firstIt bool) (err error) {
+	J, err := tsr.Inv(o.Fi, s.F)
+	if err != nil {
+		return
+	}
+	lnJ := math.Log(J)
+	cl := o.L / J
+	cg := (o.G - o.L*lnJ) / J
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			for k := 0; k < 3; k++ {
+				for l := 0; l < 3; l++ {
+					A[i][j][k][l] = cl*tsr.It[i][j]*tsr.It[k][l] + cg*(tsr.It[i][k]*tsr.It[j][l]+tsr.It[i][l]*tsr.It[j][k]) + tsr.It[i][k]*tsr.M2T(s.Sig, j, l)
+				}
+			}
+		}
+	}
+	return
+}
@@ -87,8 +87,8 @@ func (o Ogden) InitIntVars(σ []float64) (s *State, err error) {
 	return
 }
 
-// Update updates stresses for given strains
-func (o *Ogden) Update(s *State, F [][]float64) (err error) {
+// Update updates stresses for new deformation F and FΔ
+func (o *Ogden) Update(s *State, F, FΔ [][]float64) (err error) {
 
 	// TODO
 	return chk.Err("Ogden model is not implemented yet")
@@ -47,7 +47,7 @@ type Small interface {
 // Large defines rate type solid models for large deformation analyses
 type Large interface {
 	Update(s *State, F, FΔ [][]float64) error              // updates stresses for new deformation F and FΔ
-	CalcA(A [][][][]float64, s *State, firstIt bool) error // computes tangent modulus A = (2/J) * ∂τ/∂b . b - σ palm I
+	CalcA(A [][][][]float64, s *State, firstIt bool) error // computes spatial tangent modulus A_ijkl = F_jJ F_lL ∂P_iJ/∂F_kL / J
 }
 
 // SmallStrainUpdater define small-strain models that can update strains for given stresses
@@ -0,0 +1,154 @@
+// Copyright 2015 Dorival Pedroso and Raul Durand. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package msolid
+
+import (
+	"testing"
+
+	"github.com/cpmech/gosl/chk"
+	"github.com/cpmech/gosl/fun"
+	"github.com/cpmech/gosl/io"
+	"github.com/cpmech/gosl/la"
+	"github.com/cpmech/gosl/num"
+	"github.com/cpmech/gosl/tsr"
+)
+
+func Test_neohooke01(tst *testing.T) {
+
+	//verbose()
+	chk.PrintTitle("neohooke01. small strain limit")
+
+	// model
+	var m NeoHooke
+	err := m.Init(3, false, []*fun.Prm{
+		&fun.Prm{N: "E", V: 1000},
+		&fun.Prm{N: "nu", V: 0.25},
+	})
+	if err != nil {
+		tst.Errorf("Init failed: %v\n", err)
+		return
+	}
+	s, err := m.InitIntVars(make([]float64, 6))
+	if err != nil {
+		tst.Errorf("InitIntVars failed: %v\n", err)
+		return
+	}
+	chk.Matrix(tst, "F0", 1e-17, s.F, tsr.It)
+
+	// small deformation
+	ε := 1e-7
+	F := [][]float64{
+		{1 + ε, 2 * ε, 0},
+		{0, 1 - 3*ε, ε},
+		{0, 0, 1 + 2*ε},
+	}
+	err = m.Update(s, F, F)
+	if err != nil {
+		tst.Errorf("Update failed: %v\n", err)
+		return
+	}
+
+	// linear elasticity
+	var lin LinElast
+	lin.Init(3, false, []*fun.Prm{
+		&fun.Prm{N: "E", V: 1000},
+		&fun.Prm{N: "nu", V: 0.25},
+	})
+	sl, _ := lin.InitIntVars(make([]float64, 6))
+	εt := tsr.Alloc2()
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			εt[i][j] = (F[i][j]+F[j][i])/2.0 - tsr.It[i][j]
+		}
+	}
+	Δε := make([]float64, 6)
+	tsr.Ten2Man(Δε, εt)
+	lin.Update(sl, Δε, Δε, 0, 0, 0)
+	io.Pforan("σ(neo) = %v\n", s.Sig)
+	io.Pforan("σ(lin) = %v\n", sl.Sig)
+	chk.Vector(tst, "σ", 1e-9, s.Sig, sl.Sig)
+}
+
+func Test_neohooke02(tst *testing.T) {
+
+	//verbose()
+	chk.PrintTitle("neohooke02. spatial tangent modulus")
+
+	// model
+	var m NeoHooke
+	err := m.Init(3, false, []*fun.Prm{
+		&fun.Prm{N: "l", V: 80},
+		&fun.Prm{N: "G", V: 50},
+	})
+	if err != nil {
+		tst.Errorf("Init failed: %v\n", err)
+		return
+	}
+	s, _ := m.InitIntVars(make([]float64, 6))
+
+	// deformation gradient
+	F := [][]float64{
+		{1.3, 0.2, -0.1},
+		{0.1, 0.8, 0.15},
+		{-0.2, 0.05, 1.1},
+	}
+	err = m.Update(s, F, F)
+	if err != nil {
+		tst.Errorf("Update failed: %v\n", err)
+		return
+	}
+	A := tsr.Alloc4()
+	err = m.CalcA(A, s, true)
+	if err != nil {
+		tst.Errorf("CalcA failed: %v\n", err)
+		return
+	}
+
+	// first Piola-Kirchhoff stress P = J σ F⁻ᵀ
+	stmp, _ := m.InitIntVars(make([]float64, 6))
+	Ftmp := tsr.Alloc2()
+	Fi := tsr.Alloc2()
+	P := func(i, I int) float64 {
+		m.Update(stmp, Ftmp, Ftmp)
+		J, _ := tsr.Inv(Fi, Ftmp)
+		var res float64
+		for k := 0; k < 3; k++ {
+			res += J * tsr.M2T(stmp.Sig, i, k) * Fi[I][k]
+		}
+		return res
+	}
+
+	// check: A_ijkl = F_jJ F_lL ∂P_iJ/∂F_kL / J
+	J, _ := tsr.Inv(Fi, F)
+	dPdF := tsr.Alloc4()
+	for i := 0; i < 3; i++ {
+		for I := 0; I < 3; I++ {
+			for k := 0; k < 3; k++ {
+				for L := 0; L < 3; L++ {
+					dPdF[i][I][k][L], _ = num.DerivCentral(func(x float64, args ...interface{}) float64 {
+						la.MatCopy(Ftmp, 1, F)
+						Ftmp[k][L] = x
+						return P(i, I)
+					}, F[k][L], 1e-6)
+				}
+			}
+		}
+	}
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			for k := 0; k < 3; k++ {
+				for l := 0; l < 3; l++ {
+					var anum float64
+					for I := 0; I < 3; I++ {
+						for L := 0; L < 3; L++ {
+							anum += F[j][I] * F[l][L] * dPdF[i][I][k][L] / J
+						}
+					}
+					chk.AnaNum(tst, io.Sf("A%d%d%d%d", i, j, k, l), 1e-6, A[i][j][k][l], anum, chk.Verbose)
+				}
+			}
+		}
+	}
+}
@@ -4,7 +4,10 @@
 
 package out
 
-import "github.com/cpmech/gosl/utl"
+import (
+	"github.com/cpmech/gosl/chk"
+	"github.com/cpmech/gosl/utl"
+)
 
 // CombineEidIps combines eids and ips(ids)
 func CombineEidIps(eids, ips []int) (eids_ips [][]int) {
@@ -39,3 +42,15 @@ func ParseKey(key string) (string, int) {
 	}
 	return key, 0
 }
+
+// sum_reactions sums reactions corresponding to key over vertices at all selected output times
+func sum_reactions(key string, vids []int) (res []float64) {
+	res = make([]float64, len(Reacts))
+	for i, react := range Reacts {
+		if react == nil {
+			chk.Panic("reactions are not available at output time %g", Times[i])
+		}
+		res[i] = Dom.SumReactions(react, key, vids)
+	}
+	return
+}
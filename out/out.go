@@ -43,6 +43,7 @@ var (
 	Results  ResultsMap            // maps labels => points
 	TimeInds []int                 // selected output indices
 	Times    []float64             // selected output times
+	Reacts   [][]float64           // [nTimes][ny] reactions at selected output times; nil if not saved
 
 	// extrapolated values
 	Extrap []string             // keys to be extrapolated; e.g. []string{"nwlx", "nwly"}
@@ -82,6 +83,7 @@ func Start(simfnpath string, stageIdx, regionIdx int) {
 	Results = make(map[string]Points)
 	TimeInds = make([]int, 0)
 	Times = make([]float64, 0)
+	Reacts = make([][]float64, 0)
 	Splots = make([]*SplotDat, 0)
 
 	// bins
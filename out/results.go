@@ -82,6 +82,14 @@ func LoadResults(times []float64) {
 			ComputeExtrapolatedValues(Extrap)
 		}
 
+		// reactions
+		var react []float64
+		if len(Dom.Sol.React) == Dom.Ny {
+			react = make([]float64, Dom.Ny)
+			copy(react, Dom.Sol.React)
+		}
+		Reacts = append(Reacts, react)
+
 		// for each point
 		for _, pts := range Results {
 			for _, p := range pts {
@@ -98,6 +106,9 @@ func LoadResults(times []float64) {
 					for _, dof := range nod.Dofs {
 						if dof != nil {
 							utl.StrDblsMapAppend(&p.Vals, dof.Key, Dom.Sol.Y[dof.Eq])
+							if react != nil {
+								utl.StrDblsMapAppend(&p.Vals, "R"+dof.Key, react[dof.Eq])
+							}
 						}
 					}
 
@@ -153,6 +164,45 @@ func GetRes(key, alias string, idxI int) []float64 {
 	return nil
 }
 
+// ReactionAtVerts returns the sum of reactions over vertices with a given tag at all selected
+// output times
+//  key  -- dof key; e.g. "ux", "uy" or "pl"
+//  vtag -- vertex tag
+func ReactionAtVerts(key string, vtag int) []float64 {
+	verts, ok := Dom.Msh.VertTag2verts[vtag]
+	if !ok {
+		chk.Panic("cannot find vertices with tag = %d to sum reactions", vtag)
+	}
+	vids := make([]int, len(verts))
+	for i, v := range verts {
+		vids[i] = v.Id
+	}
+	return sum_reactions(key, vids)
+}
+
+// ReactionOnFaces returns the sum of reactions over vertices on faces with a given tag at all
+// selected output times; e.g. the support resultant of a tagged boundary
+//  key  -- dof key; e.g. "ux", "uy" or "pl"
+//  ftag -- face tag
+func ReactionOnFaces(key string, ftag int) []float64 {
+	vids, ok := Dom.Msh.FaceTag2verts[ftag]
+	if !ok {
+		chk.Panic("cannot find faces with tag = %d to sum reactions", ftag)
+	}
+	return sum_reactions(key, vids)
+}
+
+// LoadPath returns the equilibrium path computed by the arc-length solver
+//  Output:
+//   λ    -- load factors at all converged steps (including the initial state)
+//   ctrl -- values of the control DOF at all converged steps; nil if no control DOF was given
+func LoadPath() (λ, ctrl []float64) {
+	if len(Sum.LoadFactors) == 0 {
+		chk.Panic("cannot get load path: summary has no load factors; was the arc-length solver used?")
+	}
+	return Sum.LoadFactors, Sum.CtrlVals
+}
+
 // GetIds return the ids corresponding to alias
 func GetIds(alias string) (vids, ipids []int) {
 	if pts, ok := Results[alias]; ok {
@@ -213,3 +213,89 @@ func Test_out02(tst *testing.T) {
 		sol.CheckDispl(tst, t, []float64{ux[j], uy[j]}, x, tolu)
 	}
 }
+
+func Test_out03(tst *testing.T) {
+
+	// finalise analysis process and catch errors
+	defer func() {
+		if err := recover(); err != nil {
+			tst.Fail()
+			io.PfRed("ERROR: %v\n", err)
+		}
+	}()
+
+	// test title
+	//verbose()
+	chk.PrintTitle("out03. reactions")
+
+	// start simulation
+	processing := fem.NewFEM("data/onequa4.sim", "", true, true, false, false, chk.Verbose, 0)
+
+	// run simulation
+	err := processing.Run()
+	if err != nil {
+		tst.Errorf("Run failed:\n%v", err)
+		return
+	}
+
+	// start post-processing
+	Start("data/onequa4.sim", 0, 0)
+
+	// define points
+	Define("A B", N{0, 1})
+
+	// load results
+	LoadResults(nil)
+	chk.IntAssert(len(Reacts), len(Times))
+
+	// support resultants: qnV=-100 on top and qnH=-50 on right side
+	Ry := ReactionOnFaces("uy", -10)
+	Rx := ReactionOnFaces("ux", -13)
+	io.Pforan("Ry = %v\n", Ry)
+	io.Pforan("Rx = %v\n", Rx)
+	chk.Vector(tst, "Ry", 1e-13, Ry, []float64{0, 100})
+	chk.Vector(tst, "Rx", 1e-13, Rx, []float64{0, 50})
+
+	// reactions at nodes
+	RyA := GetRes("Ruy", "A", 0)
+	RyB := GetRes("Ruy", "B", 0)
+	chk.Vector(tst, "RyA + RyB", 1e-13, []float64{RyA[1] + RyB[1]}, []float64{100})
+	chk.Vector(tst, "RxB", 1e-15, GetRes("Rux", "B", 0), []float64{0, 0})
+}
+
+func Test_out04(tst *testing.T) {
+
+	// finalise analysis process and catch errors
+	defer func() {
+		if err := recover(); err != nil {
+			tst.Fail()
+			io.PfRed("ERROR: %v\n", err)
+		}
+	}()
+
+	// test title
+	//verbose()
+	chk.PrintTitle("out04. load path of arc-length solver")
+
+	// start simulation
+	processing := fem.NewFEM("../fem/data/spo751arc.sim", "", true, true, false, false, chk.Verbose, 0)
+
+	// run simulation
+	err := processing.Run()
+	if err != nil {
+		tst.Errorf("Run failed:\n%v", err)
+		return
+	}
+
+	// start post-processing
+	Start("../fem/data/spo751arc.sim", 0, 0)
+
+	// load path
+	λ, ux := LoadPath()
+	io.Pforan("λ  = %v\n", λ)
+	io.Pforan("ux = %v\n", ux)
+	chk.IntAssert(len(ux), len(λ))
+	chk.Vector(tst, "λ", 1e-15, λ, processing.Summary.LoadFactors)
+	chk.Vector(tst, "ux", 1e-15, ux, processing.Summary.CtrlVals)
+	chk.Scalar(tst, "λ0", 1e-15, λ[0], 0)
+}
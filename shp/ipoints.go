@@ -83,7 +83,39 @@ func (o *Shape) GetIps(nips, nipf int) (ips, ipf []Ipoint, err error) {
 	return
 }
 
+// GetIpsBasic returns a set of integration points of a basic reference geometry
+//  Input:
+//   geo  -- "lin": Gauss-Legendre points on [-1, 1] with nips = 1, 2, 3 or 5
+//           "tri": points on the reference triangle with nips = 1, 3, 12 or 16 (Σ w = 1/2)
+//  Note: this is useful for sub-cell integration; e.g. cells crossed by discontinuities
+func GetIpsBasic(geo string, nips int) (ips []Ipoint, err error) {
+	switch geo + io.Sf("_%d", nips) {
+	case "lin_1":
+		ips = ips_lin_1
+	case "lin_2":
+		ips = ips_lin_2
+	case "lin_3":
+		ips = ips_lin_3
+	case "lin_5":
+		ips = ips_lin_5
+	case "tri_1":
+		ips = ips_tri_1
+	case "tri_3":
+		ips = ips_tri_3
+	case "tri_12":
+		ips = ips_tri_12
+	case "tri_16":
+		ips = ips_tri_16
+	default:
+		err = chk.Err("cannot find integration point set for basic geometry = %q and nips = %d\n", geo, nips)
+	}
+	return
+}
+
 var (
+	ips_lin_1 = []Ipoint{
+		Ipoint{0.0, 0.0, 0.0, 2.0},
+	}
 	ips_lin_2 = []Ipoint{
 		Ipoint{-math.Sqrt(3.0) / 3.0, 0.0, 0.0, 1.0},
 		Ipoint{math.Sqrt(3.0) / 3.0, 0.0, 0.0, 1.0},
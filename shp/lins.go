@@ -26,6 +26,7 @@ func init() {
 	lin2.init_scratchpad()
 	factory["lin2"] = &lin2
 	ipsfactory["lin2_0"] = ips_lin_2
+	ipsfactory["lin2_1"] = ips_lin_1
 	ipsfactory["lin2_2"] = ips_lin_2
 
 	// lin3
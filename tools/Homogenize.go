@@ -0,0 +1,62 @@
+// Copyright 2015 Dorival Pedroso and Raul Durand. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build ignore
+
+package main
+
+import (
+	"github.com/cpmech/gofem/fem"
+	"github.com/cpmech/gosl/io"
+	"github.com/cpmech/gosl/mpi"
+)
+
+func main() {
+
+	// catch errors
+	defer func() {
+		if err := recover(); err != nil {
+			if mpi.Rank() == 0 {
+				io.PfRed("ERROR: %v\n", err)
+			}
+		}
+		mpi.Stop(false)
+	}()
+	mpi.Start(false)
+
+	// input data
+	simfn, _ := io.ArgToFilename(0, "../fem/data/rve2lay", ".sim", true)
+	stgidx := io.ArgToInt(1, 0)
+	δ := io.ArgToFloat(2, 1e-4)
+	verbose := io.ArgToBool(3, false)
+
+	// print input table
+	if mpi.Rank() == 0 {
+		io.Pf("\n%s\n", io.ArgsTable(
+			"simulation filename", "simfn", simfn,
+			"stage with periodic bcs", "stgidx", stgidx,
+			"magnitude of macroscopic strain", "δ", δ,
+			"show messages of solver", "verbose", verbose,
+		))
+	}
+
+	// homogenisation
+	analysis := fem.NewFEM(simfn, "", true, false, false, true, verbose, 0)
+	C, err := analysis.Homogenize(stgidx, δ)
+	if err != nil {
+		io.PfRed("homogenisation failed:\n%v", err)
+		return
+	}
+
+	// results
+	if mpi.Rank() == 0 {
+		io.Pf("\neffective stiffness (Mandel basis):\n")
+		for i := 0; i < len(C); i++ {
+			for j := 0; j < len(C[i]); j++ {
+				io.Pf("%15g", C[i][j])
+			}
+			io.Pf("\n")
+		}
+	}
+}
@@ -0,0 +1,62 @@
+// Copyright 2015 Dorival Pedroso and Raul Durand. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build ignore
+
+package main
+
+import (
+	"path/filepath"
+
+	"github.com/cpmech/gofem/inp"
+	"github.com/cpmech/gosl/io"
+)
+
+func main() {
+
+	// catch errors
+	defer func() {
+		if err := recover(); err != nil {
+			io.PfRed("ERROR: %v\n", err)
+		}
+	}()
+
+	// input data
+	mshfn, fnkey := io.ArgToFilename(0, "data/d2-coarse", ".msh", true)
+	nparts := io.ArgToInt(1, 2)
+	dirout := io.ArgToString(2, "/tmp/gofem")
+
+	// print input table
+	io.Pf("\n%s\n", io.ArgsTable(
+		"mesh filename", "mshfn", mshfn,
+		"number of partitions", "nparts", nparts,
+		"directory for output", "dirout", dirout,
+	))
+
+	// read mesh
+	msh, err := inp.ReadMsh("", mshfn, 0)
+	if err != nil {
+		io.PfRed("cannot read mesh:\n%v", err)
+		return
+	}
+	if len(msh.Nurbss) > 0 {
+		io.PfRed("cannot write meshes with NURBS yet\n")
+		return
+	}
+
+	// partition mesh
+	err = msh.Partition(nparts)
+	if err != nil {
+		io.PfRed("cannot partition mesh:\n%v", err)
+		return
+	}
+	for p := 0; p < nparts; p++ {
+		io.Pf("partition %3d: %6d cells\n", p, len(msh.Part2cells[p]))
+	}
+
+	// write mesh
+	fn := io.Sf("%s-p%d.msh", fnkey, nparts)
+	io.WriteFileSD(dirout, fn, msh.String()+"\n")
+	io.Pf("file <%s> written\n", filepath.Join(dirout, fn))
+}